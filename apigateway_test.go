@@ -0,0 +1,52 @@
+package aws_signing_client
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// TestWithAPIGatewayMapping ensures that requests to a custom domain are sent as they are but signed over the
+// mapped host and path.
+func TestWithAPIGatewayMapping(t *testing.T) {
+	var sent *http.Request
+	c, err := New(v4.NewSigner(creds), &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent = req
+		return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+	})}, "execute-api", "us-east-1", nil, WithAPIGatewayMapping(APIGatewayMapping{
+		Host:        "api.example.com",
+		BasePath:    "/orders/",
+		SigningHost: "abc123.execute-api.us-east-1.amazonaws.com",
+		Stage:       "prod",
+	}))
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+
+	for _, tc := range []struct{ url, signedURL string }{
+		{"https://api.example.com/orders/42%2F1?x=1", "https://abc123.execute-api.us-east-1.amazonaws.com/prod/42%2F1?x=1"},
+		{"https://api.example.com/orders", "https://abc123.execute-api.us-east-1.amazonaws.com/prod/"},
+		{"https://api.example.com/ordersx/1", "https://api.example.com/ordersx/1"},
+		{"https://other.example.com/orders/1", "https://other.example.com/orders/1"},
+	} {
+		if _, err := c.Get(tc.url); err != nil {
+			t.Fatalf("An unexpected error occurred while making a request: %s", err)
+		}
+		if sent.URL.String() != tc.url || sent.Host != "" && sent.Host != sent.URL.Host {
+			t.Errorf("%s: request was sent to %s (Host %q)", tc.url, sent.URL, sent.Host)
+		}
+
+		want, _ := http.NewRequest("GET", tc.signedURL, nil)
+		want.Header = sent.Header.Clone()
+		want.Header.Del("Authorization")
+		signTime, _ := time.Parse("20060102T150405Z", sent.Header.Get("X-Amz-Date"))
+		v4.NewSigner(creds).Sign(want, nil, "execute-api", "us-east-1", signTime)
+		if got := sent.Header.Get("Authorization"); got != want.Header.Get("Authorization") {
+			t.Errorf("%s: expected a signature over %s, got %s", tc.url, tc.signedURL, got)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package aws_signing_client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+//      _             _ _ _
+//     / \  _   _  __| (_) |_
+//    / _ \| | | |/ _` | | __|
+//   / ___ \ |_| | (_| | | |_
+//  /_/   \_\__,_|\__,_|_|\__|
+//
+
+// TestWithAuditLog ensures that one JSON record is written per signed request, identifying the principal without
+// leaking secrets.
+func TestWithAuditLog(t *testing.T) {
+	var buf bytes.Buffer
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		return response(201, "", nil), nil
+	}, WithAuditLog(&buf))
+
+	ctx := WithRequestTags(context.Background(), map[string]string{"caller": "indexer"})
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequestWithContext(ctx, "PUT", "https://example.com/index/_doc/1", strings.NewReader("{}"))
+		if _, err := c.Do(req); err != nil {
+			t.Fatalf("An unexpected error occurred: %s", err)
+		}
+	}
+
+	if strings.Contains(buf.String(), "SECRET") || strings.Contains(buf.String(), "TOKEN") {
+		t.Errorf("Audit log contains credentials: %s", buf.String())
+	}
+	sc := bufio.NewScanner(&buf)
+	records := 0
+	for sc.Scan() {
+		records++
+		var rec AuditRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			t.Fatalf("Audit record is not valid JSON: %s", err)
+		}
+		switch {
+		case rec.AccessKeyID != "ID":
+			t.Errorf("Unexpected access key ID: %q", rec.AccessKeyID)
+		case rec.Method != "PUT" || rec.Host != "example.com" || rec.Path != "/index/_doc/1":
+			t.Errorf("Unexpected request in audit record: %+v", rec)
+		case rec.Service != "es" || rec.Region != "us-east-1" || rec.StatusCode != 201:
+			t.Errorf("Unexpected scope or status in audit record: %+v", rec)
+		case rec.Time.IsZero() || rec.Tags["caller"] != "indexer":
+			t.Errorf("Missing time or tags in audit record: %+v", rec)
+		}
+	}
+	if records != 2 {
+		t.Errorf("Expected 2 audit records, got %d", records)
+	}
+}
+
+// TestAccessKeyID tests extracting the access key ID from an Authorization header.
+func TestAccessKeyID(t *testing.T) {
+	h := http.Header{"Authorization": {"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, SignedHeaders=host, Signature=abc"}}
+	if id := accessKeyID(h); id != "AKIDEXAMPLE" {
+		t.Errorf("Unexpected access key ID: %q", id)
+	}
+	if id := accessKeyID(http.Header{}); id != "" {
+		t.Errorf("Unexpected access key ID for an unsigned request: %q", id)
+	}
+}
@@ -0,0 +1,227 @@
+package aws_signing_client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// MaxErrorBodySize is the maximum number of bytes of an error response body that are read for parsing. The rest of
+// the body is still available to the caller.
+const MaxErrorBodySize = 1 << 20
+
+type (
+	// AWSError describes a non-2xx response from an AWS endpoint. The error body is parsed for the formats used
+	// by the AWS JSON and XML (REST, query and EC2) protocols, API Gateway and the Amazon Elasticsearch/OpenSearch
+	// Service; the raw body is kept in Body for anything else.
+	AWSError struct {
+		StatusCode int
+		Code       string
+		Message    string
+		RequestID  string
+		Body       []byte
+		// SignatureMismatch is set for SignatureDoesNotMatch errors diagnosed by WithSignatureDiagnostics.
+		SignatureMismatch *SignatureMismatch
+	}
+
+	// errorBody replaces the body of a response inspected by WithErrorParsing, replaying the bytes that were read
+	// for parsing.
+	errorBody struct {
+		io.Reader
+		closer io.Closer
+		err    *AWSError
+	}
+
+	xmlErrorDetail struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+
+	// xmlError matches the root element of S3-style <Error>, query-protocol <ErrorResponse> and EC2-style
+	// <Response> error documents.
+	xmlError struct {
+		xmlErrorDetail
+		RequestID      string          `xml:"RequestId"`
+		RequestIDUpper string          `xml:"RequestID"`
+		Error          *xmlErrorDetail `xml:"Error"`
+		Errors         struct {
+			Error []xmlErrorDetail `xml:"Error"`
+		} `xml:"Errors"`
+	}
+)
+
+// NewAWSError builds an *AWSError from a non-2xx response and its already-read body.
+func NewAWSError(resp *http.Response, body []byte) *AWSError {
+	e := &AWSError{
+		StatusCode: resp.StatusCode,
+		RequestID:  requestID(resp.Header),
+		Body:       body,
+	}
+	if t := resp.Header.Get("X-Amzn-Errortype"); t != "" {
+		e.Code = strings.SplitN(t, ":", 2)[0]
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '<' {
+		e.parseXML(trimmed)
+	} else {
+		e.parseJSON(trimmed)
+	}
+	return e
+}
+
+// WithErrorParsing makes the Signer parse the body of every non-2xx response into an *AWSError, which is logged and
+// can be retrieved with ResponseError. The response body is preserved for the caller.
+func WithErrorParsing() Option {
+	return func(s *Signer) {
+		s.parseErrors = true
+	}
+}
+
+// ResponseError returns the *AWSError parsed from resp by a Signer with WithErrorParsing, or nil if resp was not
+// inspected or has a 2xx status.
+func ResponseError(resp *http.Response) *AWSError {
+	if resp == nil {
+		return nil
+	}
+	body := resp.Body
+	for {
+		switch b := body.(type) {
+		case *errorBody:
+			return b.err
+		case *cancelBody:
+			body = b.ReadCloser
+		case *validatingBody:
+			body = b.ReadCloser
+		default:
+			return nil
+		}
+	}
+}
+
+// responseError returns the *AWSError a Signer already parsed from resp, or else parses body.
+func responseError(resp *http.Response, body []byte) *AWSError {
+	if awsErr := ResponseError(resp); awsErr != nil {
+		return awsErr
+	}
+	return NewAWSError(resp, body)
+}
+
+// inspectError parses the body of a non-2xx response and replaces resp.Body with one that replays it.
+func (s *Signer) inspectError(ctx context.Context, resp *http.Response) *AWSError {
+	d, err := ioutil.ReadAll(io.LimitReader(resp.Body, MaxErrorBodySize))
+	if err != nil {
+		s.logf(ctx, "Error while attempting to read error response body: '%s'", err)
+	}
+	awsErr := NewAWSError(resp, d)
+	resp.Body = &errorBody{
+		Reader: io.MultiReader(bytes.NewReader(d), resp.Body),
+		closer: resp.Body,
+		err:    awsErr,
+	}
+	s.logf(ctx, "Received AWS error response: %s", awsErr)
+	return awsErr
+}
+
+// Close implements io.Closer.
+func (eb *errorBody) Close() error {
+	return eb.closer.Close()
+}
+
+func (e *AWSError) parseJSON(body []byte) {
+	var doc struct {
+		Type         string          `json:"__type"`
+		Code         string          `json:"code"`
+		Message      string          `json:"message"`
+		MessageUpper string          `json:"Message"`
+		Error        json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(body, &doc) != nil {
+		return
+	}
+	switch {
+	case doc.Type != "":
+		// The AWS JSON protocols prefix the code with a namespace, e.g. "com.amazonaws.es#ValidationException".
+		e.Code = doc.Type[strings.LastIndex(doc.Type, "#")+1:]
+	case doc.Code != "" && e.Code == "":
+		e.Code = doc.Code
+	}
+	e.Message = doc.Message
+	if e.Message == "" {
+		e.Message = doc.MessageUpper
+	}
+
+	// Elasticsearch/OpenSearch errors are either a string or an object with a type and a reason.
+	if len(doc.Error) > 0 {
+		var es struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		}
+		var s string
+		switch {
+		case json.Unmarshal(doc.Error, &es) == nil:
+			if e.Code == "" {
+				e.Code = es.Type
+			}
+			if e.Message == "" {
+				e.Message = es.Reason
+			}
+		case json.Unmarshal(doc.Error, &s) == nil && e.Message == "":
+			e.Message = s
+		}
+	}
+}
+
+func (e *AWSError) parseXML(body []byte) {
+	var doc xmlError
+	if xml.Unmarshal(body, &doc) != nil {
+		return
+	}
+	detail := doc.xmlErrorDetail
+	switch {
+	case doc.Error != nil:
+		detail = *doc.Error
+	case len(doc.Errors.Error) > 0:
+		detail = doc.Errors.Error[0]
+	}
+	if detail.Code != "" {
+		e.Code = detail.Code
+	}
+	e.Message = detail.Message
+	if e.RequestID == "" {
+		e.RequestID = doc.RequestID
+	}
+	if e.RequestID == "" {
+		e.RequestID = doc.RequestIDUpper
+	}
+}
+
+func requestID(h http.Header) string {
+	for _, k := range []string{"X-Amzn-Requestid", "X-Amz-Request-Id", "X-Amz-Apigw-Id"} {
+		if v := h.Get(k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Error implements the error interface.
+func (err *AWSError) Error() string {
+	msg := fmt.Sprintf("AWS request failed with status %d", err.StatusCode)
+	if err.Code != "" {
+		msg += " (" + err.Code + ")"
+	}
+	if err.Message != "" {
+		msg += ": " + err.Message
+	}
+	if err.RequestID != "" {
+		msg += " [request ID: " + err.RequestID + "]"
+	}
+	return msg
+}
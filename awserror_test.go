@@ -0,0 +1,138 @@
+package aws_signing_client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+//      ___        ______    _____
+//     / \ \      / / ___|  | ____|_ __ _ __ ___  _ __ ___
+//    / _ \ \ /\ / /\___ \  |  _| | '__| '__/ _ \| '__/ __|
+//   / ___ \ V  V /  ___) | | |___| |  | | | (_) | |  \__ \
+//  /_/   \_\_/\_/  |____/  |_____|_|  |_|  \___/|_|  |___/
+//
+
+// TestNewAWSErrorXML ensures that the S3, query-protocol and EC2 XML error formats are parsed.
+func TestNewAWSErrorXML(t *testing.T) {
+	cases := []struct {
+		body, code, message, requestID string
+	}{
+		{`<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message><RequestId>s3-req</RequestId></Error>`, "NoSuchKey", "The specified key does not exist.", "s3-req"},
+		{`<ErrorResponse><Error><Type>Sender</Type><Code>Throttling</Code><Message>Rate exceeded</Message></Error><RequestId>sts-req</RequestId></ErrorResponse>`, "Throttling", "Rate exceeded", "sts-req"},
+		{`<Response><Errors><Error><Code>InvalidInstanceID.NotFound</Code><Message>not found</Message></Error></Errors><RequestID>ec2-req</RequestID></Response>`, "InvalidInstanceID.NotFound", "not found", "ec2-req"},
+		{`  <html>broken`, "", "", ""},
+	}
+	for _, c := range cases {
+		err := NewAWSError(response(400, c.body, nil), []byte(c.body))
+		if err.Code != c.code || err.Message != c.message || err.RequestID != c.requestID {
+			t.Errorf("Unexpected code/message/request ID for %s: %q, %q, %q", c.body, err.Code, err.Message, err.RequestID)
+		}
+	}
+}
+
+// TestAWSErrorString tests the error message of an *AWSError.
+func TestAWSErrorString(t *testing.T) {
+	err := &AWSError{StatusCode: 403, Code: "AccessDenied", Message: "Access Denied", RequestID: "abc"}
+	if s := err.Error(); s != "AWS request failed with status 403 (AccessDenied): Access Denied [request ID: abc]" {
+		t.Errorf("Unexpected error message: %s", s)
+	}
+	if s := (&AWSError{StatusCode: 500}).Error(); s != "AWS request failed with status 500" {
+		t.Errorf("Unexpected error message: %s", s)
+	}
+}
+
+// TestWithErrorParsing ensures that error responses are parsed and logged while their body stays readable.
+func TestWithErrorParsing(t *testing.T) {
+	body := `<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`
+	status := 403
+	rl := &recordingLogger{}
+	c, err := New(v4.NewSigner(creds), &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return response(status, body, http.Header{"X-Amz-Request-Id": {"req-1"}}), nil
+	})}, "s3", "us-east-1", rl, WithErrorParsing())
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+
+	resp, err := c.Get("https://example.com/bucket/key")
+	if err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err)
+	}
+	awsErr := ResponseError(resp)
+	if awsErr == nil || awsErr.Code != "AccessDenied" || awsErr.RequestID != "req-1" {
+		t.Errorf("Unexpected parsed error: %+v", awsErr)
+	}
+	d, err := ioutil.ReadAll(resp.Body)
+	if err != nil || string(d) != body {
+		t.Errorf("Response body was not preserved: %q, %v", d, err)
+	}
+	if err := resp.Body.Close(); err != nil {
+		t.Errorf("An unexpected error occurred while closing the body: %s", err)
+	}
+	logged := strings.Join(rl.lines, "\n")
+	if !strings.Contains(logged, "(AccessDenied)") {
+		t.Errorf("Parsed error was not logged: %s", logged)
+	}
+
+	status = 200
+	resp, err = c.Get("https://example.com/bucket/key")
+	if err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err)
+	}
+	if awsErr := ResponseError(resp); awsErr != nil {
+		t.Errorf("A 2xx response should not be parsed: %+v", awsErr)
+	}
+}
+
+// TestWithErrorMode ensures that 4xx and 5xx responses are returned as an *AWSError, per client or per request.
+func TestWithErrorMode(t *testing.T) {
+	closed := 0
+	c, err := New(v4.NewSigner(creds), &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		resp := response(404, `{"__type":"ResourceNotFoundException","message":"Stream not found"}`, nil)
+		resp.Body = closeCounter{resp.Body, &closed}
+		if req.URL.Path == "/ok" {
+			resp.StatusCode = 200
+		}
+		return resp, nil
+	})}, "kinesis", "us-east-1", nil, WithErrorMode(ErrorModeError))
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+
+	resp, err := c.Get("https://example.com/missing")
+	var awsErr *AWSError
+	switch {
+	case resp != nil:
+		t.Error("Expected no response")
+	case !errors.As(err, &awsErr):
+		t.Fatalf("Expected an *AWSError, got %v", err)
+	case awsErr.StatusCode != 404 || awsErr.Code != "ResourceNotFoundException" || awsErr.Message != "Stream not found":
+		t.Errorf("Unexpected error: %+v", awsErr)
+	case closed != 1:
+		t.Error("The response body was not closed")
+	}
+	if resp, err := c.Get("https://example.com/ok"); err != nil || resp.StatusCode != 200 {
+		t.Errorf("Expected a 2xx response to be returned, got %v, %v", resp, err)
+	}
+
+	req, _ := http.NewRequestWithContext(WithRequestErrorMode(context.Background(), ErrorModeResponse), "GET", "https://example.com/missing", nil)
+	if resp, err := c.Do(req); err != nil || resp.StatusCode != 404 {
+		t.Errorf("Expected the request to override the error mode, got %v, %v", resp, err)
+	}
+}
+
+type closeCounter struct {
+	io.ReadCloser
+	closed *int
+}
+
+func (cc closeCounter) Close() error {
+	*cc.closed++
+	return cc.ReadCloser.Close()
+}
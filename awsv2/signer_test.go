@@ -0,0 +1,93 @@
+package awsv2
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+
+	"github.com/Nextdoor/aws_signing_client"
+	"github.com/Nextdoor/aws_signing_client/signingconformance"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+var creds = credentials.NewStaticCredentialsProvider("ID", "SECRET", "TOKEN")
+
+func client(t *testing.T, provider aws.CredentialsProvider, sent **http.Request, opts ...aws_signing_client.Option) *http.Client {
+	c, err := New(provider, &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		*sent = req
+		return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+	})}, "es", "us-east-1", nil, opts...)
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+	return c
+}
+
+// TestSignsPostRequest ensures that requests are signed by the aws-sdk-go-v2 backend.
+func TestSignsPostRequest(t *testing.T) {
+	var sent *http.Request
+	c := client(t, creds, &sent)
+	if _, err := c.Post("https://example.com/idx/_doc", "application/json", strings.NewReader("{}")); err != nil {
+		t.Fatalf("An unexpected error occurred while making a request: %s", err)
+	}
+	switch {
+	case !strings.HasPrefix(sent.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=ID/"):
+		t.Errorf("Request was not signed: %s", sent.Header.Get("Authorization"))
+	case sent.Header.Get("X-Amz-Security-Token") != "TOKEN" || sent.Header.Get("X-Amz-Date") == "":
+		t.Error("Signature headers were not set")
+	}
+}
+
+// TestSignedHeaderValues ensures that Sign() returns the values of the signed headers.
+func TestSignedHeaderValues(t *testing.T) {
+	req, _ := http.NewRequest("GET", "https://example.com/", nil)
+	req.Header.Set("X-Custom", "value")
+	vals, err := NewSigner(creds).Sign(req, nil, "es", "us-east-1", time.Now())
+	switch {
+	case err != nil:
+		t.Fatalf("An unexpected error occurred while signing: %s", err)
+	case vals.Get("X-Custom") != "value" || vals.Get("Host") != "example.com":
+		t.Errorf("Unexpected signed header values: %v", vals)
+	}
+}
+
+// TestAnonymous ensures that anonymous credentials are detected.
+func TestAnonymous(t *testing.T) {
+	if !NewSigner(aws.AnonymousCredentials{}).Anonymous(context.Background()) {
+		t.Error("aws.AnonymousCredentials were not detected as anonymous")
+	}
+	if NewSigner(creds).Anonymous(context.Background()) {
+		t.Error("Static credentials were detected as anonymous")
+	}
+}
+
+// TestConformance ensures that the aws-sdk-go-v2 backend passes the signing conformance suite.
+func TestConformance(t *testing.T) {
+	signingconformance.Run(t, NewSigner(credentials.NewStaticCredentialsProvider(signingconformance.AccessKeyID, signingconformance.SecretAccessKey, "")))
+}
+
+// TestPresign ensures that Presign() moves the signature into the query string.
+func TestPresign(t *testing.T) {
+	req, _ := http.NewRequest("GET", "https://bucket.s3.amazonaws.com/key", nil)
+	if _, err := NewSigner(creds).Presign(req, nil, "s3", "us-east-1", 15*time.Minute, time.Now()); err != nil {
+		t.Fatalf("An unexpected error occurred while presigning: %s", err)
+	}
+	q := req.URL.Query()
+	switch {
+	case q.Get("X-Amz-Signature") == "" || q.Get("X-Amz-Expires") != "900":
+		t.Errorf("Request was not presigned: %s", req.URL)
+	case req.Header.Get("Authorization") != "":
+		t.Error("Presigned request carries an Authorization header")
+	}
+}
@@ -0,0 +1,129 @@
+package aws_signing_client
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+type (
+	// Backoff decides how long the retry subsystem waits before retrying a request. NextDelay is called with the
+	// number of the attempt that just failed, counting from 1, and its error or non-2xx response. Implementations
+	// are shared by all requests of a Signer and must be safe for concurrent use.
+	Backoff interface {
+		NextDelay(attempt int, err error, resp *http.Response) time.Duration
+	}
+
+	// ExponentialBackoff waits a random duration between zero and Base doubled for every attempt, capped at Max
+	// ("full jitter"). It is the default Backoff. Zero fields take the values of DefaultRetryBaseDelay and
+	// DefaultRetryMaxDelay.
+	ExponentialBackoff struct {
+		Base time.Duration
+		Max  time.Duration
+	}
+
+	// EqualJitterBackoff is like ExponentialBackoff, but always waits at least half of the exponential delay, so
+	// that retries are never immediate.
+	EqualJitterBackoff struct {
+		Base time.Duration
+		Max  time.Duration
+	}
+
+	// DecorrelatedJitterBackoff waits a random duration between Base and three times the previous delay of the
+	// same request, capped at Max. Its delays grow more smoothly than ExponentialBackoff's while still spreading
+	// out clients that failed together.
+	DecorrelatedJitterBackoff struct {
+		Base time.Duration
+		Max  time.Duration
+	}
+
+	// backoffSequence is implemented by Backoffs that keep state across the attempts of one request. The retry
+	// subsystem calls newSequence once per request and uses the returned Backoff for its attempts.
+	backoffSequence interface {
+		newSequence() Backoff
+	}
+
+	decorrelatedSequence struct {
+		DecorrelatedJitterBackoff
+		prev time.Duration
+	}
+)
+
+// WithBackoff sets the Backoff used between the attempts of requests retried with WithRetries.
+func WithBackoff(b Backoff) Option {
+	return func(s *Signer) {
+		s.retry.backoff = b
+	}
+}
+
+// NextDelay implements Backoff.
+func (b ExponentialBackoff) NextDelay(attempt int, err error, resp *http.Response) time.Duration {
+	return jitter(0, exponentialDelay(b.Base, b.Max, attempt))
+}
+
+// NextDelay implements Backoff.
+func (b EqualJitterBackoff) NextDelay(attempt int, err error, resp *http.Response) time.Duration {
+	d := exponentialDelay(b.Base, b.Max, attempt)
+	return jitter(d/2, d)
+}
+
+// NextDelay implements Backoff. Without the per-request state the retry subsystem keeps, the previous delay is
+// assumed to have been the largest possible one.
+func (b DecorrelatedJitterBackoff) NextDelay(attempt int, err error, resp *http.Response) time.Duration {
+	base, max := backoffLimits(b.Base, b.Max)
+	prev := base
+	for i := 1; i < attempt && prev < max; i++ {
+		prev *= 3
+	}
+	return jitter(base, capDelay(prev*3, max))
+}
+
+func (b DecorrelatedJitterBackoff) newSequence() Backoff {
+	return &decorrelatedSequence{DecorrelatedJitterBackoff: b}
+}
+
+// NextDelay implements Backoff.
+func (ds *decorrelatedSequence) NextDelay(attempt int, err error, resp *http.Response) time.Duration {
+	base, max := backoffLimits(ds.Base, ds.Max)
+	if ds.prev < base {
+		ds.prev = base
+	}
+	ds.prev = jitter(base, capDelay(ds.prev*3, max))
+	return ds.prev
+}
+
+// backoffLimits applies the defaults to a Backoff's base and maximum delay.
+func backoffLimits(base, max time.Duration) (time.Duration, time.Duration) {
+	if base <= 0 {
+		base = DefaultRetryBaseDelay
+	}
+	if max <= 0 {
+		max = DefaultRetryMaxDelay
+	}
+	return base, max
+}
+
+// exponentialDelay returns base doubled for every attempt after the first, capped at max.
+func exponentialDelay(base, max time.Duration, attempt int) time.Duration {
+	base, max = backoffLimits(base, max)
+	d := base
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	return capDelay(d, max)
+}
+
+func capDelay(d, max time.Duration) time.Duration {
+	if d > max || d <= 0 {
+		return max
+	}
+	return d
+}
+
+// jitter returns a random duration in [min, max].
+func jitter(min, max time.Duration) time.Duration {
+	if max <= min {
+		return min
+	}
+	return min + time.Duration(rand.Int63n(int64(max-min)+1))
+}
@@ -0,0 +1,51 @@
+package aws_signing_client
+
+import (
+	"testing"
+	"time"
+)
+
+//   ____             _          __  __
+//  | __ )  __ _  ___| | _____  / _|/ _|
+//  |  _ \ / _` |/ __| |/ / _ \| |_| |_
+//  | |_) | (_| | (__|   < (_) |  _|  _|
+//  |____/ \__,_|\___|_|\_\___/|_| |_|
+//
+
+// TestBackoffBounds tests that the provided Backoffs stay within their bounds.
+func TestBackoffBounds(t *testing.T) {
+	base, max := 10*time.Millisecond, 100*time.Millisecond
+	for attempt := 1; attempt <= 40; attempt++ {
+		exp := exponentialDelay(base, max, attempt)
+		if d := (ExponentialBackoff{Base: base, Max: max}).NextDelay(attempt, nil, nil); d < 0 || d > exp {
+			t.Errorf("ExponentialBackoff delay %s for attempt %d is outside [0, %s]", d, attempt, exp)
+		}
+		if d := (EqualJitterBackoff{Base: base, Max: max}).NextDelay(attempt, nil, nil); d < exp/2 || d > exp {
+			t.Errorf("EqualJitterBackoff delay %s for attempt %d is outside [%s, %s]", d, attempt, exp/2, exp)
+		}
+		if d := (DecorrelatedJitterBackoff{Base: base, Max: max}).NextDelay(attempt, nil, nil); d < base || d > max {
+			t.Errorf("DecorrelatedJitterBackoff delay %s for attempt %d is outside [%s, %s]", d, attempt, base, max)
+		}
+	}
+	if d := exponentialDelay(base, max, 3); d != 40*time.Millisecond {
+		t.Errorf("Unexpected exponential delay for attempt 3: %s", d)
+	}
+	if d := exponentialDelay(0, 0, 1); d != DefaultRetryBaseDelay {
+		t.Errorf("Defaults were not applied: %s", d)
+	}
+}
+
+// TestDecorrelatedJitterSequence tests that the per-request sequence of DecorrelatedJitterBackoff grows from its
+// previous delay.
+func TestDecorrelatedJitterSequence(t *testing.T) {
+	base, max := 10*time.Millisecond, time.Hour
+	seq := DecorrelatedJitterBackoff{Base: base, Max: max}.newSequence()
+	prev := base
+	for attempt := 1; attempt <= 10; attempt++ {
+		d := seq.NextDelay(attempt, nil, nil)
+		if d < base || d > prev*3 {
+			t.Errorf("Delay %s for attempt %d is outside [%s, %s]", d, attempt, base, prev*3)
+		}
+		prev = d
+	}
+}
@@ -0,0 +1,109 @@
+package aws_signing_client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// UnsignedPayload is the payload hash of requests whose body is not covered by the signature.
+const UnsignedPayload = "UNSIGNED-PAYLOAD"
+
+type (
+	// BodyTooLargeError is an implementation of the error interface that is returned by RoundTrip for requests whose
+	// body is larger than the limit set with WithMaxBufferedBody.
+	BodyTooLargeError struct {
+		// Limit is the largest body that is buffered, in bytes.
+		Limit int64
+		// Size is the Content-Length of the request, or -1 if it is unknown.
+		Size int64
+	}
+
+	// multiReadCloser reads from Reader and closes the original request body.
+	multiReadCloser struct {
+		io.Reader
+		io.Closer
+	}
+)
+
+// WithMaxBufferedBody limits the size of request bodies that are read into memory to be hashed for the signature.
+// Requests with a larger body fail with a *BodyTooLargeError before anything is sent; those with a Content-Length
+// over the limit fail without their body being read. A shared service is thereby protected from running out of
+// memory on an accidental giant payload. See WithBodySpooling and WithUnsignedPayloadFallback for sending such
+// requests anyway.
+func WithMaxBufferedBody(limit int64) Option {
+	return func(s *Signer) {
+		s.maxBufferedBody = limit
+	}
+}
+
+// WithUnsignedPayloadFallback makes requests whose body is larger than the limit set with WithMaxBufferedBody be
+// signed with an unsigned payload (X-Amz-Content-Sha256: UNSIGNED-PAYLOAD) and their body streamed rather than
+// failing. Only services that accept unsigned payloads, such as S3, accept such requests. They are sent once,
+// without retries, since their body cannot be replayed.
+func WithUnsignedPayloadFallback() Option {
+	return func(s *Signer) {
+		s.unsignedPayloadFallback = true
+	}
+}
+
+// readBody reads the body of req for signing. If the body is larger than the limit set with WithMaxBufferedBody, it
+// is spooled to disk if WithBodySpooling is used; otherwise over is true and req.Body is left ready to be streamed,
+// unless a *BodyTooLargeError is returned.
+func (s *Signer) readBody(ctx context.Context, req *http.Request) (p *payload, over bool, err error) {
+	if s.maxBufferedBody <= 0 {
+		d, err := readAll(req.Body)
+		return &payload{data: d}, false, err
+	}
+	var d []byte
+	if req.ContentLength <= s.maxBufferedBody {
+		d, err = readAll(io.LimitReader(req.Body, s.maxBufferedBody+1))
+		if err != nil || int64(len(d)) <= s.maxBufferedBody {
+			return &payload{data: d}, false, err
+		}
+	}
+	rest := io.MultiReader(bytes.NewReader(d), req.Body)
+	if s.spoolBodies {
+		p, err := s.spool(ctx, req, rest)
+		return p, false, err
+	}
+	req.Body = multiReadCloser{Reader: rest, Closer: req.Body}
+	return nil, true, s.bodyTooLarge(ctx, req)
+}
+
+// bodyTooLarge prepares req to be sent with an unsigned payload if WithUnsignedPayloadFallback is used, and returns
+// a *BodyTooLargeError otherwise.
+func (s *Signer) bodyTooLarge(ctx context.Context, req *http.Request) error {
+	if s.unsignedPayloadFallback {
+		s.logf(ctx, "Request body exceeds %d bytes. Signing with an unsigned payload.", s.maxBufferedBody)
+		req.Header.Set("X-Amz-Content-Sha256", UnsignedPayload)
+		return nil
+	}
+	req.Body.Close()
+	size := req.ContentLength
+	if size == 0 {
+		size = -1
+	}
+	s.logf(ctx, "Request body exceeds %d bytes. Refusing to send request.", s.maxBufferedBody)
+	return &BodyTooLargeError{Limit: s.maxBufferedBody, Size: size}
+}
+
+// readAll reads r to the end. An empty body is returned as a non-nil d, since a nil d means no body.
+func readAll(r io.Reader) ([]byte, error) {
+	d, err := ioutil.ReadAll(r)
+	if d == nil {
+		d = []byte{}
+	}
+	return d, err
+}
+
+// Error implements the error interface.
+func (err *BodyTooLargeError) Error() string {
+	if err.Size < 0 {
+		return fmt.Sprintf("The request body exceeds the limit of %d bytes. Cannot sign request.", err.Limit)
+	}
+	return fmt.Sprintf("The request body of %d bytes exceeds the limit of %d bytes. Cannot sign request.", err.Size, err.Limit)
+}
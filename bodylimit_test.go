@@ -0,0 +1,60 @@
+package aws_signing_client
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// TestWithMaxBufferedBody ensures that bodies over the limit are refused, or streamed with an unsigned payload.
+func TestWithMaxBufferedBody(t *testing.T) {
+	for _, fallback := range []bool{false, true} {
+		var sentBody []byte
+		var sent *http.Request
+		opts := []Option{WithMaxBufferedBody(8), WithRetries(3)}
+		if fallback {
+			opts = append(opts, WithUnsignedPayloadFallback())
+		}
+		c, err := New(v4.NewSigner(creds), &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			sent, sentBody = req, nil
+			if req.Body != nil {
+				sentBody, _ = ioutil.ReadAll(req.Body)
+			}
+			return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+		})}, "s3", "us-east-1", nil, opts...)
+		if err != nil {
+			t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+		}
+
+		if _, err := c.Post("https://example.com/small", "text/plain", strings.NewReader("12345678")); err != nil {
+			t.Fatalf("An unexpected error occurred while sending a small body: %s", err)
+		}
+		if sent.Header.Get("X-Amz-Content-Sha256") == UnsignedPayload {
+			t.Error("A body within the limit was sent with an unsigned payload")
+		}
+
+		sent = nil
+		// An io.Reader without a known length is read up to the limit before the request is refused.
+		_, err = c.Post("https://example.com/large", "text/plain", ioutil.NopCloser(strings.NewReader("123456789")))
+		var btl *BodyTooLargeError
+		switch {
+		case !fallback && (!errors.As(err, &btl) || btl.Limit != 8 || btl.Size != -1 || sent != nil):
+			t.Errorf("Expected a *BodyTooLargeError without sending, got %v", err)
+		case fallback && err != nil:
+			t.Errorf("An unexpected error occurred while sending a large body: %s", err)
+		case fallback && (string(sentBody) != "123456789" || sent.Header.Get("X-Amz-Content-Sha256") != UnsignedPayload):
+			t.Errorf("Expected the body to be streamed with an unsigned payload, got %q (%s)", sentBody, sent.Header.Get("X-Amz-Content-Sha256"))
+		}
+	}
+
+	c, _ := New(v4.NewSigner(creds), &http.Client{}, "s3", "us-east-1", nil, WithMaxBufferedBody(8))
+	_, err := c.Post("https://example.com/large", "text/plain", strings.NewReader("123456789"))
+	var btl *BodyTooLargeError
+	if !errors.As(err, &btl) || btl.Size != 9 {
+		t.Errorf("Expected a *BodyTooLargeError with the Content-Length, got %v", err)
+	}
+}
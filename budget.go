@@ -0,0 +1,130 @@
+package aws_signing_client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+type (
+	// BudgetExceededError is an implementation of the error interface that is returned by RoundTrip when a request
+	// could not complete within the latency budget set with WithLatencyBudget or WithDeadlineReserve.
+	BudgetExceededError struct {
+		// Budget is the time the request was allowed to take.
+		Budget time.Duration
+		// Err is the error of the last attempt, if any.
+		Err error
+	}
+
+	// budgetKey is the context key of the requestBudget of a request.
+	budgetKey struct{}
+
+	requestBudget struct {
+		budget   time.Duration
+		deadline time.Time
+	}
+
+	// cancelBody cancels the context of a request that was sent with a latency budget once its body is closed.
+	cancelBody struct {
+		io.ReadCloser
+		cancel context.CancelFunc
+	}
+)
+
+// WithLatencyBudget bounds the time RoundTrip may take, including buffering the body, signing, every retry attempt
+// and the backoff between them, until the response headers are received. Requests that exceed d fail with a
+// *BudgetExceededError. Reading the response body is not bounded by the budget.
+func WithLatencyBudget(d time.Duration) Option {
+	return func(s *Signer) {
+		s.latencyBudget = d
+	}
+}
+
+// WithDeadlineReserve bounds RoundTrip like WithLatencyBudget, but derives the budget from the deadline of the
+// request's context, keeping reserve for the caller to process the response before the deadline. It has no effect on
+// requests without a context deadline. If WithLatencyBudget is also given, the earlier deadline applies.
+func WithDeadlineReserve(reserve time.Duration) Option {
+	return func(s *Signer) {
+		s.deadlineReserve = reserve
+		s.useDeadlineReserve = true
+	}
+}
+
+// budgetDeadline returns the deadline of a request started at start, if it has a latency budget.
+func (s *Signer) budgetDeadline(ctx context.Context, start time.Time) (deadline time.Time, ok bool) {
+	if s.latencyBudget > 0 {
+		deadline, ok = start.Add(s.latencyBudget), true
+	}
+	if d, hasDeadline := ctx.Deadline(); s.useDeadlineReserve && hasDeadline {
+		if d = d.Add(-s.deadlineReserve); !ok || d.Before(deadline) {
+			deadline, ok = d, true
+		}
+	}
+	return deadline, ok
+}
+
+// roundTripWithBudget runs roundTrip, canceling it once the request's deadline has passed.
+func (s *Signer) roundTripWithBudget(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	start := time.Now()
+	deadline, ok := s.budgetDeadline(ctx, start)
+	if !ok {
+		return s.roundTrip(req)
+	}
+	budget := deadline.Sub(start)
+	if budget <= 0 {
+		s.logf(ctx, "Latency budget is exhausted. Refusing to send request.")
+		return nil, &BudgetExceededError{Budget: budget}
+	}
+
+	bctx, cancel := context.WithCancel(context.WithValue(ctx, budgetKey{}, requestBudget{budget: budget, deadline: deadline}))
+	timer := time.AfterFunc(budget, cancel)
+	resp, err := s.roundTrip(req.WithContext(bctx))
+	if !timer.Stop() && err != nil && ctx.Err() == nil {
+		cancel()
+		s.logf(ctx, "Latency budget of %d ms exceeded.", budget/time.Millisecond)
+		if _, ok := err.(*BudgetExceededError); !ok {
+			err = &BudgetExceededError{Budget: budget, Err: err}
+		}
+		return nil, err
+	}
+	if resp == nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, err
+}
+
+// budgetExceeded returns a *BudgetExceededError if waiting for delay would exceed the latency budget of the request
+// with context ctx, or nil.
+func budgetExceeded(ctx context.Context, delay time.Duration, cause error) error {
+	b, ok := ctx.Value(budgetKey{}).(requestBudget)
+	if !ok || time.Now().Add(delay).Before(b.deadline) {
+		return nil
+	}
+	return &BudgetExceededError{Budget: b.budget, Err: cause}
+}
+
+// Close implements io.Closer.
+func (cb *cancelBody) Close() error {
+	err := cb.ReadCloser.Close()
+	cb.cancel()
+	return err
+}
+
+// Error implements the error interface.
+func (err *BudgetExceededError) Error() string {
+	msg := fmt.Sprintf("Latency budget of %s exceeded", err.Budget)
+	if err.Err != nil {
+		msg += ": " + err.Err.Error()
+	}
+	return msg + "."
+}
+
+// Unwrap returns the error of the last attempt.
+func (err *BudgetExceededError) Unwrap() error {
+	return err.Err
+}
@@ -0,0 +1,97 @@
+package aws_signing_client
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+//   ____            _            _
+//  | __ ) _   _  __| | __ _  ___| |_
+//  |  _ \| | | |/ _` |/ _` |/ _ \ __|
+//  | |_) | |_| | (_| | (_| |  __/ |_
+//  |____/ \__,_|\__,_|\__, |\___|\__|
+//                     |___/
+
+// TestWithLatencyBudget ensures that a request that exceeds its budget is canceled with a *BudgetExceededError.
+func TestWithLatencyBudget(t *testing.T) {
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	}, WithLatencyBudget(20*time.Millisecond))
+
+	start := time.Now()
+	_, err := c.Get("https://example.com/")
+	var budgetErr *BudgetExceededError
+	switch {
+	case !errors.As(err, &budgetErr):
+		t.Errorf("Expected a *BudgetExceededError, got %v", err)
+	case budgetErr.Budget != 20*time.Millisecond || !errors.Is(err, context.Canceled):
+		t.Errorf("Unexpected budget or cause: %+v", budgetErr)
+	case time.Since(start) > time.Second:
+		t.Errorf("Request was not canceled at the deadline: %s", time.Since(start))
+	}
+}
+
+// TestWithLatencyBudgetRetries ensures that retries are not attempted when their backoff would exceed the budget.
+func TestWithLatencyBudgetRetries(t *testing.T) {
+	attempts := 0
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		attempts++
+		return response(503, `{"__type":"ServiceUnavailableException"}`, nil), nil
+	}, WithRetries(5), WithLatencyBudget(time.Second), WithBackoff(constantBackoff(time.Hour)))
+
+	start := time.Now()
+	_, err := c.Get("https://example.com/")
+	var awsErr *AWSError
+	switch {
+	case !errors.As(err, &awsErr) || awsErr.Code != "ServiceUnavailableException":
+		t.Errorf("Expected a *BudgetExceededError wrapping the last response's error, got %v", err)
+	case attempts != 1 || time.Since(start) > 500*time.Millisecond:
+		t.Errorf("Expected to give up after 1 attempt without waiting, got %d after %s", attempts, time.Since(start))
+	}
+}
+
+// TestWithDeadlineReserve ensures that the budget is derived from the context deadline, and that the response body
+// remains readable after the deadline has passed.
+func TestWithDeadlineReserve(t *testing.T) {
+	sent := 0
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		sent++
+		return response(200, "ok", nil), nil
+	}, WithDeadlineReserve(time.Second))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/", nil)
+	var budgetErr *BudgetExceededError
+	if _, err := c.Do(req); !errors.As(err, &budgetErr) || sent != 0 {
+		t.Errorf("Expected a *BudgetExceededError without sending, got %v after %d requests", err, sent)
+	}
+
+	resp, err := c.Get("https://example.com/")
+	if err != nil {
+		t.Fatalf("Requests without a deadline should not be bounded: %s", err)
+	}
+	d, err := ioutil.ReadAll(resp.Body)
+	if err != nil || string(d) != "ok" {
+		t.Errorf("Unexpected body: %q, %v", d, err)
+	}
+	resp.Body.Close()
+
+	c, _ = newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		return response(200, "ok", nil), nil
+	}, WithLatencyBudget(10*time.Millisecond))
+	resp, err = c.Get("https://example.com/")
+	if err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if d, err := ioutil.ReadAll(resp.Body); err != nil || string(d) != "ok" {
+		t.Errorf("Body was not readable after the budget passed: %q, %v", d, err)
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,271 @@
+package aws_signing_client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+const (
+	// DefaultBulkBatchSize is the default number of uncompressed bytes of NDJSON a BulkIndexer sends per request.
+	DefaultBulkBatchSize = 5 << 20
+	// DefaultBulkMaxAttempts is the default number of times a BulkIndexer sends an item that keeps failing with a
+	// retryable status.
+	DefaultBulkMaxAttempts = 3
+)
+
+type (
+	// BulkIndexer sends documents to the OpenSearch or Elasticsearch _bulk API over an *http.Client, typically one
+	// returned by New. Operations are encoded as NDJSON, split into batches, gzip-compressed and signed. Items that
+	// fail with a 429 or 5xx status are retried on their own, with a backoff between rounds.
+	BulkIndexer struct {
+		// Client is the HTTP client used to send requests. If nil, http.DefaultClient is used.
+		Client *http.Client
+		// URL is the _bulk endpoint, e.g. "https://my-domain.us-east-1.es.amazonaws.com/_bulk".
+		URL string
+		// BatchSize is the maximum number of uncompressed bytes per request. A single larger item is sent on its
+		// own. Zero means DefaultBulkBatchSize.
+		BatchSize int
+		// MaxAttempts is the number of times an item is sent before its failure is final. Zero means
+		// DefaultBulkMaxAttempts.
+		MaxAttempts int
+		// Backoff decides the delay between rounds of retries. If nil, ExponentialBackoff is used.
+		Backoff Backoff
+		// DisableCompression sends request bodies uncompressed.
+		DisableCompression bool
+	}
+
+	// BulkItem is a single bulk operation.
+	BulkItem struct {
+		// Action is "index", "create", "update" or "delete".
+		Action string
+		Index  string
+		ID     string
+		// Document is marshalled as the source line of the operation. It is ignored for "delete"; for "update" it
+		// must hold the update body, e.g. {"doc": ...}.
+		Document interface{}
+	}
+
+	// BulkFailure describes an item the _bulk API did not apply.
+	BulkFailure struct {
+		Item   BulkItem
+		Status int
+		Type   string
+		Reason string
+	}
+
+	// BulkError is returned by BulkIndexer.Index when some items failed permanently or are still failing after
+	// MaxAttempts.
+	BulkError struct {
+		Failures []BulkFailure
+		// Total is the number of items that were submitted, including those that failed.
+		Total int
+	}
+
+	bulkResponse struct {
+		Errors bool                              `json:"errors"`
+		Items  []map[string]bulkResponseItemBody `json:"items"`
+	}
+
+	bulkResponseItemBody struct {
+		Status int `json:"status"`
+		Error  *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	}
+)
+
+// NewBulkIndexer returns a BulkIndexer that sends operations to url using the provided client.
+func NewBulkIndexer(client *http.Client, url string) *BulkIndexer {
+	return &BulkIndexer{Client: client, URL: url}
+}
+
+// Index sends items and retries those that fail with a retryable status. It returns a *BulkError listing the items
+// that could not be applied, or the error of the first request that failed as a whole.
+func (b *BulkIndexer) Index(ctx context.Context, items []BulkItem) error {
+	lines := make([][]byte, len(items))
+	for i, item := range items {
+		line, err := encodeBulkItem(item)
+		if err != nil {
+			return err
+		}
+		lines[i] = line
+	}
+
+	backoff := b.Backoff
+	if backoff == nil {
+		backoff = ExponentialBackoff{}
+	}
+	maxAttempts := b.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultBulkMaxAttempts
+	}
+
+	pending := make([]int, len(items))
+	for i := range pending {
+		pending[i] = i
+	}
+	split := func(pending []int) [][]int { return b.batches(lines, pending) }
+	failed, err := retryItems(ctx, pending, maxAttempts, backoff, split, func(batch []int) ([]itemFailure[int, BulkFailure], error) {
+		failures, err := b.send(ctx, items, lines, batch)
+		results := make([]itemFailure[int, BulkFailure], len(failures))
+		for i, f := range failures {
+			retryable := f.index >= 0 && (f.Status == http.StatusTooManyRequests || f.Status >= 500)
+			results[i] = itemFailure[int, BulkFailure]{item: f.index, failure: f.BulkFailure, retryable: retryable}
+		}
+		return results, err
+	})
+	if err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return &BulkError{Failures: failed, Total: len(items)}
+	}
+	return nil
+}
+
+// indexedFailure is a BulkFailure along with the position of its item.
+type indexedFailure struct {
+	BulkFailure
+	index int
+}
+
+// batches groups the lines of the pending items into batches of at most BatchSize bytes.
+func (b *BulkIndexer) batches(lines [][]byte, pending []int) [][]int {
+	size := b.BatchSize
+	if size <= 0 {
+		size = DefaultBulkBatchSize
+	}
+	var batches [][]int
+	var batch []int
+	n := 0
+	for _, i := range pending {
+		if len(batch) > 0 && n+len(lines[i]) > size {
+			batches = append(batches, batch)
+			batch, n = nil, 0
+		}
+		batch = append(batch, i)
+		n += len(lines[i])
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// send sends one batch and returns its failed items.
+func (b *BulkIndexer) send(ctx context.Context, items []BulkItem, lines [][]byte, batch []int) ([]indexedFailure, error) {
+	var body bytes.Buffer
+	var w io.Writer = &body
+	var zw *gzip.Writer
+	if !b.DisableCompression {
+		zw = gzip.NewWriter(&body)
+		w = zw
+	}
+	for _, i := range batch {
+		if _, err := w.Write(lines[i]); err != nil {
+			return nil, err
+		}
+	}
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.URL, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("Accept", "application/json")
+	if zw != nil {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	client := b.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	d, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, responseError(resp, d)
+	}
+
+	var doc bulkResponse
+	if err := json.Unmarshal(d, &doc); err != nil {
+		return nil, fmt.Errorf("decoding %s response: %w", b.URL, err)
+	}
+	if !doc.Errors {
+		return nil, nil
+	}
+	var failures []indexedFailure
+	for pos, result := range doc.Items {
+		for _, r := range result {
+			if r.Status >= 200 && r.Status <= 299 {
+				continue
+			}
+			f := indexedFailure{BulkFailure: BulkFailure{Status: r.Status}, index: -1}
+			if pos < len(batch) {
+				f.index = batch[pos]
+				f.Item = items[f.index]
+			}
+			if r.Error != nil {
+				f.Type, f.Reason = r.Error.Type, r.Error.Reason
+			}
+			failures = append(failures, f)
+		}
+	}
+	return failures, nil
+}
+
+// encodeBulkItem returns the NDJSON lines of item.
+func encodeBulkItem(item BulkItem) ([]byte, error) {
+	meta := map[string]string{}
+	if item.Index != "" {
+		meta["_index"] = item.Index
+	}
+	if item.ID != "" {
+		meta["_id"] = item.ID
+	}
+	action, err := json.Marshal(map[string]map[string]string{item.Action: meta})
+	if err != nil {
+		return nil, err
+	}
+	line := append(action, '\n')
+	if item.Action == "delete" {
+		return line, nil
+	}
+	doc, err := json.Marshal(item.Document)
+	if err != nil {
+		return nil, err
+	}
+	return append(append(line, doc...), '\n'), nil
+}
+
+// Error implements the error interface.
+func (err *BulkError) Error() string {
+	msg := fmt.Sprintf("%d of %d bulk items failed", len(err.Failures), err.Total)
+	if len(err.Failures) > 0 {
+		f := err.Failures[0]
+		msg += fmt.Sprintf(": %s %s/%s: status %d", f.Item.Action, f.Item.Index, f.Item.ID, f.Status)
+		if f.Type != "" {
+			msg += fmt.Sprintf(" (%s: %s)", f.Type, f.Reason)
+		}
+	}
+	return msg
+}
@@ -0,0 +1,122 @@
+package aws_signing_client
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+//   ____        _ _
+//  | __ ) _   _| | | __
+//  |  _ \| | | | | |/ /
+//  | |_) | |_| | |   <
+//  |____/ \__,_|_|_|\_\
+//
+
+// bulkServer returns a client whose _bulk endpoint answers each item with the status chosen by status for its ID
+// and the number of times it has been seen, and records the IDs of every request.
+func bulkServer(t *testing.T, status func(id string, seen int) int) (*http.Client, *[][]string) {
+	var requests [][]string
+	seen := map[string]int{}
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("Content-Encoding") != "gzip" || !strings.HasPrefix(req.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+			t.Errorf("Expected a signed, gzipped request, got %q", req.Header)
+		}
+		zr, err := gzip.NewReader(req.Body)
+		if err != nil {
+			t.Fatalf("An unexpected error occurred while decompressing the request: %s", err)
+		}
+		var ids []string
+		var items []string
+		sc := bufio.NewScanner(zr)
+		for sc.Scan() {
+			var action map[string]struct {
+				ID string `json:"_id"`
+			}
+			json.Unmarshal(sc.Bytes(), &action)
+			for name, meta := range action {
+				if name != "delete" {
+					sc.Scan()
+				}
+				seen[meta.ID]++
+				st := status(meta.ID, seen[meta.ID])
+				item := fmt.Sprintf(`{"%s":{"_id":"%s","status":%d}}`, name, meta.ID, st)
+				if st >= 300 {
+					item = fmt.Sprintf(`{"%s":{"_id":"%s","status":%d,"error":{"type":"err_%d","reason":"failed"}}}`, name, meta.ID, st, st)
+				}
+				ids = append(ids, meta.ID)
+				items = append(items, item)
+			}
+		}
+		requests = append(requests, ids)
+		return response(200, `{"took":1,"errors":true,"items":[`+strings.Join(items, ",")+`]}`, nil), nil
+	})
+	return c, &requests
+}
+
+// TestBulkIndexerRetriesFailedItems ensures that only items failing with a retryable status are sent again, and
+// that permanent failures are reported.
+func TestBulkIndexerRetriesFailedItems(t *testing.T) {
+	c, requests := bulkServer(t, func(id string, seen int) int {
+		switch {
+		case id == "2" && seen == 1:
+			return 429
+		case id == "3":
+			return 400
+		}
+		return 201
+	})
+	b := NewBulkIndexer(c, "https://example.com/_bulk")
+	b.Backoff = constantBackoff(time.Millisecond)
+	err := b.Index(context.Background(), []BulkItem{
+		{Action: "index", Index: "logs", ID: "1", Document: doc{Name: "a"}},
+		{Action: "index", Index: "logs", ID: "2", Document: doc{Name: "b"}},
+		{Action: "create", Index: "logs", ID: "3", Document: doc{Name: "c"}},
+		{Action: "delete", Index: "logs", ID: "4"},
+	})
+
+	var bulkErr *BulkError
+	switch {
+	case !errors.As(err, &bulkErr):
+		t.Fatalf("Expected a *BulkError, got %v", err)
+	case len(bulkErr.Failures) != 1 || bulkErr.Failures[0].Item.ID != "3" || bulkErr.Failures[0].Type != "err_400":
+		t.Errorf("Unexpected failures: %+v", bulkErr.Failures)
+	case fmt.Sprint(*requests) != "[[1 2 3 4] [2]]":
+		t.Errorf("Unexpected requests: %v", *requests)
+	}
+}
+
+// TestBulkIndexerBatches ensures that items are split into batches of at most BatchSize bytes and that items still
+// failing after MaxAttempts are reported.
+func TestBulkIndexerBatches(t *testing.T) {
+	c, requests := bulkServer(t, func(id string, seen int) int {
+		if id == "b" {
+			return 503
+		}
+		return 200
+	})
+	b := NewBulkIndexer(c, "https://example.com/_bulk")
+	b.Backoff = constantBackoff(time.Millisecond)
+	b.BatchSize = 100
+	b.MaxAttempts = 2
+	var items []BulkItem
+	for _, id := range []string{"a", "b", "c"} {
+		items = append(items, BulkItem{Action: "index", Index: "logs", ID: id, Document: doc{Name: strings.Repeat("x", 40)}})
+	}
+
+	err := b.Index(context.Background(), items)
+	var bulkErr *BulkError
+	switch {
+	case !errors.As(err, &bulkErr) || len(bulkErr.Failures) != 1 || bulkErr.Failures[0].Status != 503:
+		t.Errorf("Expected item b to fail with a 503, got %v", err)
+	case fmt.Sprint(*requests) != "[[a] [b] [c] [b]]":
+		t.Errorf("Unexpected requests: %v", *requests)
+	}
+}
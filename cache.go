@@ -0,0 +1,261 @@
+package aws_signing_client
+
+import (
+	"bytes"
+	"container/list"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxCachedBodySize is the largest response body WithResponseCache stores. Larger responses are passed through
+// uncached.
+const MaxCachedBodySize = 1 << 20
+
+type (
+	// CacheStore stores the responses cached by WithResponseCache. Implementations must be safe for concurrent
+	// use and must not modify entries passed to or returned from them.
+	CacheStore interface {
+		Get(key string) (*CachedResponse, bool)
+		Set(key string, entry *CachedResponse)
+		Delete(key string)
+	}
+
+	// CachedResponse is a response stored by WithResponseCache.
+	CachedResponse struct {
+		StatusCode int
+		Header     http.Header
+		Body       []byte
+		// ContentLength is that of the original response, which differs from the length of Body for HEAD requests.
+		ContentLength int64
+		// Expires is when the response stops being fresh and must be revalidated.
+		Expires time.Time
+	}
+
+	// MemoryCacheStore is a CacheStore that keeps up to a fixed number of responses in memory, evicting the least
+	// recently used.
+	MemoryCacheStore struct {
+		mu         sync.Mutex
+		maxEntries int
+		entries    map[string]*list.Element
+		lru        *list.List
+	}
+
+	memoryCacheEntry struct {
+		key   string
+		entry *CachedResponse
+	}
+
+	responseCache struct {
+		store CacheStore
+		vary  []string
+	}
+)
+
+// NewMemoryCacheStore returns a MemoryCacheStore that holds up to maxEntries responses.
+func NewMemoryCacheStore(maxEntries int) *MemoryCacheStore {
+	return &MemoryCacheStore{maxEntries: maxEntries, entries: map[string]*list.Element{}, lru: list.New()}
+}
+
+// WithResponseCache caches the responses to GET and HEAD requests in store, keyed by the values of the vary headers
+// too, so that fresh responses are served without signing or sending a request. A store must not be shared by
+// Signers with different credentials.
+func WithResponseCache(store CacheStore, vary ...string) Option {
+	return func(s *Signer) {
+		s.cache = &responseCache{store: store, vary: vary}
+	}
+}
+
+// roundTripCached serves req from the cache if possible, and otherwise sends it and caches the response.
+func (s *Signer) roundTripCached(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	key := s.cache.key(req)
+	entry, ok := s.cache.store.Get(key)
+	if ok && time.Now().Before(entry.Expires) {
+		s.logf(ctx, "Serving response from cache.")
+		return entry.response(req), nil
+	}
+	if ok {
+		if etag := entry.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if lm := entry.Header.Get("Last-Modified"); lm != "" {
+			req.Header.Set("If-Modified-Since", lm)
+		}
+	}
+
+	resp, err := s.roundTripUncached(req)
+	if err != nil {
+		return resp, err
+	}
+	if ok && resp.StatusCode == http.StatusNotModified {
+		DrainAndClose(resp)
+		revalidated := *entry
+		revalidated.Expires = freshUntil(resp.Header, time.Now())
+		s.cache.store.Set(key, &revalidated)
+		s.logf(ctx, "Cached response revalidated.")
+		return revalidated.response(req), nil
+	}
+	if resp.StatusCode != http.StatusOK || hasDirective(resp.Header, "no-store") {
+		return resp, nil
+	}
+	if resp.Header.Get("ETag") == "" && resp.Header.Get("Last-Modified") == "" && !freshUntil(resp.Header, time.Now()).After(time.Now()) {
+		return resp, nil
+	}
+
+	d, err := ioutil.ReadAll(io.LimitReader(resp.Body, MaxCachedBodySize+1))
+	if err != nil || len(d) > MaxCachedBodySize {
+		resp.Body = readCloser{io.MultiReader(bytes.NewReader(d), errReader{err}, resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(d))
+	s.cache.store.Set(key, &CachedResponse{
+		StatusCode:    resp.StatusCode,
+		Header:        resp.Header.Clone(),
+		Body:          d,
+		ContentLength: resp.ContentLength,
+		Expires:       freshUntil(resp.Header, time.Now()),
+	})
+	return resp, nil
+}
+
+// cacheable reports whether req may be served from or stored in the cache.
+func cacheable(req *http.Request) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead || isUpgrade(req.Header) {
+		return false
+	}
+	for _, h := range []string{"If-None-Match", "If-Modified-Since", "If-Match", "If-Unmodified-Since", "Range"} {
+		if req.Header.Get(h) != "" {
+			return false
+		}
+	}
+	return !hasDirective(req.Header, "no-cache") && !hasDirective(req.Header, "no-store")
+}
+
+func (rc *responseCache) key(req *http.Request) string {
+	var b strings.Builder
+	b.WriteString(req.Method)
+	b.WriteByte(' ')
+	b.WriteString(req.URL.String())
+	if alias, ok := req.Context().Value(destinationKey{}).(string); ok {
+		// The destination, not the URL, decides where the request is sent and with which credentials.
+		b.WriteString("\ndestination:")
+		b.WriteString(alias)
+	}
+	for _, h := range rc.vary {
+		b.WriteByte('\n')
+		b.WriteString(http.CanonicalHeaderKey(h))
+		b.WriteByte(':')
+		b.WriteString(strings.Join(req.Header.Values(h), ","))
+	}
+	return b.String()
+}
+
+// response returns a response for req served from the entry.
+func (cr *CachedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        strconv.Itoa(cr.StatusCode) + " " + http.StatusText(cr.StatusCode),
+		StatusCode:    cr.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        cr.Header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(cr.Body)),
+		ContentLength: cr.ContentLength,
+		Request:       req,
+	}
+}
+
+// freshUntil returns when a response received at now with header h stops being fresh.
+func freshUntil(h http.Header, now time.Time) time.Time {
+	if hasDirective(h, "no-cache") {
+		return now
+	}
+	for _, d := range strings.Split(h.Get("Cache-Control"), ",") {
+		if v := strings.TrimSpace(d); strings.HasPrefix(strings.ToLower(v), "max-age=") {
+			if secs, err := strconv.Atoi(v[len("max-age="):]); err == nil {
+				return now.Add(time.Duration(secs) * time.Second)
+			}
+			return now
+		}
+	}
+	if exp, err := http.ParseTime(h.Get("Expires")); err == nil {
+		if date, err := http.ParseTime(h.Get("Date")); err == nil {
+			return now.Add(exp.Sub(date))
+		}
+		return exp
+	}
+	return now
+}
+
+// hasDirective reports whether the Cache-Control header of h contains directive.
+func hasDirective(h http.Header, directive string) bool {
+	for _, d := range strings.Split(h.Get("Cache-Control"), ",") {
+		if strings.EqualFold(strings.TrimSpace(d), directive) {
+			return true
+		}
+	}
+	return false
+}
+
+// Get implements CacheStore.
+func (m *MemoryCacheStore) Get(key string) (*CachedResponse, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	el, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	m.lru.MoveToFront(el)
+	return el.Value.(*memoryCacheEntry).entry, true
+}
+
+// Set implements CacheStore.
+func (m *MemoryCacheStore) Set(key string, entry *CachedResponse) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, ok := m.entries[key]; ok {
+		el.Value.(*memoryCacheEntry).entry = entry
+		m.lru.MoveToFront(el)
+		return
+	}
+	m.entries[key] = m.lru.PushFront(&memoryCacheEntry{key: key, entry: entry})
+	for m.maxEntries > 0 && m.lru.Len() > m.maxEntries {
+		oldest := m.lru.Back()
+		m.lru.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+}
+
+// Delete implements CacheStore.
+func (m *MemoryCacheStore) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, ok := m.entries[key]; ok {
+		m.lru.Remove(el)
+		delete(m.entries, key)
+	}
+}
+
+// readCloser combines a Reader with the Closer of the body it reads from.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// errReader returns err, if any, in place of reading.
+type errReader struct {
+	err error
+}
+
+func (er errReader) Read(p []byte) (int, error) {
+	if er.err != nil {
+		return 0, er.err
+	}
+	return 0, io.EOF
+}
@@ -0,0 +1,179 @@
+package aws_signing_client
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+//    ____           _
+//   / ___|__ _  ___| |__   ___
+//  | |   / _` |/ __| '_ \ / _ \
+//  | |__| (_| | (__| | | |  __/
+//   \____\__,_|\___|_| |_|\___|
+//
+
+// cachingClient returns a client with a response cache whose transport answers every request with handle.
+func cachingClient(t *testing.T, handle func(req *http.Request) *http.Response, vary ...string) (*http.Client, *int) {
+	calls := 0
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		calls++
+		return handle(req), nil
+	}, WithResponseCache(NewMemoryCacheStore(10), vary...))
+	return c, &calls
+}
+
+func getBody(t *testing.T, c *http.Client, method, url string, header http.Header) (*http.Response, string) {
+	req, _ := http.NewRequest(method, url, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err)
+	}
+	defer resp.Body.Close()
+	d, _ := ioutil.ReadAll(resp.Body)
+	return resp, string(d)
+}
+
+// TestResponseCacheFresh ensures that a fresh cached response is served without sending a request.
+func TestResponseCacheFresh(t *testing.T) {
+	c, calls := cachingClient(t, func(req *http.Request) *http.Response {
+		return response(200, "hello", http.Header{"Cache-Control": {"max-age=60"}})
+	})
+	for i := 0; i < 2; i++ {
+		if _, body := getBody(t, c, "GET", "https://example.com/doc", nil); body != "hello" {
+			t.Errorf("Unexpected body: %q", body)
+		}
+	}
+	if *calls != 1 {
+		t.Errorf("Expected 1 request to be sent, got %d", *calls)
+	}
+}
+
+// TestResponseCacheRevalidation ensures that a stale response with an ETag is revalidated with a signed conditional
+// request and served from the cache on a 304.
+func TestResponseCacheRevalidation(t *testing.T) {
+	var conditional *http.Request
+	c, calls := cachingClient(t, func(req *http.Request) *http.Response {
+		if req.Header.Get("If-None-Match") == "" {
+			return response(200, "hello", http.Header{"Etag": {`"v1"`}})
+		}
+		conditional = req
+		return response(304, "", nil)
+	})
+	getBody(t, c, "GET", "https://example.com/doc", nil)
+	resp, body := getBody(t, c, "GET", "https://example.com/doc", nil)
+	switch {
+	case *calls != 2:
+		t.Errorf("Expected 2 requests to be sent, got %d", *calls)
+	case conditional == nil || conditional.Header.Get("If-None-Match") != `"v1"`:
+		t.Error("Expected the second request to carry If-None-Match")
+	case conditional.Header.Get("Authorization") == "":
+		t.Error("Expected the conditional request to be signed")
+	case resp.StatusCode != 200 || body != "hello":
+		t.Errorf("Expected the cached response, got %d %q", resp.StatusCode, body)
+	}
+}
+
+// TestResponseCacheBypass ensures that no-store responses and non-GET requests are not cached.
+func TestResponseCacheBypass(t *testing.T) {
+	c, calls := cachingClient(t, func(req *http.Request) *http.Response {
+		if req.URL.Path == "/private" {
+			return response(200, "secret", http.Header{"Cache-Control": {"no-store, max-age=60"}})
+		}
+		return response(200, "ok", http.Header{"Cache-Control": {"max-age=60"}})
+	})
+	getBody(t, c, "GET", "https://example.com/private", nil)
+	getBody(t, c, "GET", "https://example.com/private", nil)
+	getBody(t, c, "POST", "https://example.com/doc", nil)
+	getBody(t, c, "POST", "https://example.com/doc", nil)
+	getBody(t, c, "GET", "https://example.com/doc", http.Header{"Cache-Control": {"no-cache"}})
+	getBody(t, c, "GET", "https://example.com/doc", http.Header{"Cache-Control": {"no-cache"}})
+	if *calls != 6 {
+		t.Errorf("Expected 6 requests to be sent, got %d", *calls)
+	}
+}
+
+// TestResponseCacheHead ensures that a cached HEAD response keeps the Content-Length of the original response.
+func TestResponseCacheHead(t *testing.T) {
+	c, calls := cachingClient(t, func(req *http.Request) *http.Response {
+		resp := response(200, "", http.Header{"Cache-Control": {"max-age=60"}})
+		resp.ContentLength = 1234
+		return resp
+	})
+	for i := 0; i < 2; i++ {
+		if resp, _ := getBody(t, c, "HEAD", "https://example.com/doc", nil); resp.ContentLength != 1234 {
+			t.Errorf("Request %d: expected a Content-Length of 1234, got %d", i+1, resp.ContentLength)
+		}
+	}
+	if *calls != 1 {
+		t.Errorf("Expected 1 request to be sent, got %d", *calls)
+	}
+}
+
+// TestResponseCacheVary ensures that requests with different values of a vary header are cached separately.
+func TestResponseCacheVary(t *testing.T) {
+	c, calls := cachingClient(t, func(req *http.Request) *http.Response {
+		return response(200, req.Header.Get("Accept"), http.Header{"Cache-Control": {"max-age=60"}})
+	}, "Accept")
+	for _, accept := range []string{"application/json", "text/plain", "application/json"} {
+		if _, body := getBody(t, c, "GET", "https://example.com/doc", http.Header{"Accept": {accept}}); body != accept {
+			t.Errorf("Expected %q, got %q", accept, body)
+		}
+	}
+	if *calls != 2 {
+		t.Errorf("Expected 2 requests to be sent, got %d", *calls)
+	}
+}
+
+// TestMemoryCacheStoreEviction ensures that MemoryCacheStore evicts the least recently used entry.
+func TestMemoryCacheStoreEviction(t *testing.T) {
+	m := NewMemoryCacheStore(2)
+	m.Set("a", &CachedResponse{})
+	m.Set("b", &CachedResponse{})
+	m.Get("a")
+	m.Set("c", &CachedResponse{})
+	if _, ok := m.Get("b"); ok {
+		t.Error("Expected b to be evicted")
+	}
+	for _, k := range []string{"a", "c"} {
+		if _, ok := m.Get(k); !ok {
+			t.Errorf("Expected %s to be cached", k)
+		}
+	}
+	m.Delete("a")
+	if _, ok := m.Get("a"); ok {
+		t.Error("Expected a to be deleted")
+	}
+}
+
+// TestResponseCacheDestinations ensures that requests for the same URL routed to different destinations are cached
+// separately.
+func TestResponseCacheDestinations(t *testing.T) {
+	calls := 0
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		calls++
+		return response(200, req.URL.Host, http.Header{"Cache-Control": {"max-age=60"}}), nil
+	}, WithResponseCache(NewMemoryCacheStore(10)), WithDestinations(map[string]Destination{
+		"blue":  {Host: "blue.example.com"},
+		"green": {Host: "green.example.com"},
+	}))
+	for _, alias := range []string{"blue", "green", "blue"} {
+		req, _ := http.NewRequestWithContext(WithDestination(context.Background(), alias), "GET", "https://placeholder/doc", nil)
+		resp, err := c.Do(req)
+		if err != nil {
+			t.Fatalf("An unexpected error occurred: %s", err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != alias+".example.com" {
+			t.Errorf("Expected the response of %s, got %q", alias, body)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 requests to be sent, got %d", calls)
+	}
+}
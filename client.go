@@ -1,16 +1,15 @@
 package aws_signing_client
 
 import (
-	"bytes"
 	"context"
+	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
-
-	"github.com/aws/aws-sdk-go/aws/signer/v4"
-	"github.com/aws/aws-sdk-go/private/protocol/rest"
 )
 
 type (
@@ -18,10 +17,84 @@ type (
 	// signing and response.
 	Signer struct {
 		transport http.RoundTripper
-		v4        *v4.Signer
-		service   string
-		region    string
+		scope     atomic.Value // *signingScope
+		scopeMu   sync.Mutex   // serializes scope writers
 		logger    ContextLogger
+		anonymous AnonymousMode
+
+		signingNameOverride     string
+		signingRegionOverride   string
+		resignRedirects         bool
+		recoverPanics           bool
+		headerInjectors         []HeaderInjector
+		finalizers              []RequestFinalizer
+		candidate               RequestSigner
+		slowRequests            []slowThreshold
+		hooks                   []Hooks
+		validators              []ResponseValidator
+		parseErrors             bool
+		errorMode               ErrorMode
+		diagnoseSignatures      bool
+		strictPaths             bool
+		canonicalHeaders        bool
+		unsignedHeaders         []string
+		invocationIDs           bool
+		maxBufferedBody         int64
+		spoolBodies             bool
+		spoolDir                string
+		unsignedPayloadFallback bool
+		passUpgrades            bool
+		logFields               []LogFieldExtractor
+		retry                   retryPolicy
+		latencyBudget           time.Duration
+		deadlineReserve         time.Duration
+		useDeadlineReserve      bool
+		cache                   *responseCache
+		hashPool                *hashPool
+		payloadHasher           PayloadHasher
+		emptyBodies             EmptyBodyMode
+		partitions              []Partition
+		journal                 *journal
+		faults                  []Fault
+
+		allowedHosts           []string // nil allows every host
+		minTLSVersion          uint16
+		requireTLSVerification bool
+		transportConfigs       []transportConfig
+		hostTransports         []hostTransport
+		apiGatewayMappings     []APIGatewayMapping
+		destinations           map[string]Destination
+		regions                *regionPool
+		connMetrics            *connMetrics
+		mirrors                []*mirror
+
+		closers   []io.Closer
+		closeOnce sync.Once
+		closeErr  error
+
+		lifeMu       sync.Mutex // guards the fields below
+		shuttingDown bool
+		inFlight     int
+		drained      chan struct{} // closed when inFlight drops to zero during Shutdown
+
+		refreshPolicy CredentialRefreshPolicy
+		refreshMu     sync.Mutex
+	}
+
+	// Option configures optional behavior of the Signer created by New.
+	Option func(*Signer)
+
+	// RequestSigner signs an HTTP request with AWS Signature Version 4, returning the signed header values. The
+	// *v4.Signer of aws-sdk-go satisfies it, and the awsv2 sub-package provides an implementation backed by
+	// aws-sdk-go-v2. A nil body means the request has no payload.
+	RequestSigner interface {
+		Sign(r *http.Request, body io.ReadSeeker, service, region string, signTime time.Time) (http.Header, error)
+	}
+
+	// AnonymousDetector is implemented by RequestSigners that can tell whether their credentials are anonymous or
+	// empty. It is used by WithAnonymousCredentials.
+	AnonymousDetector interface {
+		Anonymous(ctx context.Context) bool
 	}
 
 	// ContextLogger is used for context-enabled logging.
@@ -35,8 +108,8 @@ type (
 		logger *log.Logger
 	}
 
-	// MissingSignerError is an implementation of the error interface that indicates that no AWS v4.Signer was
-	// provided in order to create a client.
+	// MissingSignerError is an implementation of the error interface that indicates that no AWS v4.Signer or
+	// RequestSigner was provided in order to create a client.
 	MissingSignerError struct{}
 
 	// MissingServiceError is an implementation of the error interface that indicates that no AWS service was
@@ -48,17 +121,28 @@ type (
 	MissingRegionError struct{}
 )
 
+// NewDefaultLogger returns a DefaultLogger that writes to the provided log.Logger.
+func NewDefaultLogger(l *log.Logger) *DefaultLogger {
+	return &DefaultLogger{logger: l}
+}
+
 // DefaultLogger.Printf() ignores the specified context.
 func (dl *DefaultLogger) Printf(ctx context.Context, format string, v ...interface{}) {
 	dl.logger.Printf(format, v...)
 }
 
-// New obtains an HTTP client with a RoundTripper that signs AWS requests for the provided service. An
-// existing client can be specified for the `client` value, or--if nil--a new HTTP client will be created.
-func New(v4s *v4.Signer, client *http.Client, service string, region string, cl ContextLogger) (*http.Client, error) {
+func discardLogger() ContextLogger {
+	return &DefaultLogger{
+		logger: log.New(ioutil.Discard, "", 0),
+	}
+}
+
+// NewWithRequestSigner is like New, but signs requests with any RequestSigner rather than an aws-sdk-go
+// *v4.Signer. It is the constructor to use with the awsv2 backend.
+func NewWithRequestSigner(rs RequestSigner, client *http.Client, service string, region string, cl ContextLogger, opts ...Option) (*http.Client, error) {
 	c := client
 	switch {
-	case v4s == nil:
+	case rs == nil:
 		return nil, MissingSignerError{}
 	case service == "":
 		return nil, MissingServiceError{}
@@ -69,81 +153,326 @@ func New(v4s *v4.Signer, client *http.Client, service string, region string, cl
 	}
 
 	if cl == nil {
-		cl = &DefaultLogger{
-			logger: log.New(ioutil.Discard, "", 0),
-		}
+		cl = discardLogger()
 	}
 
 	s := &Signer{
 		transport: c.Transport,
-		v4:        v4s,
-		service:   service,
-		region:    region,
 		logger:    cl,
 	}
+	s.scope.Store(&signingScope{signer: rs, service: service, region: region})
+	for _, opt := range opts {
+		opt(s)
+	}
 	if s.transport == nil {
 		s.transport = http.DefaultTransport
 	}
+	if err := s.configure(); err != nil {
+		// Options may have started goroutines or been handed sinks and files, which are released by the closers.
+		for _, c := range s.closers {
+			c.Close()
+		}
+		return nil, err
+	}
 	c.Transport = s
 	return c, nil
 }
 
+// configure applies and validates the configuration set by the options.
+func (s *Signer) configure() error {
+	if err := s.configureTransport(); err != nil {
+		return err
+	}
+	if err := s.enforceTLS(); err != nil {
+		return err
+	}
+	return s.checkMirrors()
+}
+
 // RoundTrip implements the http.RoundTripper interface and is used to wrap HTTP requests in order to sign them for AWS
 // API calls. The scheme for all requests will be changed to HTTPS.
-func (s *Signer) RoundTrip(req *http.Request) (*http.Response, error) {
+func (s *Signer) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	if err := s.startRequest(req.Context()); err != nil {
+		s.logf(req.Context(), "%s", err)
+		return nil, err
+	}
+	defer s.endRequest()
+	if s.recoverPanics {
+		defer s.recoverPanic(req.Context(), &resp, &err)
+	}
+	if len(s.logFields) > 0 {
+		req = req.WithContext(s.extractLogFields(req.Context()))
+	}
+	if s.cache != nil && cacheable(req) {
+		resp, err = s.roundTripCached(req)
+	} else {
+		resp, err = s.roundTripUncached(req)
+	}
+	if err == nil && len(s.validators) > 0 {
+		s.validateResponse(req.Context(), req, resp)
+	}
+	if err == nil && resp.StatusCode >= 400 && s.requestErrorMode(req.Context()) == ErrorModeError {
+		return nil, s.responseAsError(req.Context(), resp)
+	}
+	return resp, err
+}
+
+func (s *Signer) roundTripUncached(req *http.Request) (*http.Response, error) {
+	if s.latencyBudget > 0 || s.useDeadlineReserve {
+		return s.roundTripWithBudget(req)
+	}
+	return s.roundTrip(req)
+}
+
+func (s *Signer) roundTrip(req *http.Request) (*http.Response, error) {
 	ctx := req.Context()
+	sc := scopeOverride(ctx, s.loadScope())
+	if reason := unsignedReason(req, s.passUpgrades); reason != "" {
+		if s.passUpgrades {
+			s.logf(ctx, "Sending request unsigned: %s.", reason)
+			return s.sendUnsigned(ctx, req, SkipUpgrade)
+		}
+		s.logf(ctx, "Refusing to send request: %s.", reason)
+		return nil, &UnsupportedRequestError{Method: req.Method, Reason: reason}
+	}
+	if len(s.destinations) > 0 {
+		var err error
+		if sc, err = s.route(ctx, req, sc); err != nil {
+			s.logf(ctx, "%s", err)
+			return nil, err
+		}
+	}
+	if err := s.normalizeURL(ctx, req); err != nil {
+		s.logf(ctx, "%s", err)
+		return nil, err
+	}
+	if !s.hostAllowed(req.URL.Hostname()) {
+		s.logf(ctx, "Host '%s' is not allowed. Refusing to send request.", req.URL.Host)
+		return nil, &DisallowedHostError{Host: req.URL.Hostname()}
+	}
+	if req.Response != nil && s.resignRedirects && !s.prepareRedirect(req) {
+		s.logf(ctx, "Redirected to a different host '%s'. Sending request unsigned.", req.URL.Host)
+		return s.sendUnsigned(ctx, req, SkipRedirect)
+	}
+	if signingSkipped(ctx) {
+		s.logf(ctx, "Signing disabled by the request context. Sending request unsigned.")
+		return s.sendUnsigned(ctx, req, SkipContext)
+	}
 	if h, ok := req.Header["Authorization"]; ok && len(h) > 0 && strings.HasPrefix(h[0], "AWS4") {
-		s.logger.Printf(ctx, "Received request to sign that is already signed. Skipping.")
-		return s.transport.RoundTrip(req)
+		s.logf(ctx, "Received request to sign that is already signed. Skipping.")
+		return s.sendUnsigned(ctx, req, SkipAlreadySigned)
+	}
+	if s.anonymous != AnonymousSign && isAnonymous(ctx, sc.signer) {
+		switch s.anonymous {
+		case AnonymousPassThrough:
+			s.logf(ctx, "Credentials are anonymous. Sending request unsigned.")
+			return s.sendUnsigned(ctx, req, SkipAnonymous)
+		default:
+			s.logf(ctx, "Credentials are anonymous. Refusing to send request.")
+			return nil, AnonymousCredentialsError{}
+		}
 	}
 
-	req.URL.Scheme = "https"
-	if strings.Contains(req.URL.RawPath, "%2C") {
-		s.logger.Printf(ctx, "Escaping path for URL path '%s'", req.URL.RawPath)
-		req.URL.RawPath = rest.EscapePath(req.URL.RawPath, false)
+	name, signingRegion := s.prepare(ctx, req, sc)
+	timing := RequestTiming{
+		Method:  req.Method,
+		Host:    req.URL.Host,
+		Path:    req.URL.Path,
+		Service: name,
+		Region:  signingRegion,
+		Tags:    RequestTags(ctx),
 	}
-	t := time.Now()
-	req.Header.Set("Date", t.Format(time.RFC3339))
-	s.logger.Printf(ctx, "Request to be signed: %+v", req)
 
-	var latency int64
-	var err error
-	switch req.Body {
-	case nil:
-		s.logger.Printf(ctx, "Signing request with no body...")
+	// The body is buffered once so that it can be hashed for the signature and replayed on every attempt. A nil body
+	// means the request has no body.
+	var body *payload
+	if req.Body != nil {
 		start := time.Now()
-		_, err = s.v4.Sign(req, nil, s.service, s.region, t)
-		latency = int64(time.Now().Sub(start)/time.Millisecond)
-	default:
-		d, err := ioutil.ReadAll(req.Body)
+		var over bool
+		var err error
+		body, over, err = s.readBody(ctx, req)
+		timing.BodyRead = time.Since(start)
 		if err != nil {
-			s.logger.Printf(ctx, "Error while attempting to read request body: '%s'", err)
+			s.logf(ctx, "Error while attempting to read request body: '%s'", err)
+			return nil, err
+		}
+		if over {
+			// The body is streamed with an unsigned payload and cannot be replayed for retries.
+			resp, _, err := s.send(ctx, req, sc, nil, timing)
+			return resp, err
+		}
+		if s.dropEmptyBody(req, body.bytes()) {
+			body = nil
+		} else {
+			defer body.close()
+		}
+		if err := s.precomputeHash(ctx, req, body, &timing); err != nil {
+			s.logf(ctx, "Error while attempting to hash request body: '%s'", err)
 			return nil, err
 		}
-		req.Body = ioutil.NopCloser(bytes.NewReader(d))
-		s.logger.Printf(ctx, "Signing request with body...")
-		start := time.Now()
-		_, err = s.v4.Sign(req, bytes.NewReader(d), s.service, s.region, t)
-		latency = int64(time.Now().Sub(start)/time.Millisecond)
+	}
+	if len(s.mirrors) > 0 {
+		s.mirror(ctx, req, sc, body)
 	}
 
-	if err != nil {
-		s.logger.Printf(ctx, "Error while attempting to sign request: '%s'", err)
-		return nil, err
+	if s.retry.maxAttempts > 1 {
+		return s.sendWithRetries(ctx, req, sc, body, timing)
+	}
+	resp, _, err := s.send(ctx, req, sc, body, timing)
+	return resp, err
+}
+
+// prepare readies req for signing for the scope sc: it switches to HTTPS, runs the HeaderInjectors and encodes the
+// path. It returns the name and region req is signed with.
+func (s *Signer) prepare(ctx context.Context, req *http.Request, sc *signingScope) (name, region string) {
+	req.URL.Scheme = "https"
+	// The transport sends req.ContentLength and never a Content-Length header, which would be signed with a value
+	// that may not be the one sent, e.g. on a HEAD request.
+	req.Header.Del("Content-Length")
+	if req.Body == nil {
+		req.ContentLength = 0
+	}
+	for _, inject := range s.headerInjectors {
+		inject(ctx, req)
+	}
+	if s.canonicalHeaders {
+		canonicalizeHeaders(req.Header)
+	}
+	name, region = s.signingValues(sc)
+	switch {
+	case s.strictPaths:
+		encodePath(req.URL, name)
+	case strings.Contains(req.URL.RawPath, "%2C"):
+		s.logf(ctx, "Escaping path for URL path '%s'", req.URL.RawPath)
+		req.URL.RawPath = escapePath(req.URL.RawPath, false)
+	}
+	return name, region
+}
+
+// beforeSign removes the headers set with WithUnsignedHeaders from req, prepared and about to be signed, and points
+// it at the host and path of its API Gateway mapping. It returns a function that undoes both once req is signed, and
+// puts back the body the signer replaces.
+func (s *Signer) beforeSign(ctx context.Context, req *http.Request) (restore func()) {
+	// The v1 signer replaces req.Body with the body it is given, which is nil for a stream sent with an unsigned
+	// payload and is not http.NoBody for an empty payload.
+	reqBody := req.Body
+	restoreHeaders := s.removeUnsignedHeaders(ctx, req)
+	restorePath := s.mapAPIGatewayPath(req)
+	return func() {
+		req.Body = reqBody
+		restorePath()
+		restoreHeaders()
+	}
+}
+
+// send signs req with a fresh timestamp and sends it once. sent is false if the request failed before reaching the
+// wrapped transport.
+func (s *Signer) send(ctx context.Context, req *http.Request, sc *signingScope, p *payload, timing RequestTiming) (resp *http.Response, sent bool, err error) {
+	var region *regionState
+	if !sc.derived {
+		region = s.selectRegion(req)
+	}
+	if region != nil {
+		if !s.hostAllowed(req.URL.Hostname()) {
+			s.logf(ctx, "Host '%s' is not allowed. Refusing to send request.", req.URL.Host)
+			return nil, false, &DisallowedHostError{Host: req.URL.Hostname()}
+		}
+		timing.Host, timing.Region = region.Host, region.Region
+	}
+	rt := s.transportFor(req.URL.Hostname())
+	if len(s.faults) > 0 {
+		rt = &faultTransport{rt: rt, faults: s.faults, logger: s.logf}
+	}
+	if s.invocationIDs {
+		if id, err := newUUID(); err == nil {
+			req.Header.Set(InvocationIDHeader, id)
+			timing.InvocationID = id
+		}
 	}
-	s.logger.Printf(ctx, "Signing succesful. Latency: %d ms", latency)
+	wait := time.Now()
+	s.anticipateExpiry(ctx, req, sc)
+	t := time.Now()
+	timing.QueueWait += t.Sub(wait)
+	req.Header.Set("Date", t.Format(time.RFC3339))
+	s.logf(ctx, "Request to be signed: %+v", req)
 
+	var body io.ReadSeeker
+	if p != nil {
+		req.Body, req.ContentLength = p.body(), p.len()
+		body = p.reader()
+		s.logf(ctx, "Signing request with body...")
+	} else {
+		s.logf(ctx, "Signing request with no body...")
+	}
 	start := time.Now()
-	resp, err := s.transport.RoundTrip(req)
-	latency = int64(time.Now().Sub(start)/time.Millisecond)
+	restore := s.beforeSign(ctx, req)
+	var shadow *http.Request
+	if s.candidate != nil && sc.destination == "" {
+		shadow = req.Clone(ctx)
+	}
+	_, err = sc.signer.Sign(req, body, timing.Service, timing.Region, t)
+	if shadow != nil && err == nil {
+		s.compareCandidate(ctx, req, shadow, p, timing.Service, timing.Region, t)
+	}
+	restore()
+	timing.Sign = time.Since(start)
+	if err != nil {
+		s.logf(ctx, "Error while attempting to sign request: '%s'", err)
+		return nil, false, err
+	}
+	s.logf(ctx, "Signing succesful. Latency: %d ms, queued for %d ms", timing.Sign/time.Millisecond, timing.QueueWait/time.Millisecond)
+	timing.Start = t
+	timing.AccessKeyID = accessKeyID(req.Header)
+	timing.Attempt, timing.MaxAttempts = 1, 1
+	if attempt, max, ok := RequestAttempt(ctx); ok {
+		timing.Attempt, timing.MaxAttempts = attempt, max
+	}
+	if len(s.finalizers) > 0 {
+		if req, err = s.finalize(ctx, req); err != nil {
+			s.logf(ctx, "%s", err)
+			return nil, false, err
+		}
+	}
+
+	start = time.Now()
+	if s.connMetrics != nil {
+		ct := &connTrace{}
+		resp, err = rt.RoundTrip(req.WithContext(s.connMetrics.trace(ctx, ct)))
+		info := ct.connectionInfo()
+		timing.Conn = &info
+	} else {
+		resp, err = rt.RoundTrip(req)
+	}
+	timing.Send = time.Since(start)
+	if region != nil {
+		s.regions.report(region, timing.Send, resp, err)
+	}
+	timing.Total = timing.BodyRead + timing.Hash + time.Since(t)
+	timing.Err = err
+	if resp != nil {
+		timing.StatusCode = resp.StatusCode
+	}
+	recordAttempt(ctx, timing)
+	s.reportTiming(ctx, timing)
+	if s.journal != nil {
+		s.journalRequest(ctx, req, p, timing)
+	}
 
 	if err != nil {
-		s.logger.Printf(ctx, "Error from RoundTripper. Latency: %d ms, Error: %s", latency, err)
-		return resp, err
+		s.logf(ctx, "Error from RoundTripper. Latency: %d ms, Error: %s", timing.Send/time.Millisecond, err)
+		return resp, true, err
 	}
 
-	s.logger.Printf(ctx, "Successful response from RoundTripper. Latency: %d ms", latency)
-	return resp, nil
+	s.logf(ctx, "Successful response from RoundTripper. Latency: %d ms", timing.Send/time.Millisecond)
+	if s.parseErrors && (resp.StatusCode < 200 || resp.StatusCode > 299) || s.diagnoseSignatures && resp.StatusCode == http.StatusForbidden {
+		awsErr := s.inspectError(ctx, resp)
+		if s.diagnoseSignatures && awsErr.Code == "SignatureDoesNotMatch" {
+			if awsErr.SignatureMismatch = diagnoseSignature(req, p.bytes(), awsErr); awsErr.SignatureMismatch != nil {
+				s.logf(ctx, "Signature mismatch: %s", awsErr.SignatureMismatch)
+			}
+		}
+	}
+	return resp, true, nil
 }
 
 // Error implements the error interface.
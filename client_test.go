@@ -1,8 +1,12 @@
 package aws_signing_client
 
 import (
+	"io"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"strings"
 
@@ -137,3 +141,81 @@ func (rt *testRoundTripper) RoundTrip(req *http.Request) (*http.Response, error)
 	passedReq = req
 	return &http.Response{}, rt.err
 }
+
+// TestRoundTripBodyStates ensures that the signature the endpoint verifies matches, and that no chunked body is sent,
+// for every method and every way of passing no, an empty or a non-empty body.
+func TestRoundTripBodyStates(t *testing.T) {
+	type result struct {
+		valid   bool
+		chunked bool
+		body    string
+	}
+	results := make(chan result, 1)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		results <- result{valid: validSignature(r, body), chunked: len(r.TransferEncoding) > 0, body: string(body)}
+	}))
+	defer srv.Close()
+	c, err := New(v4.NewSigner(creds), srv.Client(), "es", "us-east-1", nil)
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+
+	bodies := []struct {
+		name string
+		body func() io.Reader
+		want string
+	}{
+		{"nil", func() io.Reader { return nil }, ""},
+		{"NoBody", func() io.Reader { return http.NoBody }, ""},
+		{"empty reader", func() io.Reader { return strings.NewReader("") }, ""},
+		{"empty unknown-length reader", func() io.Reader { return ioutil.NopCloser(strings.NewReader("")) }, ""},
+		{"reader", func() io.Reader { return strings.NewReader("payload") }, "payload"},
+		{"unknown-length reader", func() io.Reader { return ioutil.NopCloser(strings.NewReader("payload")) }, "payload"},
+	}
+	for _, method := range []string{"GET", "HEAD", "DELETE", "OPTIONS", "POST", "PUT"} {
+		for _, b := range bodies {
+			for _, header := range []bool{false, true} {
+				req, _ := http.NewRequest(method, srv.URL+"/idx", b.body())
+				if header {
+					req.Header.Set("Content-Length", "5")
+				}
+				resp, err := c.Do(req)
+				if err != nil {
+					t.Errorf("%s with %s body: an unexpected error occurred: %s", method, b.name, err)
+					continue
+				}
+				resp.Body.Close()
+				r := <-results
+				switch {
+				case !r.valid:
+					t.Errorf("%s with %s body (Content-Length header %t): the signature does not match", method, b.name, header)
+				case r.chunked:
+					t.Errorf("%s with %s body (Content-Length header %t): the body was chunked", method, b.name, header)
+				case r.body != b.want:
+					t.Errorf("%s with %s body (Content-Length header %t): expected body %q, got %q", method, b.name, header, b.want, r.body)
+				}
+			}
+		}
+	}
+}
+
+// validSignature reports whether the Authorization header of r, as received by the endpoint, matches a signature
+// computed again over the headers it lists.
+func validSignature(r *http.Request, body []byte) bool {
+	auth := r.Header.Get("Authorization")
+	i := strings.Index(auth, "SignedHeaders=")
+	if i < 0 {
+		return false
+	}
+	req, _ := http.NewRequest(r.Method, "https://"+r.Host+r.URL.RequestURI(), nil)
+	req.ContentLength = r.ContentLength
+	for _, name := range strings.Split(strings.SplitN(auth[i+len("SignedHeaders="):], ",", 2)[0], ";") {
+		if name != "host" && name != "content-length" {
+			req.Header[http.CanonicalHeaderKey(name)] = r.Header.Values(name)
+		}
+	}
+	signTime, _ := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	v4.NewSigner(creds).Sign(req, strings.NewReader(string(body)), "es", "us-east-1", signTime)
+	return req.Header.Get("Authorization") == auth
+}
@@ -0,0 +1,160 @@
+//go:build !nosdkv1
+
+// Command awscurl sends a single AWS Signature Version 4 signed HTTP request and prints the response. It is meant
+// for ad-hoc debugging of endpoints protected by IAM, such as Amazon Elasticsearch/OpenSearch Service domains and
+// API Gateway stages.
+//
+// Usage:
+//
+//	awscurl [flags] URL
+//
+// Credentials and the default region are resolved the same way as the AWS CLI: environment variables, the shared
+// config and credentials files (optionally for a named -profile), and instance/container roles.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+
+	"github.com/Nextdoor/aws_signing_client"
+)
+
+// headerFlags collects repeated -H flags.
+type headerFlags []string
+
+func (h *headerFlags) String() string {
+	return strings.Join(*h, ", ")
+}
+
+func (h *headerFlags) Set(v string) error {
+	if !strings.Contains(v, ":") {
+		return fmt.Errorf("header %q must be in the form 'Name: value'", v)
+	}
+	*h = append(*h, v)
+	return nil
+}
+
+func main() {
+	var headers headerFlags
+	method := flag.String("X", "", "HTTP method (default GET, or POST when -d is set)")
+	data := flag.String("d", "", "request body; use @file to read it from a file or @- to read it from stdin")
+	service := flag.String("service", "execute-api", "AWS service abbreviation to sign for, e.g. es or execute-api")
+	region := flag.String("region", "", "AWS region to sign for (default from the environment or profile)")
+	profile := flag.String("profile", "", "shared config profile to load credentials from")
+	include := flag.Bool("i", false, "include the response status and headers in the output")
+	debug := flag.Bool("debug", false, "print the canonical request, string to sign and signing logs to stderr")
+	flag.Var(&headers, "H", "request header in the form 'Name: value' (may be repeated)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] URL\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(flag.Arg(0), *method, *data, headers, *service, *region, *profile, *include, *debug); err != nil {
+		fmt.Fprintln(os.Stderr, "awscurl:", err)
+		os.Exit(1)
+	}
+}
+
+func run(url, method, data string, headers headerFlags, service, region, profile string, include, debug bool) error {
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Profile:           profile,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return err
+	}
+	if region == "" {
+		region = aws.StringValue(sess.Config.Region)
+	}
+
+	signer := v4.NewSigner(sess.Config.Credentials, func(s *v4.Signer) {
+		if debug {
+			s.Debug = aws.LogDebugWithSigning
+			s.Logger = aws.NewDefaultLogger()
+		}
+	})
+	var cl aws_signing_client.ContextLogger
+	if debug {
+		cl = aws_signing_client.NewDefaultLogger(log.New(os.Stderr, "", log.LstdFlags))
+	}
+	client, err := aws_signing_client.New(signer, &http.Client{}, service, region, cl)
+	if err != nil {
+		return err
+	}
+
+	body, err := readBody(data)
+	if err != nil {
+		return err
+	}
+	if method == "" {
+		method = "GET"
+		if body != nil {
+			method = "POST"
+		}
+	}
+
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, url, r)
+	if err != nil {
+		return err
+	}
+	for _, h := range headers {
+		kv := strings.SplitN(h, ":", 2)
+		req.Header.Add(strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1]))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if include {
+		fmt.Printf("%s %s\n", resp.Proto, resp.Status)
+		keys := make([]string, 0, len(resp.Header))
+		for k := range resp.Header {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			for _, v := range resp.Header[k] {
+				fmt.Printf("%s: %s\n", k, v)
+			}
+		}
+		fmt.Println()
+	}
+	_, err = io.Copy(os.Stdout, resp.Body)
+	return err
+}
+
+func readBody(data string) ([]byte, error) {
+	switch {
+	case data == "":
+		return nil, nil
+	case data == "@-":
+		return ioutil.ReadAll(os.Stdin)
+	case strings.HasPrefix(data, "@"):
+		return ioutil.ReadFile(data[1:])
+	}
+	return []byte(data), nil
+}
@@ -0,0 +1,123 @@
+//go:build !nosdkv1
+
+// Command sigv4-proxy runs a local HTTP server that signs every inbound request with AWS Signature Version 4 and
+// forwards it to a single AWS endpoint. It lets workloads that cannot sign requests themselves (Grafana, Prometheus,
+// curl, ...) talk to IAM-protected endpoints through a sidecar.
+//
+// Every flag may also be set through the environment variable listed in its description; flags take precedence.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+
+	"github.com/Nextdoor/aws_signing_client"
+)
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func main() {
+	target := flag.String("target", envOr("SIGV4_PROXY_TARGET", ""), "endpoint to forward requests to, e.g. https://my-domain.us-east-1.es.amazonaws.com ($SIGV4_PROXY_TARGET)")
+	service := flag.String("service", envOr("SIGV4_PROXY_SERVICE", ""), "AWS service abbreviation to sign for ($SIGV4_PROXY_SERVICE)")
+	region := flag.String("region", envOr("SIGV4_PROXY_REGION", ""), "AWS region to sign for; defaults to the SDK's region resolution ($SIGV4_PROXY_REGION)")
+	source := flag.String("credentials", envOr("SIGV4_PROXY_CREDENTIALS", "default"), "credential source: default, env or profile ($SIGV4_PROXY_CREDENTIALS)")
+	profile := flag.String("profile", envOr("SIGV4_PROXY_PROFILE", ""), "shared config profile used when -credentials=profile ($SIGV4_PROXY_PROFILE)")
+	listen := flag.String("listen", envOr("SIGV4_PROXY_LISTEN", "localhost:8080"), "address to listen on ($SIGV4_PROXY_LISTEN)")
+	tlsCert := flag.String("tls-cert", envOr("SIGV4_PROXY_TLS_CERT", ""), "certificate file for serving TLS ($SIGV4_PROXY_TLS_CERT)")
+	tlsKey := flag.String("tls-key", envOr("SIGV4_PROXY_TLS_KEY", ""), "private key file for serving TLS ($SIGV4_PROXY_TLS_KEY)")
+	verbose := flag.Bool("verbose", os.Getenv("SIGV4_PROXY_VERBOSE") != "", "log every signed request ($SIGV4_PROXY_VERBOSE)")
+	flag.Parse()
+
+	logger := log.New(os.Stderr, "sigv4-proxy: ", log.LstdFlags)
+	if err := run(logger, *target, *service, *region, *source, *profile, *listen, *tlsCert, *tlsKey, *verbose); err != nil {
+		logger.Fatal(err)
+	}
+}
+
+func run(logger *log.Logger, target, service, region, source, profile, listen, tlsCert, tlsKey string, verbose bool) error {
+	if target == "" {
+		return fmt.Errorf("no -target was provided")
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return fmt.Errorf("invalid -target: %s", err)
+	}
+	if (tlsCert == "") != (tlsKey == "") {
+		return fmt.Errorf("-tls-cert and -tls-key must be provided together")
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Profile:           profile,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return err
+	}
+	if region == "" {
+		region = aws.StringValue(sess.Config.Region)
+	}
+
+	var creds *credentials.Credentials
+	switch source {
+	case "default", "profile":
+		creds = sess.Config.Credentials
+	case "env":
+		creds = credentials.NewEnvCredentials()
+	default:
+		return fmt.Errorf("unknown credential source %q", source)
+	}
+
+	var cl aws_signing_client.ContextLogger
+	if verbose {
+		cl = aws_signing_client.NewDefaultLogger(logger)
+	}
+	client, err := aws_signing_client.New(v4.NewSigner(creds), &http.Client{}, service, region, cl)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:    listen,
+		Handler: aws_signing_client.NewReverseProxy(u, client.Transport, aws_signing_client.NewDefaultLogger(logger)),
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errs := make(chan error, 1)
+	go func() {
+		logger.Printf("Signing requests for %s in %s and forwarding to %s; listening on %s", service, region, u.Host, listen)
+		if tlsCert != "" {
+			errs <- srv.ListenAndServeTLS(tlsCert, tlsKey)
+		} else {
+			errs <- srv.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+	logger.Print("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
@@ -0,0 +1,101 @@
+package aws_signing_client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+type (
+	// Condition holds the conditional headers sent by DoConditional. Empty fields are not sent. The headers are
+	// signed along with the rest of the request.
+	Condition struct {
+		// IfMatch makes the request succeed only if the resource's current ETag matches, e.g. for optimistic
+		// concurrency on writes. A mismatch is returned as a *PreconditionFailedError.
+		IfMatch string
+		// IfNoneMatch makes a GET or HEAD return NotModified if the resource's current ETag matches, or, set to "*",
+		// makes a write succeed only if the resource does not exist yet.
+		IfNoneMatch string
+	}
+
+	// Versioned is the result of a conditional request: the decoded response along with the resource's ETag.
+	Versioned[T any] struct {
+		// Value is the decoded response body. It is the zero value when NotModified is set.
+		Value T
+		// ETag is the resource's current ETag, if the response carried one. For a 304 response without an ETag
+		// header, it is the ETag the request was conditioned on.
+		ETag string
+		// NotModified is set when the server answered 304 Not Modified.
+		NotModified bool
+	}
+
+	// PreconditionFailedError is returned by DoConditional when the server answers 412 Precondition Failed.
+	PreconditionFailedError struct {
+		// Condition is the condition of the request that failed.
+		Condition Condition
+		// Err holds the details parsed from the response.
+		Err *AWSError
+	}
+)
+
+// DoConditional sends a JSON request like DoJSON with the conditional headers in cond. A 304 response is returned as
+// a Versioned with NotModified set, and a 412 response as a *PreconditionFailedError; other non-2xx responses are
+// returned as an *AWSError.
+func DoConditional[TReq, TResp any](ctx context.Context, jc *JSONClient, method, url string, cond Condition, body TReq) (Versioned[TResp], error) {
+	var out Versioned[TResp]
+	header := http.Header{}
+	if cond.IfMatch != "" {
+		header.Set("If-Match", cond.IfMatch)
+	}
+	if cond.IfNoneMatch != "" {
+		header.Set("If-None-Match", cond.IfNoneMatch)
+	}
+
+	resp, d, err := jc.send(ctx, method, url, header, body)
+	if err != nil {
+		return out, err
+	}
+	out.ETag = resp.Header.Get("ETag")
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		if out.ETag == "" {
+			out.ETag = cond.IfNoneMatch
+		}
+		out.NotModified = true
+		return out, nil
+	case resp.StatusCode == http.StatusPreconditionFailed:
+		return out, &PreconditionFailedError{Condition: cond, Err: responseError(resp, d)}
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return out, responseError(resp, d)
+	}
+	return out, decodeJSON(method, url, d, &out.Value)
+}
+
+// GetIfNoneMatch gets the JSON document at url unless its ETag still matches etag, in which case the result has
+// NotModified set. An empty etag makes an unconditional request.
+func GetIfNoneMatch[TResp any](ctx context.Context, jc *JSONClient, url, etag string) (Versioned[TResp], error) {
+	return DoConditional[NoBody, TResp](ctx, jc, http.MethodGet, url, Condition{IfNoneMatch: etag}, NoBody{})
+}
+
+// PutIfMatch puts body at url only if the resource's ETag still matches etag, returning a *PreconditionFailedError
+// if it has changed.
+func PutIfMatch[TReq, TResp any](ctx context.Context, jc *JSONClient, url, etag string, body TReq) (Versioned[TResp], error) {
+	return DoConditional[TReq, TResp](ctx, jc, http.MethodPut, url, Condition{IfMatch: etag}, body)
+}
+
+func (err *PreconditionFailedError) Error() string {
+	switch {
+	case err.Condition.IfMatch != "":
+		return fmt.Sprintf("Precondition failed: resource no longer matches ETag %s", err.Condition.IfMatch)
+	case err.Condition.IfNoneMatch == "*":
+		return "Precondition failed: resource already exists"
+	}
+	return fmt.Sprintf("Precondition failed: resource matches ETag %s", err.Condition.IfNoneMatch)
+}
+
+func (err *PreconditionFailedError) Unwrap() error {
+	if err.Err == nil {
+		return nil
+	}
+	return err.Err
+}
@@ -0,0 +1,78 @@
+package aws_signing_client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+//    ____                _ _ _   _                   _
+//   / ___|___  _ __   __| (_) |_(_) ___  _ __   __ _| |
+//  | |   / _ \| '_ \ / _` | | __| |/ _ \| '_ \ / _` | |
+//  | |__| (_) | | | | (_| | | |_| | (_) | | | | (_| | |
+//   \____\___/|_| |_|\__,_|_|\__|_|\___/|_| |_|\__,_|_|
+//
+
+// TestGetIfNoneMatch ensures that If-None-Match is signed and that a 304 is returned as NotModified.
+func TestGetIfNoneMatch(t *testing.T) {
+	jc := jsonClient(t, func(req *http.Request) (*http.Response, error) {
+		if !strings.Contains(req.Header.Get("Authorization"), "if-none-match") {
+			t.Errorf("If-None-Match was not signed: %s", req.Header.Get("Authorization"))
+		}
+		if req.Header.Get("If-None-Match") == `"v1"` {
+			return response(304, "", nil), nil
+		}
+		return response(200, `{"name":"v2"}`, http.Header{"Etag": {`"v2"`}}), nil
+	})
+
+	out, err := GetIfNoneMatch[doc](context.Background(), jc, "https://example.com/idx/_doc/1", `"v1"`)
+	switch {
+	case err != nil:
+		t.Errorf("An unexpected error occurred: %s", err)
+	case !out.NotModified || out.ETag != `"v1"`:
+		t.Errorf("Expected a not modified result, got %+v", out)
+	}
+
+	out, err = GetIfNoneMatch[doc](context.Background(), jc, "https://example.com/idx/_doc/1", `"v0"`)
+	switch {
+	case err != nil:
+		t.Errorf("An unexpected error occurred: %s", err)
+	case out.NotModified || out.ETag != `"v2"` || out.Value.Name != "v2":
+		t.Errorf("Expected the current document, got %+v", out)
+	}
+}
+
+// TestPutIfMatch ensures that a 412 is returned as a *PreconditionFailedError.
+func TestPutIfMatch(t *testing.T) {
+	jc := jsonClient(t, func(req *http.Request) (*http.Response, error) {
+		if !strings.Contains(req.Header.Get("Authorization"), "if-match") {
+			t.Errorf("If-Match was not signed: %s", req.Header.Get("Authorization"))
+		}
+		if req.Header.Get("If-Match") != `"v2"` {
+			return response(412, `<Error><Code>PreconditionFailed</Code><Message>At least one of the pre-conditions you specified did not hold</Message></Error>`, nil), nil
+		}
+		return response(200, "", http.Header{"Etag": {`"v3"`}}), nil
+	})
+
+	out, err := PutIfMatch[doc, NoBody](context.Background(), jc, "https://example.com/bucket/key", `"v2"`, doc{Name: "new"})
+	switch {
+	case err != nil:
+		t.Errorf("An unexpected error occurred: %s", err)
+	case out.ETag != `"v3"`:
+		t.Errorf("Unexpected ETag: %q", out.ETag)
+	}
+
+	_, err = PutIfMatch[doc, NoBody](context.Background(), jc, "https://example.com/bucket/key", `"v1"`, doc{Name: "new"})
+	var pfErr *PreconditionFailedError
+	var awsErr *AWSError
+	switch {
+	case !errors.As(err, &pfErr):
+		t.Errorf("Expected a *PreconditionFailedError, got %v", err)
+	case pfErr.Condition.IfMatch != `"v1"`:
+		t.Errorf("Unexpected condition: %+v", pfErr.Condition)
+	case !errors.As(err, &awsErr) || awsErr.Code != "PreconditionFailed":
+		t.Errorf("Expected the AWS error to be wrapped, got %v", err)
+	}
+}
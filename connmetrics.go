@@ -0,0 +1,196 @@
+package aws_signing_client
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// ConnectionInfo describes the connection a request was sent on, as found in RequestTiming.Conn when
+	// WithConnectionMetrics is used.
+	ConnectionInfo struct {
+		// Reused is true if the connection had carried an earlier request. WasIdle is true if it was taken from
+		// the idle pool, where it had waited for IdleTime.
+		Reused   bool
+		WasIdle  bool
+		IdleTime time.Duration
+		// DNS, Connect and TLSHandshake are the time spent resolving, connecting and in the TLS handshake for a new
+		// connection. They are zero for reused connections.
+		DNS          time.Duration
+		Connect      time.Duration
+		TLSHandshake time.Duration
+		// Pool is a snapshot of the Signer's connection pool once the connection was obtained.
+		Pool PoolStats
+	}
+
+	// PoolStats counts the connections of the wrapped transport, as returned by Signer.PoolStats.
+	PoolStats struct {
+		// Opened and Closed are the number of connections dialed and closed since the Signer was created.
+		Opened int64
+		Closed int64
+		// Reused is the number of requests that were sent on a connection that had carried an earlier request.
+		Reused int64
+		// Open is the number of connections currently open, and Idle the number of those waiting in the idle pool.
+		// HTTP/2 connections are never counted as idle.
+		Open int64
+		Idle int64
+	}
+
+	// connMetrics tracks the connections of a Signer created with WithConnectionMetrics.
+	connMetrics struct {
+		opened, closed, reused int64 // accessed atomically
+
+		mu   sync.Mutex
+		idle map[*trackedConn]bool
+	}
+
+	// trackedConn reports its closing to the connMetrics it was dialed for.
+	trackedConn struct {
+		net.Conn
+		metrics *connMetrics
+		closed  int32 // accessed atomically
+	}
+
+	// connTrace collects the ConnectionInfo of one attempt.
+	connTrace struct {
+		mu                               sync.Mutex
+		info                             ConnectionInfo
+		dnsStart, connectStart, tlsStart time.Time
+		conn                             *trackedConn
+	}
+)
+
+// WithConnectionMetrics tracks the connections of the wrapped transport: every RequestTiming passed to Hooks and
+// slow-request callbacks carries a ConnectionInfo with the handshake durations of a new connection or whether it was
+// reused, and Signer.PoolStats reports how many connections are open and idle. The wrapped transport must be an
+// *http.Transport (or nil for http.DefaultTransport); it is cloned, its dialer is wrapped to count connections, and
+// New fails with an *UnsupportedTransportError for any other transport. Connections made by a DialTLSContext are
+// not counted.
+func WithConnectionMetrics() Option {
+	return func(s *Signer) {
+		m := &connMetrics{idle: map[*trackedConn]bool{}}
+		s.connMetrics = m
+		s.transportConfigs = append(s.transportConfigs, transportConfig{option: "WithConnectionMetrics", apply: func(t *http.Transport) error {
+			dial := t.DialContext
+			if dial == nil {
+				dial = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+			}
+			t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+				conn, err := dial(ctx, network, addr)
+				if err != nil {
+					return nil, err
+				}
+				atomic.AddInt64(&m.opened, 1)
+				return &trackedConn{Conn: conn, metrics: m}, nil
+			}
+			return nil
+		}})
+	}
+}
+
+// PoolStats returns the connection counts of the wrapped transport. They are all zero unless WithConnectionMetrics
+// is used.
+func (s *Signer) PoolStats() PoolStats {
+	if s.connMetrics == nil {
+		return PoolStats{}
+	}
+	return s.connMetrics.stats()
+}
+
+func (m *connMetrics) stats() PoolStats {
+	ps := PoolStats{
+		Opened: atomic.LoadInt64(&m.opened),
+		Closed: atomic.LoadInt64(&m.closed),
+		Reused: atomic.LoadInt64(&m.reused),
+	}
+	ps.Open = ps.Opened - ps.Closed
+	m.mu.Lock()
+	ps.Idle = int64(len(m.idle))
+	m.mu.Unlock()
+	return ps
+}
+
+// trace returns a copy of ctx that collects the ConnectionInfo of an attempt into ct.
+func (m *connMetrics) trace(ctx context.Context, ct *connTrace) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { ct.set(func() { ct.dnsStart = time.Now() }) },
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			ct.set(func() { ct.info.DNS = time.Since(ct.dnsStart) })
+		},
+		ConnectStart: func(network, addr string) { ct.set(func() { ct.connectStart = time.Now() }) },
+		ConnectDone: func(network, addr string, err error) {
+			ct.set(func() { ct.info.Connect = time.Since(ct.connectStart) })
+		},
+		TLSHandshakeStart: func() { ct.set(func() { ct.tlsStart = time.Now() }) },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			ct.set(func() { ct.info.TLSHandshake = time.Since(ct.tlsStart) })
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			conn, _ := dialedConn(info.Conn).(*trackedConn)
+			if info.Reused {
+				atomic.AddInt64(&m.reused, 1)
+			}
+			if info.WasIdle && conn != nil {
+				m.mu.Lock()
+				delete(m.idle, conn)
+				m.mu.Unlock()
+			}
+			ct.set(func() {
+				ct.conn = conn
+				ct.info.Reused, ct.info.WasIdle, ct.info.IdleTime = info.Reused, info.WasIdle, info.IdleTime
+				ct.info.Pool = m.stats()
+			})
+		},
+		PutIdleConn: func(err error) {
+			ct.mu.Lock()
+			conn := ct.conn
+			ct.mu.Unlock()
+			if err != nil || conn == nil {
+				return
+			}
+			m.mu.Lock()
+			if atomic.LoadInt32(&conn.closed) == 0 {
+				m.idle[conn] = true
+			}
+			m.mu.Unlock()
+		},
+	})
+}
+
+// connectionInfo returns the ConnectionInfo collected so far.
+func (ct *connTrace) connectionInfo() ConnectionInfo {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	return ct.info
+}
+
+func (ct *connTrace) set(f func()) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	f()
+}
+
+// dialedConn unwraps a TLS connection to the connection returned by the dialer.
+func dialedConn(conn net.Conn) net.Conn {
+	if tc, ok := conn.(interface{ NetConn() net.Conn }); ok {
+		return tc.NetConn()
+	}
+	return conn
+}
+
+// Close implements net.Conn.
+func (tc *trackedConn) Close() error {
+	if atomic.CompareAndSwapInt32(&tc.closed, 0, 1) {
+		atomic.AddInt64(&tc.metrics.closed, 1)
+		tc.metrics.mu.Lock()
+		delete(tc.metrics.idle, tc)
+		tc.metrics.mu.Unlock()
+	}
+	return tc.Conn.Close()
+}
@@ -0,0 +1,104 @@
+package aws_signing_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+//    ____                            _   _
+//   / ___|___  _ __  _ __   ___  ___| |_(_) ___  _ __  ___
+//  | |   / _ \| '_ \| '_ \ / _ \/ __| __| |/ _ \| '_ \/ __|
+//  | |__| (_) | | | | | | |  __/ (__| |_| | (_) | | | \__ \
+//   \____\___/|_| |_|_| |_|\___|\___|\__|_|\___/|_| |_|___/
+//
+
+// TestWithConnectionMetrics ensures that new and reused connections are reported to Hooks and counted in the pool.
+func TestWithConnectionMetrics(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	rh := &recordingHooks{}
+	c, err := New(v4.NewSigner(creds), srv.Client(), "es", "us-east-1", nil, WithConnectionMetrics(), WithHooks(rh))
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+	for i := 0; i < 2; i++ {
+		resp, err := c.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("An unexpected error occurred while making a request: %s", err)
+		}
+		ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+
+	first, second := rh.requests[0].Conn, rh.requests[1].Conn
+	switch {
+	case first == nil || second == nil:
+		t.Fatal("No connection info was reported")
+	case first.Reused || first.TLSHandshake <= 0:
+		t.Errorf("Expected a new connection with a TLS handshake, got %+v", first)
+	case !second.Reused || !second.WasIdle || second.TLSHandshake != 0:
+		t.Errorf("Expected a reused idle connection, got %+v", second)
+	}
+	s := c.Transport.(*Signer)
+	if ps := s.PoolStats(); ps != (PoolStats{Opened: 1, Reused: 1, Open: 1, Idle: 1}) {
+		t.Errorf("Unexpected pool stats: %+v", ps)
+	}
+	c.CloseIdleConnections()
+	if ps := s.PoolStats(); ps != (PoolStats{Opened: 1, Closed: 1, Reused: 1}) {
+		t.Errorf("Unexpected pool stats after closing idle connections: %+v", ps)
+	}
+}
+
+// TestWarmup ensures that Warmup leaves a connection in the idle pool that the first request reuses.
+func TestWarmup(t *testing.T) {
+	var methods []string
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			t.Errorf("%s request was not signed", r.Method)
+		}
+		methods = append(methods, r.Method)
+	}))
+	defer srv.Close()
+
+	rh := &recordingHooks{}
+	c, err := New(v4.NewSigner(creds), srv.Client(), "es", "us-east-1", nil, WithConnectionMetrics(), WithHooks(rh))
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+	s := c.Transport.(*Signer)
+	if err := s.Warmup(context.Background(), srv.Listener.Addr().String()); err != nil {
+		t.Fatalf("An unexpected error occurred while warming up: %s", err)
+	}
+	if ps := s.PoolStats(); ps.Idle != 1 {
+		t.Errorf("Expected a warm idle connection, got %+v", ps)
+	}
+	resp, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while making a request: %s", err)
+	}
+	resp.Body.Close()
+	if conn := rh.requests[0].Conn; !conn.Reused {
+		t.Errorf("Expected the first request to reuse the warm connection, got %+v", conn)
+	}
+	if fmt.Sprint(methods) != "[HEAD GET]" {
+		t.Errorf("Unexpected requests: %v", methods)
+	}
+
+	c, _ = New(v4.NewSigner(creds), srv.Client(), "es", "us-east-1", nil, WithAllowedHosts("*.amazonaws.com"))
+	err = c.Transport.(*Signer).Warmup(context.Background(), "es.us-east-1.amazonaws.com", srv.Listener.Addr().String())
+	var dhe *DisallowedHostError
+	if !errors.As(err, &dhe) || len(methods) != 2 {
+		t.Errorf("Expected a *DisallowedHostError without sending a request, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package aws_signing_client
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// AnonymousMode controls how the Signer handles requests when its credentials are anonymous or empty, which is
+	// common during local development.
+	AnonymousMode int
+
+	// Credentials is an AWS key pair with an optional session token, independent of the SDK it was retrieved with.
+	Credentials struct {
+		AccessKeyID     string
+		SecretAccessKey string
+		SessionToken    string
+	}
+
+	// CredentialsRetriever is implemented by RequestSigners that can hand out their current credentials, for
+	// signatures that are not computed over an HTTP request, such as those of S3 POST policies. Both the aws-sdk-go
+	// and the awsv2 backends implement it.
+	CredentialsRetriever interface {
+		RetrieveCredentials(ctx context.Context) (Credentials, error)
+	}
+
+	// CredentialsRefresher is implemented by RequestSigners whose credentials can expire and be refreshed ahead of
+	// time. It is used by WithCredentialRefreshWindow. Both the aws-sdk-go and the awsv2 backends implement it.
+	CredentialsRefresher interface {
+		// CredentialsExpiry returns when the current credentials expire. ok is false if they do not expire or
+		// their expiry is unknown.
+		CredentialsExpiry(ctx context.Context) (expires time.Time, ok bool)
+		// RefreshCredentials retrieves new credentials.
+		RefreshCredentials(ctx context.Context) error
+	}
+
+	// AnonymousCredentialsError is an implementation of the error interface that indicates that a request was not
+	// sent because the signer's credentials are anonymous or empty.
+	AnonymousCredentialsError struct{}
+
+	// MissingCredentialsError is an implementation of the error interface that indicates that no access key ID or
+	// secret access key was provided in order to create a client.
+	MissingCredentialsError struct{}
+)
+
+const (
+	// AnonymousSign attempts to sign requests regardless of the credentials. This is the default, and fails with
+	// the credential provider's error on every request when no credentials are available.
+	AnonymousSign AnonymousMode = iota
+	// AnonymousPassThrough sends requests unsigned when the credentials are anonymous or empty.
+	AnonymousPassThrough
+	// AnonymousFail returns an AnonymousCredentialsError, before the request is read or sent, when the credentials
+	// are anonymous or empty.
+	AnonymousFail
+)
+
+// WithAnonymousCredentials sets how requests are handled when the signer's credentials are anonymous or empty.
+func WithAnonymousCredentials(mode AnonymousMode) Option {
+	return func(s *Signer) {
+		s.anonymous = mode
+	}
+}
+
+// isAnonymous reports whether rs knows its credentials to be anonymous or empty.
+func isAnonymous(ctx context.Context, rs RequestSigner) bool {
+	a, ok := rs.(AnonymousDetector)
+	return ok && a.Anonymous(ctx)
+}
+
+// Error implements the error interface.
+func (err AnonymousCredentialsError) Error() string {
+	return "The signer's credentials are anonymous or empty. Cannot sign request."
+}
+
+// Error implements the error interface.
+func (err MissingCredentialsError) Error() string {
+	return "No AWS access key ID or secret access key was provided. Cannot create client."
+}
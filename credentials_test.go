@@ -0,0 +1,98 @@
+package aws_signing_client
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+//      _
+//     / \   _ __   ___  _ __  _   _ _ __ ___   ___  _   _ ___
+//    / _ \ | '_ \ / _ \| '_ \| | | | '_ ` _ \ / _ \| | | / __|
+//   / ___ \| | | | (_) | | | | |_| | | | | | | (_) | |_| \__ \
+//  /_/   \_\_| |_|\___/|_| |_|\__, |_| |_| |_|\___/ \__,_|___/
+//                             |___/
+
+func anonymousClient(t *testing.T, c *credentials.Credentials, mode AnonymousMode) (*http.Client, *http.Request) {
+	var sent http.Request
+	hc, err := New(v4.NewSigner(c), &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent = *req
+		return response(200, "", nil), nil
+	})}, "es", "us-east-1", nil, WithAnonymousCredentials(mode))
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+	return hc, &sent
+}
+
+// TestAnonymousPassThrough ensures that requests are sent unsigned when the credentials are anonymous.
+func TestAnonymousPassThrough(t *testing.T) {
+	for _, c := range []*credentials.Credentials{credentials.AnonymousCredentials, credentials.NewStaticCredentials("", "", "")} {
+		hc, sent := anonymousClient(t, c, AnonymousPassThrough)
+		_, err := hc.Get("https://example.com/")
+		switch {
+		case err != nil:
+			t.Errorf("An unexpected error occurred while making a request: %s", err)
+		case sent.Header.Get("Authorization") != "":
+			t.Error("Request with anonymous credentials was signed")
+		}
+	}
+}
+
+// TestAnonymousFail ensures that an AnonymousCredentialsError is returned when the credentials are anonymous.
+func TestAnonymousFail(t *testing.T) {
+	hc, sent := anonymousClient(t, credentials.AnonymousCredentials, AnonymousFail)
+	_, err := hc.Get("https://example.com/")
+	switch {
+	case err == nil:
+		t.Error("No error was returned for a request with anonymous credentials")
+	case sent.URL != nil:
+		t.Error("Request with anonymous credentials was sent")
+	}
+}
+
+// TestAnonymousModeSignsRealCredentials ensures that real credentials are still used for signing.
+func TestAnonymousModeSignsRealCredentials(t *testing.T) {
+	hc, sent := anonymousClient(t, creds, AnonymousFail)
+	if _, err := hc.Get("https://example.com/"); err != nil {
+		t.Errorf("An unexpected error occurred while making a request: %s", err)
+	}
+	if sent.Header.Get("Authorization") == "" {
+		t.Error("Request with real credentials was not signed")
+	}
+}
+
+//   ____  _        _   _
+//  / ___|| |_ __ _| |_(_) ___
+//  \___ \| __/ _` | __| |/ __|
+//   ___) | || (_| | |_| | (__
+//  |____/ \__\__,_|\__|_|\___|
+//
+
+// TestNewWithStaticCredentials ensures that a client created from a static key pair signs requests.
+func TestNewWithStaticCredentials(t *testing.T) {
+	hc, err := NewWithStaticCredentials("ID", "SECRET", "TOKEN", "es", "us-east-1")
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+	var sent *http.Request
+	hc.Transport.(*Signer).transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent = req
+		return response(200, "", nil), nil
+	})
+	if _, err := hc.Get("https://example.com/"); err != nil {
+		t.Fatalf("An unexpected error occurred while making a request: %s", err)
+	}
+	if sent.Header.Get("X-Amz-Security-Token") != "TOKEN" || sent.Header.Get("Authorization") == "" {
+		t.Error("Request was not signed with the static credentials")
+	}
+}
+
+// TestNewWithStaticCredentialsMissingKey tests NewWithStaticCredentials() when it is not passed a key pair.
+func TestNewWithStaticCredentialsMissingKey(t *testing.T) {
+	if _, err := NewWithStaticCredentials("", "SECRET", "", "es", "us-east-1"); err != (MissingCredentialsError{}) {
+		t.Error("Error was not of type MissingCredentialsError")
+	}
+}
@@ -0,0 +1,90 @@
+package aws_signing_client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+type (
+	// Destination is a named endpoint requests can be addressed to by alias, so that application code does not
+	// hard-code regional host names.
+	Destination struct {
+		// Host is the destination's host, with an optional port, e.g. "orders.execute-api.eu-west-1.amazonaws.com".
+		Host string
+		// Service and Region are what requests to the destination are signed for. They default to the client's.
+		Service string
+		Region  string
+		// Signer signs requests to the destination, e.g. with the credentials of another account's role. If nil,
+		// the client's RequestSigner is used.
+		Signer RequestSigner
+	}
+
+	// UnknownDestinationError is an implementation of the error interface that is returned by RoundTrip for
+	// requests addressed with WithDestination to an alias that was not set with WithDestinations.
+	UnknownDestinationError struct {
+		Alias string
+	}
+
+	destinationKey struct{}
+)
+
+// WithDestinations adds named destinations requests can be addressed to, either with a context returned by
+// WithDestination or by using the alias as the host of the request URL, e.g. "https://orders/v1/items". Requests to a
+// destination are sent to its host and signed for its service, region and RequestSigner, instead of the client's
+// scope and the signing name and region set with WithSigningName and WithSigningRegion; they are not spread by
+// WithRegions. The host is still subject to WithAllowedHosts. Redirect hops keep their host and are signed for the
+// destination. The aliases can be loaded from configuration, so that endpoints change without code changes. It may
+// be given more than once; later aliases replace earlier ones of the same name.
+func WithDestinations(destinations map[string]Destination) Option {
+	return func(s *Signer) {
+		if s.destinations == nil {
+			s.destinations = map[string]Destination{}
+		}
+		for alias, d := range destinations {
+			s.destinations[alias] = d
+		}
+	}
+}
+
+// WithDestination returns a copy of ctx that addresses a request made with it to the destination set with
+// WithDestinations under alias, whatever the host of its URL.
+func WithDestination(ctx context.Context, alias string) context.Context {
+	return context.WithValue(ctx, destinationKey{}, alias)
+}
+
+// route points req at the destination it is addressed to, if any, and returns the scope it is signed for.
+func (s *Signer) route(ctx context.Context, req *http.Request, sc *signingScope) (*signingScope, error) {
+	alias, ok := ctx.Value(destinationKey{}).(string)
+	if !ok {
+		alias = req.URL.Hostname()
+	}
+	d, found := s.destinations[alias]
+	switch {
+	case !found && ok:
+		return nil, &UnknownDestinationError{Alias: alias}
+	case !found:
+		return sc, nil
+	}
+
+	if req.Response == nil && d.Host != "" {
+		req.URL.Host, req.Host = d.Host, ""
+	}
+	routed := &signingScope{signer: d.Signer, service: d.Service, region: d.Region, destination: alias, derived: true}
+	if routed.signer == nil {
+		routed.signer = sc.signer
+	}
+	if routed.service == "" {
+		routed.service = sc.service
+	}
+	if routed.region == "" {
+		routed.region = sc.region
+	}
+	s.logf(ctx, "Routing request to destination '%s' at '%s'.", alias, req.URL.Host)
+	return routed, nil
+}
+
+// Error implements the error interface.
+func (err *UnknownDestinationError) Error() string {
+	return fmt.Sprintf("No destination named '%s' was configured. Refusing to send request.", err.Alias)
+}
@@ -0,0 +1,54 @@
+package aws_signing_client
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// TestWithDestinations ensures that requests addressed by alias are sent to the destination's host and signed for
+// its service, region and credentials.
+func TestWithDestinations(t *testing.T) {
+	var sent *http.Request
+	other := v4.NewSigner(credentials.NewStaticCredentials("OTHER", "SECRET", ""))
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		sent = req
+		return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+	}, WithSigningName("aoss"), WithDestinations(map[string]Destination{
+		"orders":  {Host: "abc123.execute-api.eu-west-1.amazonaws.com", Service: "execute-api", Region: "eu-west-1", Signer: other},
+		"archive": {Host: "archive.s3.us-west-2.amazonaws.com", Service: "s3"},
+	}))
+
+	ctx := WithDestination(context.Background(), "archive")
+	for _, tc := range []struct {
+		ctx         context.Context
+		url         string
+		host, scope string
+	}{
+		{context.Background(), "https://orders/v1/items", "abc123.execute-api.eu-west-1.amazonaws.com", "Credential=OTHER/*/eu-west-1/execute-api/"},
+		{ctx, "https://placeholder/2024/report.csv", "archive.s3.us-west-2.amazonaws.com", "Credential=ID/*/us-east-1/s3/"},
+		{context.Background(), "https://search.example.com/_search", "search.example.com", "Credential=ID/*/us-east-1/aoss/"},
+	} {
+		req, _ := http.NewRequestWithContext(tc.ctx, http.MethodGet, tc.url, nil)
+		if _, err := c.Do(req); err != nil {
+			t.Fatalf("An unexpected error occurred while making a request: %s", err)
+		}
+		auth := sent.Header.Get("Authorization")
+		prefix, suffix, _ := strings.Cut(tc.scope, "*")
+		if sent.URL.Host != tc.host || !strings.Contains(auth, prefix) || !strings.Contains(auth, suffix) {
+			t.Errorf("%s: expected a request to %s signed with %s, got %s signed with %s", tc.url, tc.host, tc.scope, sent.URL.Host, auth)
+		}
+	}
+
+	req, _ := http.NewRequestWithContext(WithDestination(context.Background(), "billing"), http.MethodGet, "https://billing/", nil)
+	var ude *UnknownDestinationError
+	if _, err := c.Do(req); !errors.As(err, &ude) || ude.Alias != "billing" {
+		t.Errorf("Expected an *UnknownDestinationError, got %v", err)
+	}
+}
@@ -0,0 +1,251 @@
+package aws_signing_client
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Names of the checks made by Signer.Diagnose.
+const (
+	CheckCredentials = "credentials"
+	CheckSigning     = "signing"
+	CheckIdentity    = "identity"
+	CheckDNS         = "dns"
+	CheckTLS         = "tls"
+	CheckClock       = "clock"
+)
+
+type (
+	// DiagnosticReport is the result of Signer.Diagnose.
+	DiagnosticReport struct {
+		// Service and Region are the client's scope; SigningName and SigningRegion are what requests are signed for.
+		Service       string
+		Region        string
+		SigningName   string
+		SigningRegion string
+		// ClockSkew is how far the server's clock is ahead of the local one, as measured against the first host
+		// whose response carried a Date header. It is accurate to about a second.
+		ClockSkew time.Duration
+		Checks    []DiagnosticCheck
+	}
+
+	// DiagnosticCheck is the outcome of one check of a DiagnosticReport.
+	DiagnosticCheck struct {
+		// Name is one of the Check constants.
+		Name string
+		// Host is the host the check was made against, if any.
+		Host string
+		OK   bool
+		// Detail describes what was found, e.g. the access key ID or the negotiated TLS version.
+		Detail string
+		Err    error
+	}
+)
+
+// Diagnose checks the configuration of the Signer and returns a report, for debugging clients whose requests are
+// rejected, e.g. with a 403. It checks that the credentials can be retrieved and have not expired, that a request can
+// be signed, that STS accepts the credentials (see VerifyCredentials), and, for every host, that it resolves, that a
+// TLS connection can be established through the wrapped transport, and that the local clock is within
+// SignatureValidity of the server's. Without hosts, the endpoints set with WithRegions are checked, or the regional STS
+// endpoint if there are none. The reachability checks send an unsigned HEAD request to "/" of each host; any response
+// counts as reachable.
+func (s *Signer) Diagnose(ctx context.Context, hosts ...string) *DiagnosticReport {
+	sc := s.loadScope()
+	name, region := s.signingValues(sc)
+	r := &DiagnosticReport{Service: sc.service, Region: sc.region, SigningName: name, SigningRegion: region}
+	if len(hosts) == 0 && s.regions != nil {
+		for _, rs := range s.regions.regions {
+			hosts = append(hosts, rs.Host)
+		}
+	}
+	if len(hosts) == 0 {
+		hosts = []string{s.partition(sc.region).Host("sts", sc.region)}
+	}
+
+	r.Checks = append(r.Checks, s.diagnoseCredentials(ctx, sc), s.diagnoseSigning(ctx, sc, name, region))
+	id, err := s.VerifyCredentials(ctx)
+	if err != nil {
+		r.Checks = append(r.Checks, DiagnosticCheck{Name: CheckIdentity, Err: err})
+	} else {
+		r.Checks = append(r.Checks, DiagnosticCheck{Name: CheckIdentity, OK: true, Detail: id.ARN})
+	}
+
+	skewMeasured := false
+	for _, host := range hosts {
+		r.Checks = append(r.Checks, diagnoseDNS(ctx, host))
+		check, date, local := s.diagnoseTLS(ctx, host)
+		r.Checks = append(r.Checks, check)
+		if date.IsZero() {
+			continue
+		}
+		skew := date.Sub(local)
+		clock := DiagnosticCheck{Name: CheckClock, Host: host, OK: skew < SignatureValidity && skew > -SignatureValidity}
+		clock.Detail = fmt.Sprintf("server clock is %s ahead", skew.Round(time.Second))
+		if !clock.OK {
+			clock.Err = fmt.Errorf("the clock skew of %s exceeds %s, so signatures will be rejected", skew.Round(time.Second), SignatureValidity)
+		}
+		r.Checks = append(r.Checks, clock)
+		if !skewMeasured {
+			r.ClockSkew, skewMeasured = skew, true
+		}
+	}
+	for _, c := range r.Checks {
+		s.logf(ctx, "Diagnostic check %s", c)
+	}
+	return r
+}
+
+// diagnoseCredentials checks that the credentials of sc can be retrieved and have not expired.
+func (s *Signer) diagnoseCredentials(ctx context.Context, sc *signingScope) DiagnosticCheck {
+	c := DiagnosticCheck{Name: CheckCredentials}
+	if isAnonymous(ctx, sc.signer) {
+		c.Err = AnonymousCredentialsError{}
+		return c
+	}
+	cr, ok := sc.signer.(CredentialsRetriever)
+	if !ok {
+		c.OK, c.Detail = true, fmt.Sprintf("the %T RequestSigner does not expose its credentials", sc.signer)
+		return c
+	}
+	creds, err := cr.RetrieveCredentials(ctx)
+	if err != nil {
+		c.Err = err
+		return c
+	}
+	c.OK, c.Detail = true, "access key ID "+creds.AccessKeyID
+	if creds.SessionToken != "" {
+		c.Detail += " with a session token"
+	}
+	if rf, ok := sc.signer.(CredentialsRefresher); ok {
+		if expires, ok := rf.CredentialsExpiry(ctx); ok {
+			remaining := time.Until(expires)
+			if remaining <= 0 {
+				c.OK, c.Err = false, fmt.Errorf("the credentials expired %s ago", (-remaining).Round(time.Second))
+			} else {
+				c.Detail += fmt.Sprintf(", expiring in %s", remaining.Round(time.Second))
+			}
+		}
+	}
+	return c
+}
+
+// diagnoseSigning checks that a test request can be signed for name and region.
+func (s *Signer) diagnoseSigning(ctx context.Context, sc *signingScope, name, region string) DiagnosticCheck {
+	c := DiagnosticCheck{Name: CheckSigning}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://localhost/", nil)
+	if err != nil {
+		c.Err = err
+		return c
+	}
+	if _, err := sc.signer.Sign(req, nil, name, region, time.Now()); err != nil {
+		c.Err = err
+		return c
+	}
+	auth := req.Header.Get("Authorization")
+	i := strings.Index(auth, "Credential=")
+	if i < 0 {
+		c.Err = fmt.Errorf("the signed request has no SigV4 Authorization header: %q", auth)
+		return c
+	}
+	scope := auth[i+len("Credential="):]
+	if j := strings.IndexByte(scope, ','); j >= 0 {
+		scope = scope[:j]
+	}
+	c.OK, c.Detail = true, "credential scope "+scope
+	return c
+}
+
+// diagnoseDNS checks that host resolves.
+func diagnoseDNS(ctx context.Context, host string) DiagnosticCheck {
+	c := DiagnosticCheck{Name: CheckDNS, Host: host}
+	hostname := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+	}
+	if net.ParseIP(hostname) != nil {
+		c.OK, c.Detail = true, "IP address"
+		return c
+	}
+	addrs, err := net.DefaultResolver.LookupHost(ctx, hostname)
+	if err != nil {
+		c.Err = err
+		return c
+	}
+	c.OK, c.Detail = true, strings.Join(addrs, ", ")
+	return c
+}
+
+// diagnoseTLS sends an unsigned HEAD request to host and returns the outcome, along with the Date of the response
+// and the local time at the midpoint of the request, if the response had one.
+func (s *Signer) diagnoseTLS(ctx context.Context, host string) (c DiagnosticCheck, date, local time.Time) {
+	c = DiagnosticCheck{Name: CheckTLS, Host: host}
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, "https://"+host+"/", nil)
+	if err != nil {
+		c.Err = err
+		return c, date, local
+	}
+	if !s.hostAllowed(req.URL.Hostname()) {
+		c.Err = &DisallowedHostError{Host: req.URL.Hostname()}
+		return c, date, local
+	}
+	start := time.Now()
+	resp, err := s.transportFor(req.URL.Hostname()).RoundTrip(req)
+	if err != nil {
+		c.Err = err
+		return c, date, local
+	}
+	local = start.Add(time.Since(start) / 2)
+	DrainAndClose(resp)
+	c.OK, c.Detail = true, fmt.Sprintf("HTTP %d", resp.StatusCode)
+	if resp.TLS != nil {
+		c.Detail = fmt.Sprintf("%s, %s", tls.VersionName(resp.TLS.Version), c.Detail)
+	}
+	if date, err = http.ParseTime(resp.Header.Get("Date")); err != nil {
+		return c, time.Time{}, local
+	}
+	return c, date, local
+}
+
+// OK reports whether every check passed.
+func (r *DiagnosticReport) OK() bool {
+	for _, c := range r.Checks {
+		if !c.OK {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the report with one line per check.
+func (r *DiagnosticReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Signing %s/%s as %s/%s\n", r.Service, r.Region, r.SigningName, r.SigningRegion)
+	for _, c := range r.Checks {
+		fmt.Fprintf(&b, "%s\n", c)
+	}
+	return b.String()
+}
+
+// String formats the check on a single line.
+func (c DiagnosticCheck) String() string {
+	status := "OK  "
+	if !c.OK {
+		status = "FAIL"
+	}
+	s := status + " " + c.Name
+	if c.Host != "" {
+		s += " " + c.Host
+	}
+	if c.Detail != "" {
+		s += ": " + c.Detail
+	}
+	if c.Err != nil {
+		s += ": " + c.Err.Error()
+	}
+	return s
+}
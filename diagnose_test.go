@@ -0,0 +1,57 @@
+package aws_signing_client
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDiagnose ensures that Diagnose reports the outcome of every check, including a skewed clock.
+func TestDiagnose(t *testing.T) {
+	var skew time.Duration
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Error("The reachability check was signed")
+		}
+		w.Header().Set("Date", time.Now().Add(skew).UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	_, s := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		if req.URL.Host == "sts.us-east-1.amazonaws.com" {
+			return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(
+				`<GetCallerIdentityResponse><GetCallerIdentityResult><Arn>arn:aws:iam::123456789012:user/app</Arn></GetCallerIdentityResult></GetCallerIdentityResponse>`))}, nil
+		}
+		return srv.Client().Transport.RoundTrip(req)
+	})
+	host := srv.Listener.Addr().String()
+
+	r := s.Diagnose(context.Background(), host)
+	if !r.OK() || r.ClockSkew < -2*time.Second || r.ClockSkew > time.Second {
+		t.Errorf("Expected every check to pass, got:\n%s", r)
+	}
+	want := []string{
+		"OK   credentials: access key ID ID with a session token",
+		"OK   signing: credential scope ID/",
+		"OK   identity: arn:aws:iam::123456789012:user/app",
+		"OK   dns " + host + ": IP address",
+		"OK   tls " + host + ": TLS 1.3, HTTP 403",
+		"OK   clock " + host + ": server clock is ",
+	}
+	for i, c := range r.Checks {
+		if i >= len(want) || !strings.HasPrefix(c.String(), want[i]) {
+			t.Errorf("Unexpected check %d: %s", i, c)
+		}
+	}
+
+	skew = 10 * time.Minute
+	r = s.Diagnose(context.Background(), host)
+	if clock := r.Checks[len(r.Checks)-1]; r.OK() || clock.Name != CheckClock || clock.OK || r.ClockSkew < 9*time.Minute {
+		t.Errorf("Expected the clock check to fail, got:\n%s", r)
+	}
+}
@@ -0,0 +1,203 @@
+package aws_signing_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// DefaultDownloadPartSize is the default number of bytes a Downloader fetches per Range request.
+	DefaultDownloadPartSize = 8 << 20
+	// DefaultDownloadConcurrency is the default number of parts a Downloader fetches at once.
+	DefaultDownloadConcurrency = 4
+	// DefaultDownloadMaxAttempts is the default number of requests a Downloader makes for a part before its failure
+	// is final.
+	DefaultDownloadMaxAttempts = 3
+)
+
+// Downloader fetches large objects with signed GET requests over an *http.Client, typically one returned by New.
+// The object is split into parts that are fetched in parallel with Range requests, each signed on its own. A part
+// whose request or body fails with a retryable error (see IsRetryable) is resumed from the last byte received. Parts
+// after the first are sent with If-Match and the ETag of the first response, so that an object replaced during the
+// download fails with a 412 *AWSError rather than being stitched together from two versions.
+type Downloader struct {
+	// Client is the HTTP client used to send requests. If nil, http.DefaultClient is used.
+	Client *http.Client
+	// PartSize is the number of bytes requested per part. Zero means DefaultDownloadPartSize.
+	PartSize int64
+	// Concurrency is the number of parts fetched at once. Zero means DefaultDownloadConcurrency.
+	Concurrency int
+	// MaxAttempts is the number of requests made for a part before its failure is final. Zero means
+	// DefaultDownloadMaxAttempts.
+	MaxAttempts int
+	// Backoff decides the delay before a part is resumed. If nil, ExponentialBackoff is used.
+	Backoff Backoff
+}
+
+// NewDownloader returns a Downloader that sends requests using the provided client.
+func NewDownloader(client *http.Client) *Downloader {
+	return &Downloader{Client: client}
+}
+
+// Download fetches the object at url into w and returns its size. If the server ignores the Range header, the
+// object is copied from the single response instead. The first failed part cancels the others and its error is
+// returned; the content of w is then incomplete.
+func (d *Downloader) Download(ctx context.Context, url string, w io.WriterAt) (int64, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	partSize := d.PartSize
+	if partSize <= 0 {
+		partSize = DefaultDownloadPartSize
+	}
+	resp, err := d.get(ctx, url, 0, partSize-1, "")
+	if err != nil {
+		var awsErr *AWSError
+		if errors.As(err, &awsErr) && awsErr.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+			// S3 rejects any range of an empty object.
+			return 0, nil
+		}
+		return 0, err
+	}
+	if resp.StatusCode == http.StatusOK {
+		defer resp.Body.Close()
+		return io.Copy(io.NewOffsetWriter(w, 0), resp.Body)
+	}
+	size, err := contentRangeSize(resp.Header.Get("Content-Range"))
+	if err != nil {
+		resp.Body.Close()
+		return 0, err
+	}
+	etag := resp.Header.Get("ETag")
+
+	concurrency := d.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultDownloadConcurrency
+	}
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		slots    = make(chan struct{}, concurrency)
+	)
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+	for start := int64(0); start < size; start += partSize {
+		end := start + partSize - 1
+		if end >= size {
+			end = size - 1
+		}
+		var first *http.Response
+		if start == 0 {
+			first = resp
+		}
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			if first != nil {
+				first.Body.Close()
+			}
+			fail(ctx.Err())
+			break
+		}
+		wg.Add(1)
+		go func(start, end int64, resp *http.Response) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			if err := d.part(ctx, url, etag, start, end, w, resp); err != nil {
+				fail(err)
+			}
+		}(start, end, first)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return 0, firstErr
+	}
+	return size, nil
+}
+
+// part writes the bytes start through end of the object to w, resuming after failures. If resp is not nil, it is
+// the response to the part's first request.
+func (d *Downloader) part(ctx context.Context, url, etag string, start, end int64, w io.WriterAt, resp *http.Response) error {
+	maxAttempts := d.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultDownloadMaxAttempts
+	}
+	return retryCall(ctx, maxAttempts, d.Backoff, func() error {
+		var err error
+		if resp == nil {
+			resp, err = d.get(ctx, url, start, end, etag)
+		}
+		if err == nil {
+			var n int64
+			n, err = io.Copy(io.NewOffsetWriter(w, start), io.LimitReader(resp.Body, end-start+1))
+			resp.Body.Close()
+			start += n
+			if err == nil && start <= end {
+				err = io.ErrUnexpectedEOF
+			}
+		}
+		resp = nil
+		return err
+	})
+}
+
+// get sends a signed GET request for the bytes start through end of the object. It returns an *AWSError for
+// responses other than 200 and 206.
+func (d *Downloader) get(ctx context.Context, url string, start, end int64, etag string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+	if etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
+
+	client := d.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, MaxErrorBodySize))
+		return nil, responseError(resp, b)
+	}
+	if resp.StatusCode == http.StatusOK && start > 0 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s ignored the Range header of a part request", url)
+	}
+	return resp, nil
+}
+
+// contentRangeSize returns the complete length from a Content-Range header such as "bytes 0-99/1234".
+func contentRangeSize(h string) (int64, error) {
+	i := strings.LastIndexByte(h, '/')
+	if !strings.HasPrefix(h, "bytes ") || i < 0 {
+		return 0, fmt.Errorf("invalid Content-Range header %q", h)
+	}
+	size, err := strconv.ParseInt(h[i+1:], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Content-Range header %q", h)
+	}
+	return size, nil
+}
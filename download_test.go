@@ -0,0 +1,138 @@
+package aws_signing_client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// truncatingWriter fails after n bytes, which makes the server cut the response short.
+type truncatingWriter struct {
+	http.ResponseWriter
+	n int
+}
+
+func (w *truncatingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		p = p[:w.n]
+	}
+	n, _ := w.ResponseWriter.Write(p)
+	w.n -= n
+	if w.n == 0 {
+		return n, errors.New("truncated")
+	}
+	return n, nil
+}
+
+// TestDownloader ensures that objects are downloaded in signed parts and that a part cut short is resumed.
+func TestDownloader(t *testing.T) {
+	object := []byte(strings.Repeat("0123456789", 100))
+	var (
+		mu        sync.Mutex
+		ranges    []string
+		truncated bool
+	)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			t.Errorf("Request for %s was not signed", r.Header.Get("Range"))
+		}
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		cut := !truncated && r.Header.Get("Range") == "bytes=300-599"
+		truncated = truncated || cut
+		mu.Unlock()
+		w.Header().Set("ETag", `"v1"`)
+		if cut {
+			w = &truncatingWriter{ResponseWriter: w, n: 100}
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(object))
+	}))
+	defer srv.Close()
+
+	c, err := New(v4.NewSigner(creds), srv.Client(), "s3", "us-east-1", nil)
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+	f, err := os.Create(filepath.Join(t.TempDir(), "object"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	d := &Downloader{Client: c, PartSize: 300, Concurrency: 2, Backoff: constantBackoff(0)}
+	n, err := d.Download(context.Background(), srv.URL+"/bucket/key", f)
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while downloading: %s", err)
+	}
+	got, _ := os.ReadFile(f.Name())
+	if n != int64(len(object)) || !bytes.Equal(got, object) {
+		t.Errorf("Expected the object to be downloaded, got %d bytes: %q", n, got)
+	}
+	want := map[string]bool{"bytes=0-299": true, "bytes=300-599": true, "bytes=400-599": true, "bytes=600-899": true, "bytes=900-999": true}
+	if len(ranges) != len(want) {
+		t.Errorf("Unexpected requests: %v", ranges)
+	}
+	for _, r := range ranges {
+		if !want[r] {
+			t.Errorf("Unexpected request for %s", r)
+		}
+	}
+}
+
+// TestDownloaderObjectChanged ensures that a download fails if the object is replaced while its parts are fetched.
+func TestDownloaderObjectChanged(t *testing.T) {
+	var mu sync.Mutex
+	version := 0
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		version++
+		w.Header().Set("ETag", fmt.Sprintf(`"v%d"`, version))
+		mu.Unlock()
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(strings.Repeat("x", 100)))
+	}))
+	defer srv.Close()
+
+	c, _ := New(v4.NewSigner(creds), srv.Client(), "s3", "us-east-1", nil)
+	d := &Downloader{Client: c, PartSize: 50, Backoff: constantBackoff(0)}
+	f, err := os.Create(filepath.Join(t.TempDir(), "object"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	_, err = d.Download(context.Background(), srv.URL, f)
+	var awsErr *AWSError
+	if !errors.As(err, &awsErr) || awsErr.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("Expected a 412 *AWSError, got %v", err)
+	}
+}
+
+// TestDownloaderWithoutRange ensures that an object is copied from a response that ignores the Range header.
+func TestDownloaderWithoutRange(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("whole object"))
+	}))
+	defer srv.Close()
+
+	c, _ := New(v4.NewSigner(creds), srv.Client(), "s3", "us-east-1", nil)
+	f, err := os.Create(filepath.Join(t.TempDir(), "object"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	n, err := NewDownloader(c).Download(context.Background(), srv.URL, f)
+	got, _ := os.ReadFile(f.Name())
+	if err != nil || n != 12 || string(got) != "whole object" {
+		t.Errorf("Expected the whole object, got %d bytes %q and %v", n, got, err)
+	}
+}
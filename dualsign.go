@@ -0,0 +1,98 @@
+package aws_signing_client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Checks of a SignerMismatch.
+const (
+	// MismatchError means the candidate RequestSigner failed to sign a request the primary signed.
+	MismatchError = "error"
+	// MismatchSignedHeaders means the two RequestSigners signed different headers.
+	MismatchSignedHeaders = "signed-headers"
+	// MismatchCanonicalRequest means the two RequestSigners signed different canonical requests.
+	MismatchCanonicalRequest = "canonical-request"
+	// MismatchSignature means the two RequestSigners computed different signatures with the same algorithm.
+	MismatchSignature = "signature"
+)
+
+// SignerMismatch describes a request that the candidate RequestSigner of WithCandidateSigner signed differently than
+// the primary one, as passed to Hooks.OnSignerMismatch.
+type SignerMismatch struct {
+	// Check is the first check that failed, one of the Mismatch constants.
+	Check  string
+	Method string
+	Host   string
+	Path   string
+	// Primary and Candidate are the values that differ: the signed headers, the canonical requests or the
+	// Authorization headers. Candidate is empty when Err is set.
+	Primary   string
+	Candidate string
+	// Err is the error of the candidate RequestSigner.
+	Err error
+}
+
+// WithCandidateSigner signs every attempt a second time with candidate, e.g. the awsv2 backend when migrating from
+// aws-sdk-go, and compares the result with the signature of the client's RequestSigner, which is the one sent. The
+// signed headers and the canonical requests must match, as must the Authorization headers of signatures computed
+// with the same algorithm; the first difference is logged and reported to Hooks.OnSignerMismatch. The X-Amz-Region-Set
+// header that only SigV4A signs is left out of the comparison, so that a SigV4A candidate can be validated against a
+// SigV4 primary. Requests routed to a Destination with its own RequestSigner are not compared. The candidate's
+// signing time counts towards RequestTiming.Sign.
+func WithCandidateSigner(candidate RequestSigner) Option {
+	return func(s *Signer) {
+		s.candidate = candidate
+	}
+}
+
+// compareCandidate signs shadow, a copy of req taken before req was signed by the primary RequestSigner, with the
+// candidate RequestSigner and reports how their signatures differ, if they do.
+func (s *Signer) compareCandidate(ctx context.Context, req, shadow *http.Request, p *payload, service, region string, t time.Time) {
+	var body io.ReadSeeker
+	if p != nil {
+		body = p.reader()
+	}
+	_, err := s.candidate.Sign(shadow, body, service, region, t)
+	primary, candidate := comparedHeaderNames(req), comparedHeaderNames(shadow)
+	primaryAuth, candidateAuth := req.Header.Get("Authorization"), shadow.Header.Get("Authorization")
+	primaryCanon, candidateCanon := canonicalRequestFor(req, p.bytes(), primary), canonicalRequestFor(shadow, p.bytes(), candidate)
+
+	m := SignerMismatch{Method: req.Method, Host: req.URL.Host, Path: req.URL.Path}
+	switch {
+	case err != nil:
+		m.Check, m.Err = MismatchError, err
+	case strings.Join(primary, ";") != strings.Join(candidate, ";"):
+		m.Check, m.Primary, m.Candidate = MismatchSignedHeaders, strings.Join(primary, ";"), strings.Join(candidate, ";")
+	case primaryCanon != candidateCanon:
+		m.Check, m.Primary, m.Candidate = MismatchCanonicalRequest, primaryCanon, candidateCanon
+	case algorithm(primaryAuth) == algorithm(candidateAuth) && primaryAuth != candidateAuth:
+		m.Check, m.Primary, m.Candidate = MismatchSignature, primaryAuth, candidateAuth
+	default:
+		return
+	}
+
+	s.logf(ctx, "Candidate signer mismatch on %s %s: %s.", m.Method, m.Host+m.Path, m.Check)
+	for _, h := range s.hooks {
+		h.OnSignerMismatch(ctx, m)
+	}
+}
+
+// comparedHeaderNames returns the signed headers of req, without X-Amz-Region-Set.
+func comparedHeaderNames(req *http.Request) []string {
+	var names []string
+	for _, name := range signedHeaderNames(req) {
+		if name != "x-amz-region-set" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// algorithm returns the signing algorithm of an Authorization header.
+func algorithm(auth string) string {
+	return strings.SplitN(auth, " ", 2)[0]
+}
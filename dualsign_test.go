@@ -0,0 +1,67 @@
+package aws_signing_client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// mismatchHooks records the mismatches reported by WithCandidateSigner.
+type mismatchHooks struct {
+	NopHooks
+	mismatches []SignerMismatch
+}
+
+func (mh *mismatchHooks) OnSignerMismatch(ctx context.Context, m SignerMismatch) {
+	mh.mismatches = append(mh.mismatches, m)
+}
+
+// failingSigner fails to sign every request.
+type failingSigner struct{}
+
+func (failingSigner) Sign(r *http.Request, body io.ReadSeeker, service, region string, signTime time.Time) (http.Header, error) {
+	return nil, errors.New("unsupported")
+}
+
+// TestWithCandidateSigner ensures that requests signed differently by the candidate signer are reported, and that
+// the primary signature is the one sent.
+func TestWithCandidateSigner(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		candidate RequestSigner
+		check     string
+	}{
+		{"same", v4.NewSigner(creds), ""},
+		{"other credentials", v4.NewSigner(credentials.NewStaticCredentials("OTHER", "SECRET", "TOKEN")), MismatchSignature},
+		{"unsigned token", v4.NewSigner(credentials.NewStaticCredentials("ID", "SECRET", "")), MismatchSignedHeaders},
+		{"failing", failingSigner{}, MismatchError},
+	} {
+		var sent *http.Request
+		mh := &mismatchHooks{}
+		c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+			sent = req
+			return response(200, "", nil), nil
+		}, WithCandidateSigner(tc.candidate), WithUnsignedHeaders("X-Trace"), WithHooks(mh))
+		req, _ := http.NewRequest(http.MethodPost, "https://example.com/a%2Cb", strings.NewReader(`{"query":{}}`))
+		req.Header.Set("X-Trace", "1")
+		if _, err := c.Do(req); err != nil {
+			t.Fatalf("%s: an unexpected error occurred: %s", tc.name, err)
+		}
+
+		switch {
+		case tc.check == "" && len(mh.mismatches) != 0:
+			t.Errorf("%s: unexpected mismatches: %+v", tc.name, mh.mismatches)
+		case tc.check != "" && (len(mh.mismatches) != 1 || mh.mismatches[0].Check != tc.check):
+			t.Errorf("%s: expected a %s mismatch, got %+v", tc.name, tc.check, mh.mismatches)
+		case !strings.Contains(sent.Header.Get("Authorization"), "Credential=ID/"):
+			t.Errorf("%s: expected the primary signature to be sent, got %s", tc.name, sent.Header.Get("Authorization"))
+		}
+	}
+}
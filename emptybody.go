@@ -0,0 +1,38 @@
+package aws_signing_client
+
+import (
+	"net/http"
+)
+
+// EmptyBodyMode selects how the Signer signs requests whose body is not nil but empty, such as http.NoBody or a
+// reader over an empty buffer.
+type EmptyBodyMode int
+
+const (
+	// EmptyBodyHashed signs an empty body as a payload: the RequestSigner is given an empty reader and hashes the
+	// empty string. It is the default.
+	EmptyBodyHashed EmptyBodyMode = iota
+	// EmptyBodyAsNil signs and sends an empty body as if the request had no body: the RequestSigner is given a nil
+	// body and the request is sent with a nil Body, so that it is signed the same way as a request built without
+	// one.
+	EmptyBodyAsNil
+)
+
+// WithEmptyBodies sets how requests with an empty, non-nil body are signed. With the aws-sdk-go signers both modes
+// produce the hash of the empty string, but a RequestSigner may treat a nil body differently, e.g. as an unsigned
+// payload. It applies to Client.Sign and Client.Presign as well, which always sign http.NoBody as no body.
+func WithEmptyBodies(mode EmptyBodyMode) Option {
+	return func(s *Signer) {
+		s.emptyBodies = mode
+	}
+}
+
+// dropEmptyBody removes the empty body d of req if empty bodies are treated as nil, and reports whether it did.
+func (s *Signer) dropEmptyBody(req *http.Request, d []byte) bool {
+	if s.emptyBodies != EmptyBodyAsNil || d == nil || len(d) > 0 {
+		return false
+	}
+	req.Body.Close()
+	req.Body, req.ContentLength = nil, 0
+	return true
+}
@@ -0,0 +1,89 @@
+package aws_signing_client
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// bodySigner records whether it was given a body, and its content.
+type bodySigner struct {
+	nilBody bool
+	body    string
+}
+
+func (bs *bodySigner) Sign(r *http.Request, body io.ReadSeeker, service, region string, signTime time.Time) (http.Header, error) {
+	bs.nilBody, bs.body = body == nil, ""
+	if body != nil {
+		d, err := ioutil.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		bs.body = string(d)
+	}
+	r.Header.Set("Authorization", "AWS4-HMAC-SHA256 Signature=test")
+	return r.Header, nil
+}
+
+// TestWithEmptyBodies ensures that empty bodies are signed as an empty payload by default, and as no body with
+// EmptyBodyAsNil, while nil and non-empty bodies are signed the same way in both modes.
+func TestWithEmptyBodies(t *testing.T) {
+	for _, tc := range []struct {
+		mode    EmptyBodyMode
+		body    io.Reader
+		nilBody bool
+	}{
+		{EmptyBodyHashed, nil, true},
+		{EmptyBodyHashed, io.MultiReader(), false},
+		{EmptyBodyHashed, http.NoBody, false},
+		{EmptyBodyHashed, strings.NewReader("body"), false},
+		{EmptyBodyAsNil, nil, true},
+		{EmptyBodyAsNil, io.MultiReader(), true},
+		{EmptyBodyAsNil, http.NoBody, true},
+		{EmptyBodyAsNil, strings.NewReader("body"), false},
+	} {
+		var sent *http.Request
+		bs := &bodySigner{}
+		c, err := NewWithRequestSigner(bs, &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			sent = req
+			return response(200, "", nil), nil
+		})}, "es", "us-east-1", nil, WithEmptyBodies(tc.mode))
+		if err != nil {
+			t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+		}
+		req, _ := http.NewRequest(http.MethodPost, "https://example.com/", tc.body)
+		if _, err := c.Do(req); err != nil {
+			t.Fatalf("An unexpected error occurred: %s", err)
+		}
+		if bs.nilBody != tc.nilBody {
+			t.Errorf("Mode %d, body %T: expected a nil body to be signed to be %t, got %t", tc.mode, tc.body, tc.nilBody, bs.nilBody)
+		}
+		if tc.nilBody && sent.Body != nil {
+			t.Errorf("Mode %d, body %T: expected the request to be sent without a body", tc.mode, tc.body)
+		}
+		if sent.ContentLength != int64(len(bs.body)) {
+			t.Errorf("Mode %d, body %T: expected a Content-Length of %d, got %d", tc.mode, tc.body, len(bs.body), sent.ContentLength)
+		}
+	}
+}
+
+// TestClientSignEmptyBody ensures that Client.Sign follows WithEmptyBodies.
+func TestClientSignEmptyBody(t *testing.T) {
+	for _, mode := range []EmptyBodyMode{EmptyBodyHashed, EmptyBodyAsNil} {
+		bs := &bodySigner{}
+		c, err := NewClient(bs, &http.Client{}, "es", "us-east-1", nil, WithEmptyBodies(mode))
+		if err != nil {
+			t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+		}
+		req, _ := http.NewRequest(http.MethodPost, "https://example.com/", io.MultiReader())
+		if err := c.Sign(req); err != nil {
+			t.Fatalf("An unexpected error occurred while signing: %s", err)
+		}
+		if bs.nilBody != (mode == EmptyBodyAsNil) || (req.Body == nil) != bs.nilBody {
+			t.Errorf("Mode %d: unexpected body signed %t, request body %v", mode, bs.nilBody, req.Body)
+		}
+	}
+}
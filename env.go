@@ -0,0 +1,34 @@
+//go:build !nosdkv1
+
+package aws_signing_client
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// Environment variables read by NewFromEnvironment in addition to the standard AWS credential variables.
+const (
+	EnvRegion         = "AWS_REGION"
+	EnvDefaultRegion  = "AWS_DEFAULT_REGION"
+	EnvSigningService = "AWS_SIGNING_SERVICE"
+)
+
+// NewFromEnvironment obtains a new HTTP client configured entirely from the environment. The region is read from
+// AWS_REGION or, if unset, AWS_DEFAULT_REGION; the service from AWS_SIGNING_SERVICE; and the credentials from
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and the optional AWS_SESSION_TOKEN. A MissingCredentialsError,
+// MissingServiceError or MissingRegionError is returned when the corresponding variables are unset.
+func NewFromEnvironment(opts ...Option) (*http.Client, error) {
+	creds := credentials.NewEnvCredentials()
+	if _, err := creds.Get(); err != nil {
+		return nil, MissingCredentialsError{}
+	}
+	region := os.Getenv(EnvRegion)
+	if region == "" {
+		region = os.Getenv(EnvDefaultRegion)
+	}
+	return New(v4.NewSigner(creds), &http.Client{}, os.Getenv(EnvSigningService), region, nil, opts...)
+}
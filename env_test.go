@@ -0,0 +1,54 @@
+package aws_signing_client
+
+import (
+	"testing"
+)
+
+//   _____            _                                      _
+//  | ____|_ ____   _(_)_ __ ___  _ __  _ __ ___   ___ _ __ | |_
+//  |  _| | '_ \ \ / / | '__/ _ \| '_ \| '_ ` _ \ / _ \ '_ \| __|
+//  | |___| | | \ V /| | | | (_) | | | | | | | | |  __/ | | | |_
+//  |_____|_| |_|\_/ |_|_|  \___/|_| |_|_| |_| |_|\___|_| |_|\__|
+//
+
+func setEnvironment(t *testing.T, env map[string]string) {
+	for _, k := range []string{"AWS_ACCESS_KEY_ID", "AWS_ACCESS_KEY", "AWS_SECRET_ACCESS_KEY", "AWS_SECRET_KEY", "AWS_SESSION_TOKEN", EnvRegion, EnvDefaultRegion, EnvSigningService} {
+		t.Setenv(k, env[k])
+	}
+}
+
+// TestNewFromEnvironment tests the NewFromEnvironment() function with a complete environment.
+func TestNewFromEnvironment(t *testing.T) {
+	setEnvironment(t, map[string]string{
+		"AWS_ACCESS_KEY_ID":     "ID",
+		"AWS_SECRET_ACCESS_KEY": "SECRET",
+		EnvDefaultRegion:        "eu-west-1",
+		EnvSigningService:       "execute-api",
+	})
+	c, err := NewFromEnvironment()
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+	service, region := c.Transport.(*Signer).Scope()
+	if region != "eu-west-1" || service != "execute-api" {
+		t.Errorf("Unexpected scope: %s/%s", service, region)
+	}
+}
+
+// TestNewFromEnvironmentMissingValues tests the NewFromEnvironment() function with incomplete environments.
+func TestNewFromEnvironmentMissingValues(t *testing.T) {
+	setEnvironment(t, map[string]string{EnvRegion: "us-east-1", EnvSigningService: "es"})
+	if _, err := NewFromEnvironment(); err != (MissingCredentialsError{}) {
+		t.Error("Error was not of type MissingCredentialsError")
+	}
+
+	setEnvironment(t, map[string]string{"AWS_ACCESS_KEY_ID": "ID", "AWS_SECRET_ACCESS_KEY": "SECRET", EnvRegion: "us-east-1"})
+	if _, err := NewFromEnvironment(); err != (MissingServiceError{}) {
+		t.Error("Error was not of type MissingServiceError")
+	}
+
+	setEnvironment(t, map[string]string{"AWS_ACCESS_KEY_ID": "ID", "AWS_SECRET_ACCESS_KEY": "SECRET", EnvSigningService: "es"})
+	if _, err := NewFromEnvironment(); err != (MissingRegionError{}) {
+		t.Error("Error was not of type MissingRegionError")
+	}
+}
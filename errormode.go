@@ -0,0 +1,60 @@
+package aws_signing_client
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// ErrorMode selects how RoundTrip returns responses with a 4xx or 5xx status.
+type ErrorMode int
+
+const (
+	// ErrorModeResponse returns them as responses, as any http.RoundTripper does. It is the default.
+	ErrorModeResponse ErrorMode = iota
+	// ErrorModeError returns them as an *AWSError, parsed from at most MaxErrorBodySize bytes of the body, instead
+	// of a response. The body is drained, up to another MaxErrorBodySize bytes so that the connection can be reused,
+	// and closed. *http.Client wraps the error in a *url.Error; use errors.As to retrieve it. Retries made by
+	// WithRetries still see the responses.
+	ErrorModeError
+)
+
+type errorModeKey struct{}
+
+// WithErrorMode sets how the Signer returns responses with a 4xx or 5xx status. It can be overridden for a request
+// with WithRequestErrorMode.
+func WithErrorMode(mode ErrorMode) Option {
+	return func(s *Signer) {
+		s.errorMode = mode
+	}
+}
+
+// WithRequestErrorMode returns a copy of ctx that makes the Signer return 4xx and 5xx responses to requests sent
+// with it according to mode, regardless of the client's WithErrorMode.
+func WithRequestErrorMode(ctx context.Context, mode ErrorMode) context.Context {
+	return context.WithValue(ctx, errorModeKey{}, mode)
+}
+
+// requestErrorMode returns the ErrorMode of a request sent with ctx.
+func (s *Signer) requestErrorMode(ctx context.Context) ErrorMode {
+	if mode, ok := ctx.Value(errorModeKey{}).(ErrorMode); ok {
+		return mode
+	}
+	return s.errorMode
+}
+
+// responseAsError parses resp into an *AWSError as described by ErrorModeError, and drains and closes its body.
+func (s *Signer) responseAsError(ctx context.Context, resp *http.Response) *AWSError {
+	defer DrainAndClose(resp)
+	awsErr := ResponseError(resp)
+	if awsErr == nil {
+		d, err := ioutil.ReadAll(io.LimitReader(resp.Body, MaxErrorBodySize))
+		if err != nil {
+			s.logf(ctx, "Error while attempting to read error response body: '%s'", err)
+		}
+		awsErr = NewAWSError(resp, d)
+	}
+	s.logf(ctx, "Returning AWS error response as an error: %s", awsErr)
+	return awsErr
+}
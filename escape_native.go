@@ -0,0 +1,27 @@
+//go:build nosdkv1 || nosdkrest
+
+package aws_signing_client
+
+import (
+	"fmt"
+	"strings"
+)
+
+// escapePath percent-encodes every byte of path outside the RFC 3986 unreserved set, and '/' when encodeSep is
+// true. It mirrors rest.EscapePath from aws-sdk-go, which is not available without the v1 backend and is left out
+// with the nosdkrest build tag, as it is internal to the SDK.
+func escapePath(path string, encodeSep bool) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~',
+			c == '/' && !encodeSep:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
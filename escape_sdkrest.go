@@ -0,0 +1,11 @@
+//go:build !nosdkv1 && !nosdkrest
+
+package aws_signing_client
+
+import (
+	"github.com/aws/aws-sdk-go/private/protocol/rest"
+)
+
+func escapePath(path string, encodeSep bool) string {
+	return rest.EscapePath(path, encodeSep)
+}
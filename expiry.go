@@ -0,0 +1,52 @@
+package aws_signing_client
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// CredentialRefreshPolicy decides whether the credentials are refreshed before req is signed, given how long they
+// remain valid. It is set with WithCredentialRefreshPolicy.
+type CredentialRefreshPolicy func(req *http.Request, remaining time.Duration) bool
+
+// WithCredentialRefreshWindow refreshes expiring credentials before signing a request when less than window remains
+// before they expire, rather than when they have expired, so that a long upload does not start with a session token
+// that expires mid-transfer. It requires a RequestSigner that implements CredentialsRefresher, as both the aws-sdk-go
+// and the awsv2 backends do; credentials that do not expire are never refreshed.
+func WithCredentialRefreshWindow(window time.Duration) Option {
+	return WithCredentialRefreshPolicy(func(req *http.Request, remaining time.Duration) bool {
+		return remaining < window
+	})
+}
+
+// WithCredentialRefreshPolicy is like WithCredentialRefreshWindow, but lets policy decide for every request, e.g.
+// with a window that grows with the request's Content-Length.
+func WithCredentialRefreshPolicy(policy CredentialRefreshPolicy) Option {
+	return func(s *Signer) {
+		s.refreshPolicy = policy
+	}
+}
+
+// anticipateExpiry refreshes the credentials of sc before req is signed if the refresh policy asks for it. A failed
+// refresh is logged, and req is signed with the current credentials.
+func (s *Signer) anticipateExpiry(ctx context.Context, req *http.Request, sc *signingScope) {
+	cr, ok := sc.signer.(CredentialsRefresher)
+	if s.refreshPolicy == nil || !ok {
+		return
+	}
+	expires, ok := cr.CredentialsExpiry(ctx)
+	if !ok || !s.refreshPolicy(req, time.Until(expires)) {
+		return
+	}
+	// Concurrent requests wait for a single refresh rather than each starting one.
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+	if expires, ok = cr.CredentialsExpiry(ctx); !ok || !s.refreshPolicy(req, time.Until(expires)) {
+		return
+	}
+	s.logf(ctx, "Credentials expire in %d s. Refreshing before signing.", time.Until(expires)/time.Second)
+	if err := cr.RefreshCredentials(ctx); err != nil {
+		s.logf(ctx, "Error while attempting to refresh credentials: '%s'", err)
+	}
+}
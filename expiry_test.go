@@ -0,0 +1,70 @@
+package aws_signing_client
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// expiringProvider hands out numbered session credentials valid for lifetime.
+type expiringProvider struct {
+	mu        sync.Mutex
+	lifetime  time.Duration
+	retrieved int
+	expires   time.Time
+}
+
+func (p *expiringProvider) Retrieve() (credentials.Value, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.retrieved++
+	p.expires = time.Now().Add(p.lifetime)
+	return credentials.Value{AccessKeyID: fmt.Sprintf("ASIA%d", p.retrieved), SecretAccessKey: "SECRET", SessionToken: "TOKEN"}, nil
+}
+
+func (p *expiringProvider) IsExpired() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return time.Now().After(p.expires)
+}
+
+func (p *expiringProvider) ExpiresAt() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.expires
+}
+
+// TestWithCredentialRefreshWindow ensures that credentials are refreshed before signing when they are about to
+// expire, and only then.
+func TestWithCredentialRefreshWindow(t *testing.T) {
+	for _, lifetime := range []time.Duration{time.Hour, time.Minute} {
+		p := &expiringProvider{lifetime: lifetime}
+		var keys []string
+		c, err := New(v4.NewSigner(credentials.NewCredentials(p)), &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			keys = append(keys, accessKeyID(req.Header))
+			return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+		})}, "s3", "us-east-1", nil, WithCredentialRefreshWindow(5*time.Minute))
+		if err != nil {
+			t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+		}
+		for i := 0; i < 2; i++ {
+			if _, err := c.Get("https://example.com/"); err != nil {
+				t.Fatalf("An unexpected error occurred while making a request: %s", err)
+			}
+		}
+		want := "[ASIA1 ASIA1]"
+		if lifetime < 5*time.Minute {
+			want = "[ASIA2 ASIA3]"
+		}
+		if got := fmt.Sprint(keys); got != want {
+			t.Errorf("Lifetime %s: expected requests signed with %s, got %s", lifetime, want, got)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package aws_signing_client
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+type (
+	// Client is a signing HTTP client whose methods do not expose the types of the AWS SDK it signs with, so that
+	// code written against it keeps compiling when the signing backend changes, e.g. from aws-sdk-go to
+	// aws-sdk-go-v2. Create one with NewClient, or wrap an *http.Client returned by New with WrapClient.
+	Client struct {
+		http   *http.Client
+		signer *Signer
+		sub    *scopedTransport // set for Clients derived with WithScope or WithPathPrefix
+	}
+
+	// ClientOptions describes what the requests of a Client are signed for.
+	ClientOptions struct {
+		// Service and Region identify the endpoint, as passed to NewClient or SetScope.
+		Service string
+		Region  string
+		// SigningName and SigningRegion are the service and region in the signature's credential scope. They
+		// differ from Service and Region when WithSigningName or WithSigningRegion is used, or for global services.
+		SigningName   string
+		SigningRegion string
+	}
+
+	// RequestPresigner is implemented by RequestSigners that can presign requests, moving the signature into the
+	// query string so that the URL can be used without credentials until exp has passed. Both the aws-sdk-go and
+	// the awsv2 backends implement it. It is used by Client.Presign.
+	RequestPresigner interface {
+		Presign(r *http.Request, body io.ReadSeeker, service, region string, exp time.Duration, signTime time.Time) (http.Header, error)
+	}
+
+	// UnsupportedPresignError is an implementation of the error interface that is returned by Client.Presign when
+	// the RequestSigner does not implement RequestPresigner.
+	UnsupportedPresignError struct {
+		// Signer is the type of the RequestSigner.
+		Signer string
+	}
+)
+
+// NewClient obtains a Client that signs requests for the provided service and region with rs, as NewWithRequestSigner
+// does.
+func NewClient(rs RequestSigner, client *http.Client, service string, region string, cl ContextLogger, opts ...Option) (*Client, error) {
+	c, err := NewWithRequestSigner(rs, client, service, region, cl, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return WrapClient(c)
+}
+
+// WrapClient returns a Client for an *http.Client created by New, NewWithRequestSigner or awsv2.New. It returns a
+// MissingSignerError if the transport of c is not a *Signer.
+func WrapClient(c *http.Client) (*Client, error) {
+	s, ok := c.Transport.(*Signer)
+	if !ok {
+		return nil, MissingSignerError{}
+	}
+	return &Client{http: c, signer: s}, nil
+}
+
+// Do signs and sends req, as (*http.Client).Do does.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	return c.http.Do(req)
+}
+
+// Sign signs req in place for the current scope without sending it, as the Client would before sending it: the
+// HeaderInjectors run and the path is encoded first. The body of req is read within the limit set with
+// WithMaxBufferedBody and replaced, so that it can still be sent.
+func (c *Client) Sign(req *http.Request) error {
+	s := c.signer
+	sc := c.scopeFor()
+	if c.sub != nil {
+		c.sub.addPrefix(req)
+	}
+	name, region := s.prepare(req.Context(), req, sc)
+	body, err := s.bufferBody(req)
+	if err != nil {
+		return err
+	}
+	restore := s.beforeSign(req.Context(), req)
+	defer restore()
+	_, err = sc.signer.Sign(req, body, name, region, time.Now())
+	return err
+}
+
+// Presign signs req for the current scope with the signature in the query string, and returns the presigned URL
+// and the headers that must be sent with it. The URL can be used by anyone until expires has passed, without
+// credentials. It returns an *UnsupportedPresignError if the RequestSigner does not implement RequestPresigner.
+func (c *Client) Presign(req *http.Request, expires time.Duration) (string, http.Header, error) {
+	s := c.signer
+	sc := c.scopeFor()
+	ps, ok := sc.signer.(RequestPresigner)
+	if !ok {
+		return "", nil, &UnsupportedPresignError{Signer: fmt.Sprintf("%T", sc.signer)}
+	}
+	if c.sub != nil {
+		c.sub.addPrefix(req)
+	}
+	name, region := s.prepare(req.Context(), req, sc)
+	body, err := s.bufferBody(req)
+	if err != nil {
+		return "", nil, err
+	}
+	restore := s.beforeSign(req.Context(), req)
+	h, err := ps.Presign(req, body, name, region, expires, time.Now())
+	// The signature is carried to the URL the request is sent to.
+	query := req.URL.RawQuery
+	restore()
+	if err != nil {
+		return "", nil, err
+	}
+	req.URL.RawQuery = query
+	return req.URL.String(), h, nil
+}
+
+// Options returns what requests are currently signed for.
+func (c *Client) Options() ClientOptions {
+	sc := c.scopeFor()
+	name, region := c.signer.signingValues(sc)
+	return ClientOptions{Service: sc.service, Region: sc.region, SigningName: name, SigningRegion: region}
+}
+
+// SetScope changes the service and region that subsequent requests are signed for, as Signer.SetScope does. On a
+// Client derived with WithScope or WithPathPrefix, only the scope of that Client changes.
+func (c *Client) SetScope(service, region string) error {
+	if c.sub == nil {
+		return c.signer.SetScope(service, region)
+	}
+	switch {
+	case service == "":
+		return MissingServiceError{}
+	case region == "":
+		return MissingRegionError{}
+	}
+	c.sub.scope.Store(scopeValues{service: service, region: region})
+	return nil
+}
+
+// HTTPClient returns the underlying *http.Client, for libraries that require one.
+func (c *Client) HTTPClient() *http.Client {
+	return c.http
+}
+
+// Close releases the resources held by the Client, as Signer.Close does. Clients derived from the same Client share
+// these resources, so closing any of them closes them all.
+func (c *Client) Close() error {
+	return c.signer.Close()
+}
+
+// bufferBody reads the body of req as the transport does, within the limit set with WithMaxBufferedBody, and
+// replaces it with a copy, returning a reader for signing. It returns nil if req has no body or is to be sent with an
+// unsigned payload.
+func (s *Signer) bufferBody(req *http.Request) (io.ReadSeeker, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	p, over, err := s.readBody(req.Context(), req)
+	if err != nil || over {
+		return nil, err
+	}
+	if p.file != nil {
+		req.Body, req.ContentLength = spooledBody{Reader: p.reader(), p: p}, p.len()
+		return p.reader(), nil
+	}
+	if s.dropEmptyBody(req, p.bytes()) {
+		return nil, nil
+	}
+	req.Body.Close()
+	req.Body = p.body()
+	return p.reader(), nil
+}
+
+// spooledBody is the body of a request signed with Sign whose payload was spooled to disk. It removes the file once
+// the request has been sent.
+type spooledBody struct {
+	io.Reader
+	p *payload
+}
+
+// Close implements the io.Closer interface.
+func (b spooledBody) Close() error {
+	b.p.close()
+	return nil
+}
+
+// Error implements the error interface.
+func (err *UnsupportedPresignError) Error() string {
+	return fmt.Sprintf("The %s RequestSigner cannot presign requests.", err.Signer)
+}
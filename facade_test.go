@@ -0,0 +1,162 @@
+package aws_signing_client
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// TestClient ensures that a Client signs, presigns and sends requests for its scope.
+func TestClient(t *testing.T) {
+	var sent *http.Request
+	c, err := New(v4.NewSigner(creds), &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent = req
+		return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+	})}, "iam", "eu-west-1", nil)
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+	fc, err := WrapClient(c)
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while wrapping the client: %s", err)
+	}
+	if o := fc.Options(); o != (ClientOptions{Service: "iam", Region: "eu-west-1", SigningName: "iam", SigningRegion: "us-east-1"}) {
+		t.Errorf("Unexpected options: %+v", o)
+	}
+
+	req, _ := http.NewRequest("POST", "https://iam.amazonaws.com/", strings.NewReader("Action=ListUsers"))
+	if err := fc.Sign(req); err != nil {
+		t.Fatalf("An unexpected error occurred while signing: %s", err)
+	}
+	if !strings.Contains(req.Header.Get("Authorization"), "/us-east-1/iam/aws4_request") {
+		t.Errorf("Request was not signed for the scope: %s", req.Header.Get("Authorization"))
+	}
+	if b, _ := ioutil.ReadAll(req.Body); string(b) != "Action=ListUsers" {
+		t.Errorf("Body was not replaced: %q", b)
+	}
+
+	req, _ = http.NewRequest("GET", "https://iam.amazonaws.com/?Action=ListUsers", nil)
+	u, _, err := fc.Presign(req, time.Minute)
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while presigning: %s", err)
+	}
+	if !strings.Contains(u, "X-Amz-Signature=") || !strings.Contains(u, "Action=ListUsers") {
+		t.Errorf("URL was not presigned: %s", u)
+	}
+
+	req, _ = http.NewRequest("GET", "https://iam.amazonaws.com/", nil)
+	if _, err := fc.Do(req); err != nil || sent.Header.Get("Authorization") == "" {
+		t.Errorf("Request was not signed and sent: %v", err)
+	}
+
+	if _, err := WrapClient(&http.Client{}); err != (MissingSignerError{}) {
+		t.Errorf("Expected MissingSignerError, got %v", err)
+	}
+}
+
+// TestClientPresignUnsupported ensures that Presign reports RequestSigners that cannot presign.
+func TestClientPresignUnsupported(t *testing.T) {
+	fc, err := NewClient(panickingSigner{}, &http.Client{}, "es", "us-east-1", nil)
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+	req, _ := http.NewRequest("GET", "https://example.com/", nil)
+	_, _, err = fc.Presign(req, time.Minute)
+	var upe *UnsupportedPresignError
+	if !errors.As(err, &upe) {
+		t.Errorf("Expected an *UnsupportedPresignError, got %v", err)
+	}
+}
+
+// TestClientSignAPIGatewayMapping ensures that Sign and Presign sign over the mapped host and path, as the transport
+// does.
+func TestClientSignAPIGatewayMapping(t *testing.T) {
+	var sent *http.Request
+	c, err := New(v4.NewSigner(creds), &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent = req
+		return response(200, "", nil), nil
+	})}, "execute-api", "us-east-1", nil, WithAPIGatewayMapping(APIGatewayMapping{
+		Host:        "api.example.com",
+		BasePath:    "orders",
+		SigningHost: "abc123.execute-api.us-east-1.amazonaws.com",
+		Stage:       "prod",
+	}))
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+	fc, _ := WrapClient(c)
+	mapped := func(signed *http.Request) string {
+		want, _ := http.NewRequest("GET", "https://abc123.execute-api.us-east-1.amazonaws.com/prod/42", nil)
+		want.Header = signed.Header.Clone()
+		want.Header.Del("Authorization")
+		signTime, _ := time.Parse("20060102T150405Z", signed.Header.Get("X-Amz-Date"))
+		v4.NewSigner(creds).Sign(want, nil, "execute-api", "us-east-1", signTime)
+		return want.Header.Get("Authorization")
+	}
+
+	req, _ := http.NewRequest("GET", "https://api.example.com/orders/42", nil)
+	if _, err := fc.Do(req); err != nil {
+		t.Fatalf("An unexpected error occurred while sending: %s", err)
+	}
+	if got := sent.Header.Get("Authorization"); got != mapped(sent) {
+		t.Errorf("The transport did not sign over the mapped path: %s", got)
+	}
+
+	req, _ = http.NewRequest("GET", "https://api.example.com/orders/42", nil)
+	if err := fc.Sign(req); err != nil {
+		t.Fatalf("An unexpected error occurred while signing: %s", err)
+	}
+	if got := req.Header.Get("Authorization"); got != mapped(req) || req.URL.String() != "https://api.example.com/orders/42" {
+		t.Errorf("Sign did not sign over the mapped path: %s (%s)", got, req.URL)
+	}
+
+	req, _ = http.NewRequest("GET", "https://api.example.com/orders/42", nil)
+	u, _, err := fc.Presign(req, time.Minute)
+	if err != nil || !strings.HasPrefix(u, "https://api.example.com/orders/42?") || !strings.Contains(u, "X-Amz-Signature=") {
+		t.Errorf("Expected a presigned URL of the custom domain, got %s (%v)", u, err)
+	}
+}
+
+// TestClientSignBodyLimit ensures that Sign reads bodies within the limit set with WithMaxBufferedBody, as the
+// transport does.
+func TestClientSignBodyLimit(t *testing.T) {
+	sign := func(opts ...Option) (*http.Request, error) {
+		c, err := New(v4.NewSigner(creds), &http.Client{}, "s3", "us-east-1", nil, append(opts, WithMaxBufferedBody(8))...)
+		if err != nil {
+			t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+		}
+		fc, _ := WrapClient(c)
+		req, _ := http.NewRequest("PUT", "https://example.com/", ioutil.NopCloser(strings.NewReader("0123456789")))
+		return req, fc.Sign(req)
+	}
+
+	var btl *BodyTooLargeError
+	if _, err := sign(); !errors.As(err, &btl) || btl.Limit != 8 {
+		t.Errorf("Expected a *BodyTooLargeError, got %v", err)
+	}
+
+	req, err := sign(WithUnsignedPayloadFallback())
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while signing: %s", err)
+	}
+	if d, _ := ioutil.ReadAll(req.Body); string(d) != "0123456789" || req.Header.Get("X-Amz-Content-Sha256") != UnsignedPayload {
+		t.Errorf("Expected the body to be left to stream with an unsigned payload, got %q", d)
+	}
+
+	dir := t.TempDir()
+	if req, err = sign(WithBodySpooling(dir)); err != nil {
+		t.Fatalf("An unexpected error occurred while signing: %s", err)
+	}
+	if d, _ := ioutil.ReadAll(req.Body); string(d) != "0123456789" || req.ContentLength != 10 {
+		t.Errorf("Expected the spooled body to be sent, got %q", d)
+	}
+	req.Body.Close()
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Errorf("Expected the spooled body to be removed once closed, found %d files", len(files))
+	}
+}
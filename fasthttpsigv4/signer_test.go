@@ -0,0 +1,100 @@
+package fasthttpsigv4
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+//    __           _   _     _   _
+//   / _| __ _ ___| |_| |__ | |_| |_ _ __
+//  | |_ / _` / __| __| '_ \| __| __| '_ \
+//  |  _| (_| \__ \ |_| | | | |_| |_| |_) |
+//  |_|  \__,_|___/\__|_| |_|\__|\__| .__/
+//                                  |_|
+
+var creds = credentials.NewStaticCredentials("ID", "SECRET", "TOKEN")
+
+// TestSignMatchesNetHTTP ensures that a fasthttp request is signed exactly like the equivalent net/http request.
+func TestSignMatchesNetHTTP(t *testing.T) {
+	s, err := NewSigner(v4.NewSigner(creds), "es", "us-east-1")
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a signer: %s", err)
+	}
+	at := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	req.SetRequestURI("https://my-domain.us-east-1.es.amazonaws.com/logs-2020/_search?q=level:error&size=10")
+	req.Header.SetMethod("POST")
+	req.Header.SetContentType("application/json")
+	req.Header.Set("X-Tenant-Id", "acme")
+	req.SetBodyString(`{"query":{}}`)
+	if err := s.signAt(req, at); err != nil {
+		t.Fatalf("An unexpected error occurred while signing: %s", err)
+	}
+
+	hr, _ := http.NewRequest("POST", "https://my-domain.us-east-1.es.amazonaws.com/logs-2020/_search?q=level:error&size=10", nil)
+	hr.Header.Set("Content-Type", "application/json")
+	hr.Header.Set("X-Tenant-Id", "acme")
+	hr.Header.Set("Date", at.Format(time.RFC3339))
+	if _, err := v4.NewSigner(creds).Sign(hr, bytes.NewReader([]byte(`{"query":{}}`)), "es", "us-east-1", at); err != nil {
+		t.Fatalf("An unexpected error occurred while signing: %s", err)
+	}
+
+	for _, k := range []string{"Authorization", "X-Amz-Date", "X-Amz-Security-Token"} {
+		if got, want := string(req.Header.Peek(k)), hr.Header.Get(k); got != want {
+			t.Errorf("%s differs:\n got %s\nwant %s", k, got, want)
+		}
+	}
+}
+
+// TestDo ensures that requests sent with Do reach the server signed, and that signing twice replaces the signature.
+func TestDo(t *testing.T) {
+	ln := fasthttputil.NewInmemoryListener()
+	defer ln.Close()
+	var auth []string
+	go fasthttp.Serve(ln, func(ctx *fasthttp.RequestCtx) {
+		auth = append(auth, string(ctx.Request.Header.Peek("Authorization")))
+	})
+	client := &fasthttp.Client{Dial: func(addr string) (net.Conn, error) { return ln.Dial() }}
+
+	s, err := NewSigner(v4.NewSigner(creds), "execute-api", "us-east-1")
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a signer: %s", err)
+	}
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+	req.SetRequestURI("http://api.example.com/prod/items")
+	for i := 0; i < 2; i++ {
+		if err := s.Do(client, req, resp); err != nil {
+			t.Fatalf("An unexpected error occurred while sending: %s", err)
+		}
+	}
+	if len(auth) != 2 || !strings.HasPrefix(auth[1], "AWS4-HMAC-SHA256 Credential=ID/") || strings.Count(auth[1], "Credential=") != 1 {
+		t.Errorf("Unexpected Authorization headers: %q", auth)
+	}
+}
+
+// TestNewSignerErrors ensures that NewSigner requires a signer, service and region.
+func TestNewSignerErrors(t *testing.T) {
+	if _, err := NewSigner(nil, "es", "us-east-1"); err == nil {
+		t.Error("Expected an error for a nil signer")
+	}
+	if _, err := NewSigner(v4.NewSigner(creds), "", "us-east-1"); err == nil {
+		t.Error("Expected an error for a missing service")
+	}
+	if _, err := NewSigner(v4.NewSigner(creds), "es", ""); err == nil {
+		t.Error("Expected an error for a missing region")
+	}
+}
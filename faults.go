@@ -0,0 +1,126 @@
+package aws_signing_client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+type (
+	// Fault is a failure injected by WithFaults into a fraction of the attempts sent to the wrapped transport, to
+	// test retry and failover configuration without real failures. The constructors LatencyFault, ResetFault,
+	// ErrorFault and ExpiredCredentialsFault return the common ones.
+	Fault struct {
+		// Rate is the probability, from 0 to 1, that the fault is injected into an attempt.
+		Rate float64
+		// Latency delays the attempt before it is sent, or before an injected failure is returned.
+		Latency time.Duration
+		// Reset fails the attempt with a connection reset instead of sending it.
+		Reset bool
+		// StatusCode, if set, answers the attempt with an AWS error response with that status and the error Code
+		// and Message, instead of sending it.
+		StatusCode int
+		Code       string
+		Message    string
+	}
+
+	// faultTransport injects faults into the attempts it sends to the wrapped transport.
+	faultTransport struct {
+		rt     http.RoundTripper
+		faults []Fault
+		logger func(ctx context.Context, format string, v ...interface{})
+	}
+)
+
+// LatencyFault delays a fraction rate of the attempts by d.
+func LatencyFault(rate float64, d time.Duration) Fault {
+	return Fault{Rate: rate, Latency: d}
+}
+
+// ResetFault fails a fraction rate of the attempts with a connection reset, as a network error would.
+func ResetFault(rate float64) Fault {
+	return Fault{Rate: rate, Reset: true}
+}
+
+// ErrorFault answers a fraction rate of the attempts with an AWS error response, e.g.
+// ErrorFault(0.1, 400, "ThrottlingException").
+func ErrorFault(rate float64, status int, code string) Fault {
+	return Fault{Rate: rate, StatusCode: status, Code: code, Message: "Injected fault."}
+}
+
+// ExpiredCredentialsFault answers a fraction rate of the attempts as AWS answers a request signed with an expired
+// session token.
+func ExpiredCredentialsFault(rate float64) Fault {
+	return Fault{Rate: rate, StatusCode: http.StatusForbidden, Code: "ExpiredTokenException", Message: "The security token included in the request is expired"}
+}
+
+// WithFaults injects faults into the signed attempts the Signer sends, after they are signed and in place of, or
+// before, the wrapped transport, so that retries, WithRegions failover, Hooks and error parsing see them as real
+// failures. Each fault is drawn independently for every attempt; latencies add up, and the first drawn fault that
+// fails the attempt decides how. Injected faults are logged. It is meant for tests and staging environments.
+func WithFaults(faults ...Fault) Option {
+	return func(s *Signer) {
+		s.faults = append(s.faults, faults...)
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (ft *faultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	var latency time.Duration
+	var fail *Fault
+	for i, f := range ft.faults {
+		if rand.Float64() >= f.Rate {
+			continue
+		}
+		latency += f.Latency
+		if fail == nil && (f.Reset || f.StatusCode != 0) {
+			fail = &ft.faults[i]
+		}
+	}
+	if latency > 0 {
+		ft.logger(ctx, "Injecting %d ms of latency.", latency/time.Millisecond)
+		t := time.NewTimer(latency)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+	}
+
+	if fail == nil {
+		return ft.rt.RoundTrip(req)
+	}
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	if fail.Reset {
+		ft.logger(ctx, "Injecting a connection reset.")
+		return nil, &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+	}
+	ft.logger(ctx, "Injecting a %d %s response.", fail.StatusCode, fail.Code)
+	d, _ := json.Marshal(map[string]string{"__type": fail.Code, "message": fail.Message})
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", fail.StatusCode, http.StatusText(fail.StatusCode)),
+		StatusCode: fail.StatusCode,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header: http.Header{
+			"Content-Type":     {"application/x-amz-json-1.1"},
+			"X-Amzn-Errortype": {fail.Code},
+			"X-Amzn-Requestid": {"injected-fault"},
+		},
+		Body:          ioutil.NopCloser(bytes.NewReader(d)),
+		ContentLength: int64(len(d)),
+		Request:       req,
+	}, nil
+}
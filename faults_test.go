@@ -0,0 +1,57 @@
+package aws_signing_client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// TestWithFaults ensures that injected faults are seen by retries and error parsing as real failures.
+func TestWithFaults(t *testing.T) {
+	sent := 0
+	transport := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent++
+		return response(200, "", nil), nil
+	})}
+
+	c, _ := New(v4.NewSigner(creds), transport, "es", "us-east-1", nil, WithFaults(ResetFault(1)))
+	if _, err := c.Get("https://example.com/"); !errors.Is(err, syscall.ECONNRESET) || sent != 0 {
+		t.Errorf("Expected a connection reset without sending, got %v after %d requests", err, sent)
+	}
+
+	rh := &recordingHooks{}
+	c, _ = New(v4.NewSigner(creds), transport, "es", "us-east-1", nil, WithFaults(ExpiredCredentialsFault(1)), WithErrorParsing(), WithRetries(3), fastRetries, WithHooks(rh))
+	resp, err := c.Get("https://example.com/")
+	if err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err)
+	}
+	if awsErr := ResponseError(resp); resp.StatusCode != 403 || awsErr == nil || awsErr.Code != "ExpiredTokenException" {
+		t.Errorf("Expected an expired token response, got %d and %v", resp.StatusCode, awsErr)
+	}
+	if len(rh.requests) != 1 || rh.requests[0].StatusCode != 403 {
+		t.Errorf("Expected the fault to be reported to the hooks and not retried, got %+v", rh.requests)
+	}
+
+	rh = &recordingHooks{}
+	c, _ = New(v4.NewSigner(creds), transport, "es", "us-east-1", nil, WithFaults(ErrorFault(1, 503, "ServiceUnavailable"), LatencyFault(1, 10*time.Millisecond)), WithRetries(2), fastRetries, WithHooks(rh))
+	start := time.Now()
+	if resp, err := c.Get("https://example.com/"); err != nil || resp.StatusCode != 503 || len(rh.requests) != 2 {
+		t.Errorf("Expected the injected 503 to be retried, got %v, %v after %d attempts", resp, err, len(rh.requests))
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("Expected the latency to be injected into every attempt, took %s", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	c, _ = New(v4.NewSigner(creds), transport, "es", "us-east-1", nil, WithFaults(LatencyFault(1, time.Hour)))
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://example.com/", nil)
+	if _, err := c.Do(req); !errors.Is(err, context.DeadlineExceeded) || sent != 0 {
+		t.Errorf("Expected the injected latency to end with the context, got %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+//go:build !nosdkv1
+
+package aws_signing_client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// FileCredentialsProviderName is the ProviderName of credentials retrieved by a FileCredentialsProvider.
+const FileCredentialsProviderName = "FileCredentialsProvider"
+
+// DefaultCredentialsPollInterval is how often a FileCredentialsProvider checks its file for changes when no
+// PollInterval is set.
+const DefaultCredentialsPollInterval = 10 * time.Second
+
+// FileCredentialsProvider is a credentials.Provider that reads credentials from a JSON file, such as one written by
+// a secret manager agent, and picks up rotated keys when the file changes. The file uses the credential_process
+// output format:
+//
+//	{"Version": 1, "AccessKeyId": "...", "SecretAccessKey": "...", "SessionToken": "...", "Expiration": "..."}
+//
+// SessionToken and Expiration are optional. The file is checked for changes at most once per PollInterval, without
+// any background goroutine.
+type FileCredentialsProvider struct {
+	// Filename is the path of the credentials file.
+	Filename string
+	// PollInterval is the minimum time between checks of the file's modification time. If zero,
+	// DefaultCredentialsPollInterval is used.
+	PollInterval time.Duration
+
+	mu         sync.Mutex
+	modTime    time.Time
+	lastCheck  time.Time
+	expiration time.Time
+	retrieved  bool
+}
+
+type fileCredentials struct {
+	Version         int
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string
+	SessionToken    string
+	Expiration      *time.Time
+}
+
+// NewFileCredentials returns a *credentials.Credentials that reads from filename using a FileCredentialsProvider.
+func NewFileCredentials(filename string) *credentials.Credentials {
+	return credentials.NewCredentials(&FileCredentialsProvider{Filename: filename})
+}
+
+// Retrieve reads the credentials file. It implements credentials.Provider.
+func (p *FileCredentialsProvider) Retrieve() (credentials.Value, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	v := credentials.Value{ProviderName: FileCredentialsProviderName}
+	fi, err := os.Stat(p.Filename)
+	if err != nil {
+		return v, err
+	}
+	d, err := ioutil.ReadFile(p.Filename)
+	if err != nil {
+		return v, err
+	}
+	var fc fileCredentials
+	if err := json.Unmarshal(d, &fc); err != nil {
+		return v, fmt.Errorf("parsing credentials file '%s': %w", p.Filename, err)
+	}
+	if fc.AccessKeyID == "" || fc.SecretAccessKey == "" {
+		return v, fmt.Errorf("credentials file '%s' has no AccessKeyId or SecretAccessKey", p.Filename)
+	}
+
+	p.modTime = fi.ModTime()
+	p.lastCheck = time.Now()
+	p.expiration = time.Time{}
+	if fc.Expiration != nil {
+		p.expiration = *fc.Expiration
+	}
+	p.retrieved = true
+
+	v.AccessKeyID = fc.AccessKeyID
+	v.SecretAccessKey = fc.SecretAccessKey
+	v.SessionToken = fc.SessionToken
+	return v, nil
+}
+
+// IsExpired reports whether the credentials have passed their expiration or the file has been modified since it
+// was last read. It implements credentials.Provider.
+func (p *FileCredentialsProvider) IsExpired() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	switch {
+	case !p.retrieved:
+		return true
+	case !p.expiration.IsZero() && !now.Before(p.expiration):
+		return true
+	}
+
+	interval := p.PollInterval
+	if interval == 0 {
+		interval = DefaultCredentialsPollInterval
+	}
+	if now.Sub(p.lastCheck) < interval {
+		return false
+	}
+	p.lastCheck = now
+	fi, err := os.Stat(p.Filename)
+	if err != nil {
+		// Keep using the last good credentials while the file is being replaced.
+		return false
+	}
+	return !fi.ModTime().Equal(p.modTime)
+}
@@ -0,0 +1,75 @@
+package aws_signing_client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+//   _____ _ _       ____              _
+//  |  ___(_) | ___ / ___|_ __ ___  __| |___
+//  | |_  | | |/ _ \ |   | '__/ _ \/ _` / __|
+//  |  _| | | |  __/ |___| | |  __/ (_| \__ \
+//  |_|   |_|_|\___|\____|_|  \___|\__,_|___/
+//
+
+func writeCredentials(t *testing.T, path, id string, mtime time.Time) {
+	d := `{"Version": 1, "AccessKeyId": "` + id + `", "SecretAccessKey": "SECRET", "SessionToken": "TOKEN"}`
+	if err := ioutil.WriteFile(path, []byte(d), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestFileCredentialsProviderRotation ensures that a modified credentials file is picked up.
+func TestFileCredentialsProviderRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	writeCredentials(t, path, "OLDID", time.Now().Add(-time.Hour))
+	c := credentials.NewCredentials(&FileCredentialsProvider{Filename: path, PollInterval: time.Nanosecond})
+
+	v, err := c.Get()
+	if err != nil || v.AccessKeyID != "OLDID" || v.SessionToken != "TOKEN" {
+		t.Fatalf("Unexpected credentials: %+v, %v", v, err)
+	}
+	writeCredentials(t, path, "NEWID", time.Now())
+	if v, _ = c.Get(); v.AccessKeyID != "NEWID" {
+		t.Errorf("Rotated credentials were not picked up: %s", v.AccessKeyID)
+	}
+}
+
+// TestFileCredentialsProviderInvalid ensures that an incomplete credentials file is rejected.
+func TestFileCredentialsProviderInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	ioutil.WriteFile(path, []byte(`{"Version": 1, "AccessKeyId": "ID"}`), 0600)
+	if _, err := NewFileCredentials(path).Get(); err == nil {
+		t.Error("No error was returned for a credentials file without a secret")
+	}
+}
+
+// TestSetCredentials ensures that requests are signed with the new credentials after SetCredentials().
+func TestSetCredentials(t *testing.T) {
+	c, s, sent := scopeClient(t)
+	if err := s.SetCredentials(&credentials.StaticProvider{Value: credentials.Value{AccessKeyID: "NEWID", SecretAccessKey: "SECRET"}}); err != nil {
+		t.Fatalf("An unexpected error occurred while setting credentials: %s", err)
+	}
+	if _, err := c.Get("https://example.com/"); err != nil {
+		t.Fatalf("An unexpected error occurred while making a request: %s", err)
+	}
+	h := sent().Header
+	switch {
+	case !strings.Contains(h.Get("Authorization"), "Credential=NEWID/"):
+		t.Errorf("Request was not signed with the new credentials: %s", h.Get("Authorization"))
+	case h.Get("X-Amz-Security-Token") != "":
+		t.Error("The previous session token was sent with the new credentials")
+	}
+	if err := s.SetCredentials(nil); err != (MissingCredentialsError{}) {
+		t.Error("Error was not of type MissingCredentialsError")
+	}
+}
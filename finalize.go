@@ -0,0 +1,74 @@
+package aws_signing_client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+type (
+	// RequestFinalizer changes the headers h of a signed request right before it is sent. Only headers excluded
+	// from signing may be added, changed or removed.
+	RequestFinalizer func(ctx context.Context, h http.Header)
+
+	// FinalizerError is an implementation of the error interface that is returned by RoundTrip when a
+	// RequestFinalizer changes a header that is not excluded from signing.
+	FinalizerError struct {
+		Header string
+	}
+)
+
+// WithRequestFinalizer registers a RequestFinalizer that runs on every attempt after it is signed and before it is
+// sent, e.g. for infrastructure to stamp trace or routing metadata without invalidating the signature. It is given
+// a copy of the headers; the changes are applied to the attempt sent, not to the caller's request, so that they are
+// never signed by a later attempt. Finalizers may only add, change or remove the headers excluded from signing: those
+// set with WithUnsignedHeaders and those the signers never sign, User-Agent and X-Amzn-Trace-Id. If a finalizer
+// touches any other header, whether or not the signature covers it, the request is not sent and a *FinalizerError is
+// returned. Finalizers run in the order they were registered.
+func WithRequestFinalizer(finalize RequestFinalizer) Option {
+	return func(s *Signer) {
+		s.finalizers = append(s.finalizers, finalize)
+	}
+}
+
+// finalize runs the finalizers on a copy of the headers of the signed request req, and returns a shallow copy of
+// req with the finalized headers.
+func (s *Signer) finalize(ctx context.Context, req *http.Request) (*http.Request, error) {
+	h := req.Header.Clone()
+	for _, finalize := range s.finalizers {
+		finalize(ctx, h)
+	}
+
+	unsigned := map[string]bool{"User-Agent": true, "X-Amzn-Trace-Id": true}
+	for _, name := range s.unsignedHeaders {
+		unsigned[name] = true
+	}
+	changed := func(name string) bool {
+		a, b := h[name], req.Header[name]
+		if len(a) != len(b) {
+			return true
+		}
+		for i := range a {
+			if a[i] != b[i] {
+				return true
+			}
+		}
+		return false
+	}
+	for _, hdr := range []http.Header{h, req.Header} {
+		for name := range hdr {
+			if !unsigned[http.CanonicalHeaderKey(name)] && changed(name) {
+				return nil, &FinalizerError{Header: http.CanonicalHeaderKey(name)}
+			}
+		}
+	}
+
+	final := req.WithContext(ctx)
+	final.Header = h
+	return final, nil
+}
+
+// Error implements the error interface.
+func (err *FinalizerError) Error() string {
+	return fmt.Sprintf("A request finalizer changed the header '%s', which is not excluded from signing. Refusing to send request.", err.Header)
+}
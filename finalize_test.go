@@ -0,0 +1,64 @@
+package aws_signing_client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// TestWithRequestFinalizer ensures that finalizers may change unsigned headers of every attempt without them being
+// signed, and that changing a signed header prevents the request from being sent.
+func TestWithRequestFinalizer(t *testing.T) {
+	var sent []*http.Request
+	transport := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent = append(sent, req)
+		return response(503, "", nil), nil
+	})}
+	attempt := 0
+	c, err := New(v4.NewSigner(creds), transport, "es", "us-east-1", nil, WithUnsignedHeaders("X-Route"), WithRetries(2), fastRetries,
+		WithRequestFinalizer(func(ctx context.Context, h http.Header) {
+			attempt++
+			h.Set("X-Amzn-Trace-Id", "Root=1-abc")
+			h.Set("X-Route", strings.Repeat("b", attempt))
+		}))
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com/", nil)
+	req.Header.Set("X-Route", "a")
+	if _, err := c.Do(req); err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err)
+	}
+	if len(sent) != 2 {
+		t.Fatalf("Expected 2 attempts, got %d", len(sent))
+	}
+	for i, r := range sent {
+		switch {
+		case r.Header.Get("X-Amzn-Trace-Id") != "Root=1-abc" || r.Header.Get("X-Route") != strings.Repeat("b", i+1):
+			t.Errorf("Attempt %d: the finalized headers were not sent: %v", i+1, r.Header)
+		case strings.Contains(r.Header.Get("Authorization"), "x-amzn-trace-id") || strings.Contains(r.Header.Get("Authorization"), "x-route"):
+			t.Errorf("Attempt %d: the finalized headers were signed: %s", i+1, r.Header.Get("Authorization"))
+		}
+	}
+	if req.Header.Get("X-Route") != "a" || req.Header.Get("X-Amzn-Trace-Id") != "" {
+		t.Errorf("The caller's request was changed: %v", req.Header)
+	}
+
+	// Signed headers, and headers that would be sent unsigned although they are not excluded from signing, are
+	// refused.
+	for header, value := range map[string]string{"X-Amz-Date": "20240101T000000Z", "X-Amz-Target": "DynamoDB_20120810.Scan", "Content-Type": "text/plain"} {
+		sent = nil
+		c, _ = New(v4.NewSigner(creds), transport, "es", "us-east-1", nil, WithRequestFinalizer(func(ctx context.Context, h http.Header) {
+			h.Set(header, value)
+		}))
+		_, err = c.Get("https://example.com/")
+		var ferr *FinalizerError
+		if !errors.As(err, &ferr) || ferr.Header != header || len(sent) != 0 {
+			t.Errorf("Expected a *FinalizerError for %s without sending, got %v after %d requests", header, err, len(sent))
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package grpcsigv4
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+//    ____ ____  ____   ____   ____  _       __     ___  _
+//   / ___|  _ \|  _ \ / ___| / ___|(_) __ _\ \   / / || |
+//  | |  _| |_) | |_) | |     \___ \| |/ _` |\ \ / /| || |_
+//  | |_| |  _ <|  __/| |___   ___) | | (_| | \ V / |__   _|
+//   \____|_| \_\_|    \____| |____/|_|\__, |  \_/     |_|
+//                                     |___/
+
+var creds = credentials.NewStaticCredentials("ID", "SECRET", "TOKEN")
+
+// TestNewCredentialsErrors ensures that New requires a signer, service and region.
+func TestNewCredentialsErrors(t *testing.T) {
+	for _, args := range [][2]string{{"", "us-east-1"}, {"execute-api", ""}} {
+		if _, err := NewCredentials(v4.NewSigner(creds), args[0], args[1]); err == nil {
+			t.Errorf("Expected an error for %v", args)
+		}
+	}
+	if _, err := NewCredentials(nil, "execute-api", "us-east-1"); err == nil {
+		t.Error("Expected an error for a nil signer")
+	}
+}
+
+// TestCredentials ensures that calls carry SigV4 metadata signed for the call's full method.
+func TestCredentials(t *testing.T) {
+	lis := bufconn.Listen(1 << 20)
+	var md metadata.MD
+	srv := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ = metadata.FromIncomingContext(ctx)
+		return handler(ctx, req)
+	}))
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	c, err := NewCredentials(v4.NewSigner(creds), "execute-api", "us-east-1")
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating credentials: %s", err)
+	}
+	c.AllowInsecure = true
+	conn, err := grpc.NewClient("passthrough:///api.example.com",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithPerRPCCredentials(c))
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while dialing: %s", err)
+	}
+	defer conn.Close()
+
+	if _, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("An unexpected error occurred while calling: %s", err)
+	}
+	auth := strings.Join(md.Get("authorization"), "")
+	switch {
+	case !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=ID/") || !strings.Contains(auth, "/us-east-1/execute-api/aws4_request"):
+		t.Errorf("Unexpected authorization metadata: %q", auth)
+	case len(md.Get("x-amz-date")) != 1 || strings.Join(md.Get("x-amz-security-token"), "") != "TOKEN":
+		t.Errorf("Missing date or security token metadata: %v", md)
+	case strings.Join(md.Get("x-amz-content-sha256"), "") != UnsignedPayload:
+		t.Errorf("Unexpected payload hash metadata: %v", md.Get("x-amz-content-sha256"))
+	}
+}
+
+// TestCallURL ensures that the full method of the call is signed when it is known.
+func TestCallURL(t *testing.T) {
+	u, err := callURL(context.Background(), []string{"http://api.example.com/grpc.health.v1.Health"})
+	if err != nil || u.String() != "https://api.example.com/grpc.health.v1.Health" {
+		t.Errorf("Unexpected URL: %v, %v", u, err)
+	}
+	if _, err := callURL(context.Background(), nil); err == nil {
+		t.Error("Expected an error without a URI")
+	}
+}
+
+// TestRequireTransportSecurity ensures that transport security is required unless explicitly allowed.
+func TestRequireTransportSecurity(t *testing.T) {
+	c, _ := NewCredentials(v4.NewSigner(creds), "execute-api", "us-east-1")
+	if !c.RequireTransportSecurity() {
+		t.Error("Transport security should be required by default")
+	}
+}
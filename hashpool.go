@@ -0,0 +1,88 @@
+package aws_signing_client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type (
+	// hashPool is a fixed set of goroutines hashing request bodies. Jobs are handed over on an unbuffered channel, so
+	// that a request waits for a free worker rather than queueing without bound.
+	hashPool struct {
+		jobs      chan hashJob
+		quit      chan struct{}
+		closeOnce sync.Once
+	}
+
+	hashJob struct {
+		ctx    context.Context
+		hasher PayloadHasher
+		data   []byte
+		result chan hashResult
+	}
+
+	hashResult struct {
+		hash string
+		err  error
+	}
+)
+
+// WithHashWorkers hashes request bodies for the signature on a pool of n goroutines instead of the request's
+// goroutine, e.g. for services sending many mid-sized payloads under CPU contention. A request waits for a free
+// worker, so that at most n bodies are hashed at once; the time it waited is reported to Hooks as part of
+// RequestTiming.QueueWait and the time spent hashing as RequestTiming.Hash. The hash is sent as the
+// X-Amz-Content-Sha256 header, and is computed once for every attempt of a request. Empty bodies and requests that
+// already carry the header are not hashed. The workers are stopped when the Signer is closed; requests made after
+// that are hashed by the signer.
+func WithHashWorkers(n int) Option {
+	return func(s *Signer) {
+		if n <= 0 {
+			return
+		}
+		p := &hashPool{jobs: make(chan hashJob), quit: make(chan struct{})}
+		for i := 0; i < n; i++ {
+			go p.work()
+		}
+		s.hashPool = p
+		s.closers = append(s.closers, p)
+	}
+}
+
+// hashBody hashes the in-memory payload p on the hash pool with the PayloadHasher, if any, and sets the Hash and
+// QueueWait of timing. It returns an empty hash if the pool is closed.
+func (s *Signer) hashBody(ctx context.Context, p *payload, timing *RequestTiming) (string, error) {
+	job := hashJob{ctx: ctx, hasher: s.payloadHasher, data: p.data, result: make(chan hashResult, 1)}
+	wait := time.Now()
+	select {
+	case s.hashPool.jobs <- job:
+	case <-s.hashPool.quit:
+		return "", nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	start := time.Now()
+	timing.QueueWait = start.Sub(wait)
+	res := <-job.result
+	timing.Hash = time.Since(start)
+	return res.hash, res.err
+}
+
+// work hashes jobs until the pool is closed.
+func (p *hashPool) work() {
+	for {
+		select {
+		case job := <-p.jobs:
+			hash, err := hashPayload(job.ctx, job.hasher, job.data)
+			job.result <- hashResult{hash: hash, err: err}
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// Close stops the workers. It implements io.Closer.
+func (p *hashPool) Close() error {
+	p.closeOnce.Do(func() { close(p.quit) })
+	return nil
+}
@@ -0,0 +1,53 @@
+package aws_signing_client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestWithHashWorkers ensures that bodies hashed on the worker pool are signed with their hash, that the hashing is
+// reported to Hooks, and that requests are still signed once the pool is closed.
+func TestWithHashWorkers(t *testing.T) {
+	rh := &recordingHooks{}
+	c, s := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		return response(200, "", nil), nil
+	}, WithHashWorkers(2), WithHooks(rh))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body := strings.Repeat(fmt.Sprint(i), 64<<10)
+			req, _ := http.NewRequest(http.MethodPost, "https://example.com/", strings.NewReader(body))
+			if _, err := c.Do(req); err != nil {
+				t.Errorf("An unexpected error occurred: %s", err)
+				return
+			}
+			sum := sha256.Sum256([]byte(body))
+			if got := req.Header.Get("X-Amz-Content-Sha256"); got != hex.EncodeToString(sum[:]) {
+				t.Errorf("Expected the body hash to be sent, got %q", got)
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, timing := range rh.requests {
+		if timing.Hash <= 0 {
+			t.Errorf("Expected the hashing time to be reported, got %+v", timing)
+		}
+	}
+
+	s.Close()
+	req, _ := http.NewRequest(http.MethodPost, "https://example.com/", strings.NewReader("body"))
+	if _, err := c.Do(req); err != nil {
+		t.Fatalf("An unexpected error occurred after closing the pool: %s", err)
+	}
+	if last := rh.requests[len(rh.requests)-1]; last.Hash != 0 || !strings.Contains(req.Header.Get("Authorization"), "Signature=") {
+		t.Errorf("Expected the request to be hashed by the signer, got %+v", last)
+	}
+}
@@ -0,0 +1,118 @@
+package aws_signing_client
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// HeaderInjector adds or changes headers of a request before it is signed, so that they are covered by the
+// signature.
+type HeaderInjector func(ctx context.Context, req *http.Request)
+
+// WithHeaderInjector registers a HeaderInjector that runs on every request right before it is signed, e.g. to add
+// tenant IDs, idempotency keys or x-amzn-* headers. Injectors run in the order they were registered. Headers set by
+// wrapping the Signer's transport instead would be added after signing and invalidate the signature.
+func WithHeaderInjector(inject HeaderInjector) Option {
+	return func(s *Signer) {
+		s.headerInjectors = append(s.headerInjectors, inject)
+	}
+}
+
+// WithHeaderCanonicalization rewrites the headers of every request into their SigV4 canonical form before signing:
+// leading and trailing whitespace is trimmed, runs of spaces and tabs are folded into a single space, and repeated
+// headers are joined into one comma-separated value. The request then reaches the endpoint with the exact values
+// that were signed, even through proxies that fold repeated headers or normalize whitespace.
+func WithHeaderCanonicalization() Option {
+	return func(s *Signer) {
+		s.canonicalHeaders = true
+	}
+}
+
+// canonicalizeHeaders rewrites h as described by WithHeaderCanonicalization.
+func canonicalizeHeaders(h http.Header) {
+	for k, vs := range h {
+		values := make([]string, len(vs))
+		for i, v := range vs {
+			values[i] = strings.Join(strings.FieldsFunc(v, func(r rune) bool { return r == ' ' || r == '\t' }), " ")
+		}
+		h[k] = []string{strings.Join(values, ",")}
+	}
+}
+
+// WithUnsignedHeaders leaves the named headers out of the signature while still sending them, for infrastructure
+// headers such as tracing or routing headers that proxies between the client and AWS strip or rewrite, which would
+// otherwise invalidate the signature. The headers are removed before each attempt is signed and put back afterwards,
+// and the headers left out are logged.
+func WithUnsignedHeaders(names ...string) Option {
+	return func(s *Signer) {
+		for _, name := range names {
+			s.unsignedHeaders = append(s.unsignedHeaders, http.CanonicalHeaderKey(name))
+		}
+	}
+}
+
+// removeUnsignedHeaders removes the headers set with WithUnsignedHeaders from req, and returns a function that puts
+// them back.
+func (s *Signer) removeUnsignedHeaders(ctx context.Context, req *http.Request) (restore func()) {
+	var removed http.Header
+	for _, name := range s.unsignedHeaders {
+		if v, ok := req.Header[name]; ok {
+			if removed == nil {
+				removed = http.Header{}
+			}
+			removed[name] = v
+			delete(req.Header, name)
+		}
+	}
+	if removed == nil {
+		return func() {}
+	}
+	names := make([]string, 0, len(removed))
+	for name := range removed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	s.logf(ctx, "Excluding headers from the signature: %s", strings.Join(names, ", "))
+	return func() {
+		for name, v := range removed {
+			req.Header[name] = v
+		}
+	}
+}
+
+// DefaultIdempotencyHeader is the header WithIdempotencyToken sets when no header is given. It is the client token
+// header understood by AWS APIs that accept idempotency tokens.
+const DefaultIdempotencyHeader = "X-Amzn-Client-Token"
+
+// WithIdempotencyToken sets header, or DefaultIdempotencyHeader if header is empty, to a random UUID on every POST and
+// PATCH request that does not already carry it. The token is set once per request, before the first attempt, so
+// that every attempt made by WithRetries sends and signs the same token and the endpoint can discard duplicates.
+func WithIdempotencyToken(header string) Option {
+	if header == "" {
+		header = DefaultIdempotencyHeader
+	}
+	return WithHeaderInjector(func(ctx context.Context, req *http.Request) {
+		if req.Method != http.MethodPost && req.Method != http.MethodPatch || req.Header.Get(header) != "" {
+			return
+		}
+		if token, err := newUUID(); err == nil {
+			req.Header.Set(header, token)
+		}
+	})
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := io.ReadFull(rand.Reader, b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
@@ -0,0 +1,201 @@
+package aws_signing_client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+//   _   _                _
+//  | | | | ___  __ _  __| | ___ _ __ ___
+//  | |_| |/ _ \/ _` |/ _` |/ _ \ '__/ __|
+//  |  _  |  __/ (_| | (_| |  __/ |  \__ \
+//  |_| |_|\___|\__,_|\__,_|\___|_|  |___/
+//
+
+func headerClient(t *testing.T, opts ...Option) (*http.Client, **http.Request) {
+	var sent *http.Request
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		sent = req
+		return response(200, "", nil), nil
+	}, opts...)
+	return c, &sent
+}
+
+type tenantKey struct{}
+
+// TestHeaderInjector ensures that injected headers are set before signing and covered by the signature.
+func TestHeaderInjector(t *testing.T) {
+	c, sent := headerClient(t, WithHeaderInjector(func(ctx context.Context, req *http.Request) {
+		req.Header.Set("X-Tenant-Id", ctx.Value(tenantKey{}).(string))
+	}))
+	req, _ := http.NewRequestWithContext(context.WithValue(context.Background(), tenantKey{}, "acme"), "GET", "https://example.com/", nil)
+	if _, err := c.Do(req); err != nil {
+		t.Fatalf("An unexpected error occurred while making a request: %s", err)
+	}
+	switch h := (*sent).Header; {
+	case h.Get("X-Tenant-Id") != "acme":
+		t.Error("Injected header was not sent")
+	case !strings.Contains(h.Get("Authorization"), "x-tenant-id"):
+		t.Errorf("Injected header was not signed: %s", h.Get("Authorization"))
+	}
+}
+
+// TestWithIdempotencyToken ensures that POST requests get a signed token that stays the same across retries, while
+// other methods and existing tokens are left alone.
+func TestWithIdempotencyToken(t *testing.T) {
+	var tokens, signed []string
+	calls := 0
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		calls++
+		tokens = append(tokens, req.Header.Get(DefaultIdempotencyHeader))
+		signed = append(signed, req.Header.Get("Authorization"))
+		if calls == 1 {
+			return response(503, "", nil), nil
+		}
+		return response(200, "", nil), nil
+	}, WithIdempotencyToken(""), WithRetries(2), fastRetries)
+
+	if _, err := c.Post("https://example.com/orders", "application/json", strings.NewReader("{}")); err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err)
+	}
+	switch {
+	case len(tokens) != 2 || len(tokens[0]) != 36 || tokens[0] != tokens[1]:
+		t.Errorf("Expected the same token on both attempts, got %q", tokens)
+	case !strings.Contains(signed[1], strings.ToLower(DefaultIdempotencyHeader)):
+		t.Errorf("The token is not covered by the signature: %s", signed[1])
+	}
+
+	tokens = nil
+	if _, err := c.Post("https://example.com/orders", "application/json", strings.NewReader("{}")); err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err)
+	}
+	if len(tokens) != 1 || tokens[0] == "" {
+		t.Fatalf("Expected a token, got %q", tokens)
+	}
+
+	tokens = nil
+	req, _ := http.NewRequest("POST", "https://example.com/orders", strings.NewReader("{}"))
+	req.Header.Set(DefaultIdempotencyHeader, "caller-token")
+	if _, err := c.Do(req); err != nil || tokens[0] != "caller-token" {
+		t.Errorf("The caller's token was replaced: %q, %v", tokens, err)
+	}
+
+	tokens = nil
+	if _, err := c.Get("https://example.com/orders"); err != nil || tokens[0] != "" {
+		t.Errorf("A GET request got a token: %q, %v", tokens, err)
+	}
+}
+
+// foldHeaders mimics a proxy that folds repeated headers and normalizes whitespace.
+func foldHeaders(h http.Header) http.Header {
+	folded := http.Header{}
+	for k, vs := range h {
+		folded.Set(k, strings.Join(strings.Fields(strings.Join(vs, ", ")), " "))
+	}
+	return folded
+}
+
+// TestWithHeaderCanonicalization ensures that headers are signed and sent in canonical form, so that proxies that
+// fold them do not change what was signed.
+func TestWithHeaderCanonicalization(t *testing.T) {
+	for _, canonicalize := range []bool{false, true} {
+		var opts []Option
+		if canonicalize {
+			opts = append(opts, WithHeaderCanonicalization())
+		}
+		c, sent := headerClient(t, opts...)
+		req, _ := http.NewRequest("GET", "https://example.com/", nil)
+		req.Header.Add("X-Tags", "  a\t\tb ")
+		req.Header.Add("X-Tags", "c")
+		req.Header.Set("X-Note", "\tone  two\t")
+		if _, err := c.Do(req); err != nil {
+			t.Fatalf("An unexpected error occurred: %s", err)
+		}
+
+		signed := canonicalRequest(*sent, nil)
+		proxied := (*sent).Clone(context.Background())
+		proxied.Header = foldHeaders(proxied.Header)
+		if match := canonicalRequest(proxied, nil) == signed; match != canonicalize {
+			t.Errorf("Canonicalization %t: expected the signature to survive folding to be %t", canonicalize, canonicalize)
+		}
+		if canonicalize && ((*sent).Header.Get("X-Tags") != "a b,c" || (*sent).Header.Get("X-Note") != "one two") {
+			t.Errorf("Unexpected canonical headers: %q", (*sent).Header)
+		}
+	}
+}
+
+// TestWithUserAgent ensures that the product and package tokens are appended to the User-Agent once.
+func TestWithUserAgent(t *testing.T) {
+	c, sent := headerClient(t, WithUserAgent("indexer/2.1"))
+	token := "indexer/2.1 aws_signing_client/" + Version()
+
+	req, _ := http.NewRequest("GET", "https://example.com/", nil)
+	req.Header.Set("User-Agent", "my-app/1.0")
+	if _, err := c.Do(req); err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err)
+	}
+	if ua := (*sent).Header.Get("User-Agent"); ua != "my-app/1.0 "+token {
+		t.Errorf("Unexpected User-Agent: %q", ua)
+	}
+
+	req, _ = http.NewRequest("GET", "https://example.com/", nil)
+	req.Header.Set("User-Agent", "my-app/1.0 "+token)
+	if _, err := c.Do(req); err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err)
+	}
+	if ua := (*sent).Header.Get("User-Agent"); ua != "my-app/1.0 "+token {
+		t.Errorf("Expected the User-Agent not to be appended to twice, got %q", ua)
+	}
+
+	if _, err := c.Get("https://example.com/"); err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err)
+	}
+	if ua := (*sent).Header.Get("User-Agent"); ua != token {
+		t.Errorf("Unexpected User-Agent: %q", ua)
+	}
+}
+
+// TestWithUnsignedHeaders ensures that unsigned headers are sent but left out of the signature.
+func TestWithUnsignedHeaders(t *testing.T) {
+	c, sent := headerClient(t, WithUnsignedHeaders("x-trace-id"))
+	req, _ := http.NewRequest("GET", "https://example.com/", nil)
+	req.Header.Set("X-Trace-Id", "abc")
+	req.Header.Set("X-Tenant", "42")
+	if _, err := c.Do(req); err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err)
+	}
+	auth := (*sent).Header.Get("Authorization")
+	switch {
+	case (*sent).Header.Get("X-Trace-Id") != "abc":
+		t.Error("Unsigned header was not sent")
+	case strings.Contains(auth, "x-trace-id") || !strings.Contains(auth, "x-tenant"):
+		t.Errorf("Unexpected signed headers: %s", auth)
+	}
+}
+
+// TestWithUnsignedHeadersFacade ensures that Sign and Presign leave unsigned headers out of the signature too.
+func TestWithUnsignedHeadersFacade(t *testing.T) {
+	c, _ := headerClient(t, WithUnsignedHeaders("x-trace-id"))
+	fc, _ := WrapClient(c)
+	req, _ := http.NewRequest("GET", "https://example.com/", nil)
+	req.Header.Set("X-Trace-Id", "abc")
+	if err := fc.Sign(req); err != nil {
+		t.Fatalf("An unexpected error occurred while signing: %s", err)
+	}
+	if auth := req.Header.Get("Authorization"); strings.Contains(auth, "x-trace-id") || req.Header.Get("X-Trace-Id") != "abc" {
+		t.Errorf("Unexpected signed headers: %s", auth)
+	}
+
+	req, _ = http.NewRequest("GET", "https://example.com/", nil)
+	req.Header.Set("X-Trace-Id", "abc")
+	u, _, err := fc.Presign(req, time.Minute)
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while presigning: %s", err)
+	}
+	if strings.Contains(u, "x-trace-id") || req.Header.Get("X-Trace-Id") != "abc" {
+		t.Errorf("Unexpected signed headers: %s", u)
+	}
+}
@@ -0,0 +1,148 @@
+package aws_signing_client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HealthStatus is the health of an endpoint as determined by a HealthChecker.
+type HealthStatus int
+
+const (
+	// HealthUnknown is the status before the first check completes.
+	HealthUnknown HealthStatus = iota
+	// Healthy means the last check succeeded.
+	Healthy
+	// Unhealthy means the last check failed.
+	Unhealthy
+)
+
+// HealthTransition describes a change of HealthStatus reported by a HealthChecker.
+type HealthTransition struct {
+	From, To HealthStatus
+	// StatusCode is the status code of the check that caused the transition, or 0 if no response was received.
+	StatusCode int
+	// Err is the error of the check that caused the transition, if any.
+	Err error
+	At  time.Time
+}
+
+// HealthChecker periodically sends a signed request to an endpoint, such as an OpenSearch domain's
+// /_cluster/health, and reports status transitions through OnTransition. Fields must not be changed after Run is
+// called.
+type HealthChecker struct {
+	// Client sends the checks; it is typically a client returned by New.
+	Client *http.Client
+	// URL is the URL to check.
+	URL string
+	// Method is the HTTP method of the checks. If empty, HEAD is used.
+	Method string
+	// Interval is the time between checks.
+	Interval time.Duration
+	// Timeout bounds each check. If zero, Interval is used.
+	Timeout time.Duration
+	// IsHealthy classifies a response. If nil, any 2xx response is healthy.
+	IsHealthy func(*http.Response) bool
+	// OnTransition, if set, is called from Run's goroutine whenever the status changes, including from
+	// HealthUnknown after the first check.
+	OnTransition func(HealthTransition)
+
+	mu     sync.Mutex
+	status HealthStatus
+}
+
+// NewHealthChecker returns a HealthChecker that sends a HEAD request to url with client every interval.
+func NewHealthChecker(client *http.Client, url string, interval time.Duration, onTransition func(HealthTransition)) *HealthChecker {
+	return &HealthChecker{Client: client, URL: url, Interval: interval, OnTransition: onTransition}
+}
+
+// Run checks the endpoint immediately and then every Interval until ctx is done.
+func (h *HealthChecker) Run(ctx context.Context) {
+	t := time.NewTicker(h.Interval)
+	defer t.Stop()
+	for {
+		h.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Check performs a single check, records the resulting status and reports a transition if it changed.
+func (h *HealthChecker) Check(ctx context.Context) (HealthStatus, error) {
+	code, err := h.check(ctx)
+	to := Healthy
+	if err != nil {
+		to = Unhealthy
+	}
+
+	h.mu.Lock()
+	from := h.status
+	h.status = to
+	h.mu.Unlock()
+
+	if from != to && h.OnTransition != nil {
+		h.OnTransition(HealthTransition{From: from, To: to, StatusCode: code, Err: err, At: time.Now()})
+	}
+	return to, err
+}
+
+// Status returns the status determined by the most recent check.
+func (h *HealthChecker) Status() HealthStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.status
+}
+
+func (h *HealthChecker) check(ctx context.Context) (int, error) {
+	timeout := h.Timeout
+	if timeout == 0 {
+		timeout = h.Interval
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	method := h.Method
+	if method == "" {
+		method = "HEAD"
+	}
+	req, err := http.NewRequestWithContext(ctx, method, h.URL, nil)
+	if err != nil {
+		return 0, err
+	}
+	c := h.Client
+	if c == nil {
+		c = http.DefaultClient
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	// Drain the body so the connection can be reused by the next check.
+	DrainAndClose(resp)
+
+	healthy := resp.StatusCode >= 200 && resp.StatusCode <= 299
+	if h.IsHealthy != nil {
+		healthy = h.IsHealthy(resp)
+	}
+	if !healthy {
+		return resp.StatusCode, fmt.Errorf("health check of '%s' returned status %d", h.URL, resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// String implements the fmt.Stringer interface.
+func (hs HealthStatus) String() string {
+	switch hs {
+	case Healthy:
+		return "healthy"
+	case Unhealthy:
+		return "unhealthy"
+	}
+	return "unknown"
+}
@@ -0,0 +1,71 @@
+package aws_signing_client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+//   _   _            _ _   _
+//  | | | | ___  __ _| | |_| |__
+//  | |_| |/ _ \/ _` | | __| '_ \
+//  |  _  |  __/ (_| | | |_| | | |
+//  |_| |_|\___|\__,_|_|\__|_| |_|
+//
+
+// TestHealthCheckerTransitions ensures that status transitions are reported once per change.
+func TestHealthCheckerTransitions(t *testing.T) {
+	codes := []int{200, 200, 503, 503, 200}
+	var signed int
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		if req.Method == "HEAD" && req.Header.Get("Authorization") != "" {
+			signed++
+		}
+		code := codes[0]
+		codes = codes[1:]
+		return response(code, "", nil), nil
+	})
+
+	var transitions []HealthTransition
+	h := NewHealthChecker(c, "https://example.com/_cluster/health", time.Second, func(tr HealthTransition) {
+		transitions = append(transitions, tr)
+	})
+	for range []int{0, 1, 2, 3, 4} {
+		h.Check(context.Background())
+	}
+
+	switch {
+	case signed != 5:
+		t.Errorf("Expected 5 signed HEAD checks, got %d", signed)
+	case len(transitions) != 3:
+		t.Fatalf("Expected 3 transitions, got %d: %+v", len(transitions), transitions)
+	case transitions[0].From != HealthUnknown || transitions[0].To != Healthy:
+		t.Errorf("Unexpected first transition: %+v", transitions[0])
+	case transitions[1].To != Unhealthy || transitions[1].StatusCode != 503 || transitions[1].Err == nil:
+		t.Errorf("Unexpected second transition: %+v", transitions[1])
+	case transitions[2].To != Healthy || h.Status() != Healthy:
+		t.Errorf("Unexpected final transition: %+v", transitions[2])
+	}
+}
+
+// TestHealthCheckerRun ensures that Run checks immediately and stops when its context is done.
+func TestHealthCheckerRun(t *testing.T) {
+	c := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return response(200, "", nil), nil
+	})}
+	done := make(chan HealthTransition, 1)
+	h := NewHealthChecker(c, "https://example.com/", time.Hour, func(tr HealthTransition) { done <- tr })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		h.Run(ctx)
+		close(stopped)
+	}()
+	if tr := <-done; tr.To != Healthy {
+		t.Errorf("Unexpected transition: %+v", tr)
+	}
+	cancel()
+	<-stopped
+}
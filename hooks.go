@@ -0,0 +1,117 @@
+package aws_signing_client
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// Hooks receives events from the Signer, e.g. to record metrics. Every event carries the request tags attached
+	// with WithRequestTags. Implementations should embed NopHooks so that they keep compiling when events are
+	// added. Hooks are called on the request's goroutine and must be safe for concurrent use.
+	Hooks interface {
+		// OnRequest is called once the wrapped transport returns for a signed request.
+		OnRequest(ctx context.Context, timing RequestTiming)
+		// OnRetry is called when attempt, counting from 1, failed with cause and the request will be retried after
+		// delay. The attempt's OnRequest event, which carries its destination and status, precedes it.
+		OnRetry(ctx context.Context, attempt int, delay time.Duration, cause error)
+		// OnSkip is called before req is sent without being signed for reason. No OnRequest event follows.
+		OnSkip(ctx context.Context, req *http.Request, reason SkipReason)
+		// OnAnomaly is called when a response fails a check of a validator registered with
+		// WithResponseValidator.
+		OnAnomaly(ctx context.Context, a Anomaly)
+		// OnSignerMismatch is called when the candidate RequestSigner of WithCandidateSigner signs a request
+		// differently than the client's RequestSigner.
+		OnSignerMismatch(ctx context.Context, m SignerMismatch)
+	}
+
+	// NopHooks implements Hooks by ignoring every event.
+	NopHooks struct{}
+
+	// RetryEvent describes a retry, as passed to Hooks.OnRetry.
+	RetryEvent struct {
+		Attempt int
+		Delay   time.Duration
+		Cause   error
+		// Tags are the request tags attached to the request's context with WithRequestTags.
+		Tags map[string]string
+	}
+
+	// RetryEventStream is a Hooks implementation that delivers retries as RetryEvents on a channel, e.g. to feed
+	// a backpressure controller running in its own goroutine. Events are dropped rather than delaying requests when
+	// the channel's buffer is full. Register it with WithHooks; it is closed, closing the channel, by Signer.Close.
+	RetryEventStream struct {
+		NopHooks
+		mu      sync.RWMutex // guards sending against closing events
+		events  chan RetryEvent
+		closed  bool
+		dropped int64 // accessed atomically
+	}
+)
+
+// OnRequest implements Hooks.
+func (NopHooks) OnRequest(ctx context.Context, timing RequestTiming) {}
+
+// OnRetry implements Hooks.
+func (NopHooks) OnRetry(ctx context.Context, attempt int, delay time.Duration, cause error) {}
+
+// OnSkip implements Hooks.
+func (NopHooks) OnSkip(ctx context.Context, req *http.Request, reason SkipReason) {}
+
+// OnAnomaly implements Hooks.
+func (NopHooks) OnAnomaly(ctx context.Context, a Anomaly) {}
+
+// OnSignerMismatch implements Hooks.
+func (NopHooks) OnSignerMismatch(ctx context.Context, m SignerMismatch) {}
+
+// WithHooks registers h to receive the Signer's events. It may be given more than once; hooks are called in the
+// order they were registered. Hooks that implement io.Closer are closed by Signer.Close.
+func WithHooks(h Hooks) Option {
+	return func(s *Signer) {
+		s.hooks = append(s.hooks, h)
+	}
+}
+
+// NewRetryEventStream returns a RetryEventStream whose channel buffers up to buffer events.
+func NewRetryEventStream(buffer int) *RetryEventStream {
+	return &RetryEventStream{events: make(chan RetryEvent, buffer)}
+}
+
+// Events returns the channel retries are delivered on. It is closed when the stream is closed.
+func (rs *RetryEventStream) Events() <-chan RetryEvent {
+	return rs.events
+}
+
+// Dropped returns the number of events that were dropped because the channel's buffer was full.
+func (rs *RetryEventStream) Dropped() int64 {
+	return atomic.LoadInt64(&rs.dropped)
+}
+
+// OnRetry implements Hooks.
+func (rs *RetryEventStream) OnRetry(ctx context.Context, attempt int, delay time.Duration, cause error) {
+	ev := RetryEvent{Attempt: attempt, Delay: delay, Cause: cause, Tags: RequestTags(ctx)}
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	if rs.closed {
+		return
+	}
+	select {
+	case rs.events <- ev:
+	default:
+		atomic.AddInt64(&rs.dropped, 1)
+	}
+}
+
+// Close implements io.Closer. Events of retries after Close are discarded.
+func (rs *RetryEventStream) Close() error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if !rs.closed {
+		rs.closed = true
+		close(rs.events)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package aws_signing_client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+//   _   _             _
+//  | | | | ___   ___ | | _____
+//  | |_| |/ _ \ / _ \| |/ / __|
+//  |  _  | (_) | (_) |   <\__ \
+//  |_| |_|\___/ \___/|_|\_\___/
+//
+
+type retryHooks struct {
+	NopHooks
+	mu      sync.Mutex
+	retries []RetryEvent
+}
+
+func (rh *retryHooks) OnRetry(ctx context.Context, attempt int, delay time.Duration, cause error) {
+	rh.mu.Lock()
+	defer rh.mu.Unlock()
+	rh.retries = append(rh.retries, RetryEvent{Attempt: attempt, Delay: delay, Cause: cause, Tags: RequestTags(ctx)})
+}
+
+func retryingClient(t *testing.T, statuses []int, opts ...Option) *http.Client {
+	n := 0
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		n++
+		return response(statuses[n-1], "", nil), nil
+	}, append([]Option{WithRetries(len(statuses)), fastRetries}, opts...)...)
+	return c
+}
+
+// TestOnRetry ensures that OnRetry is called for every retried attempt with its cause.
+func TestOnRetry(t *testing.T) {
+	rh := &retryHooks{}
+	c := retryingClient(t, []int{503, 429, 200}, WithHooks(rh))
+
+	ctx := WithRequestTags(context.Background(), map[string]string{"domain": "logs"})
+	req, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/", nil)
+	if _, err := c.Do(req); err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err)
+	}
+
+	if len(rh.retries) != 2 {
+		t.Fatalf("Expected 2 retries, got %d", len(rh.retries))
+	}
+	for i, ev := range rh.retries {
+		var awsErr *AWSError
+		switch {
+		case ev.Attempt != i+1:
+			t.Errorf("Unexpected attempt for retry %d: %d", i, ev.Attempt)
+		case !errors.As(ev.Cause, &awsErr) || awsErr.StatusCode != []int{503, 429}[i]:
+			t.Errorf("Unexpected cause for retry %d: %v", i, ev.Cause)
+		case ev.Tags["domain"] != "logs":
+			t.Errorf("Tags were not passed to OnRetry: %v", ev.Tags)
+		}
+	}
+}
+
+// TestRetryEventStream ensures that retries are delivered on the stream's channel, that events are dropped when it
+// is full, and that closing the Signer closes the channel.
+func TestRetryEventStream(t *testing.T) {
+	stream := NewRetryEventStream(1)
+	c := retryingClient(t, []int{503, 503, 200}, WithHooks(stream))
+	if _, err := c.Get("https://example.com/"); err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err)
+	}
+
+	if ev := <-stream.Events(); ev.Attempt != 1 {
+		t.Errorf("Unexpected first event: %+v", ev)
+	}
+	if stream.Dropped() != 1 {
+		t.Errorf("Expected 1 dropped event, got %d", stream.Dropped())
+	}
+	if err := c.Transport.(*Signer).Close(); err != nil {
+		t.Fatalf("An unexpected error occurred while closing the Signer: %s", err)
+	}
+	if _, ok := <-stream.Events(); ok {
+		t.Error("The event channel was not closed")
+	}
+}
+
+type skipHooks struct {
+	NopHooks
+	skips []SkipReason
+}
+
+func (sh *skipHooks) OnSkip(ctx context.Context, req *http.Request, reason SkipReason) {
+	sh.skips = append(sh.skips, reason)
+}
+
+// TestOnSkip ensures that requests sent without signing are reported with the reason.
+func TestOnSkip(t *testing.T) {
+	sh := &skipHooks{}
+	c, sent := headerClient(t, WithHooks(sh))
+
+	signed, _ := http.NewRequest("GET", "https://example.com/", nil)
+	signed.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential=OLD/20150830/us-east-1/es/aws4_request")
+	if _, err := c.Do(signed); err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err)
+	}
+	stats := &Stats{}
+	ctx := WithRequestStats(WithoutSigning(context.Background()), stats)
+	skipped, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/", nil)
+	if _, err := c.Do(skipped); err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err)
+	}
+	if (*sent).Header.Get("Authorization") != "" {
+		t.Error("Expected the request marked with WithoutSigning to be sent unsigned")
+	}
+	if stats.Skipped != SkipContext {
+		t.Errorf("Expected the request's stats to record the skip, got %q", stats.Skipped)
+	}
+	if _, err := c.Get("https://example.com/"); err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err)
+	}
+
+	if len(sh.skips) != 2 || sh.skips[0] != SkipAlreadySigned || sh.skips[1] != SkipContext {
+		t.Errorf("Unexpected skip events: %v", sh.skips)
+	}
+}
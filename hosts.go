@@ -0,0 +1,55 @@
+package aws_signing_client
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DisallowedHostError is an implementation of the error interface that is returned by RoundTrip for requests to a
+// host outside the allowlist set with WithAllowedHosts.
+type DisallowedHostError struct {
+	Host string
+}
+
+// WithAllowedHosts restricts the hosts the Signer sends requests to. A pattern is either a host name, matched
+// exactly, or a wildcard such as "*.amazonaws.com", which matches any subdomain but not the domain itself. Matching
+// ignores case and the port. Requests to any other host, including redirect hops, fail with a *DisallowedHostError
+// before they are signed or sent. It may be given more than once; the patterns are combined.
+func WithAllowedHosts(patterns ...string) Option {
+	return func(s *Signer) {
+		for _, p := range patterns {
+			s.allowedHosts = append(s.allowedHosts, strings.ToLower(strings.TrimSuffix(p, ".")))
+		}
+		if s.allowedHosts == nil {
+			s.allowedHosts = []string{}
+		}
+	}
+}
+
+// hostAllowed reports whether requests may be sent to host, which must not include a port.
+func (s *Signer) hostAllowed(host string) bool {
+	if s.allowedHosts == nil {
+		return true
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, p := range s.allowedHosts {
+		if matchHost(p, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchHost reports whether the lower-case host, without a port or trailing dot, matches pattern, which is either
+// a host name or a wildcard such as "*.amazonaws.com".
+func matchHost(pattern, host string) bool {
+	if strings.HasPrefix(pattern, "*.") {
+		return strings.HasSuffix(host, pattern[1:]) && len(host) > len(pattern)-1
+	}
+	return host == pattern
+}
+
+// Error implements the error interface.
+func (err *DisallowedHostError) Error() string {
+	return fmt.Sprintf("Host '%s' is not in the list of allowed hosts. Refusing to send request.", err.Host)
+}
@@ -0,0 +1,95 @@
+package aws_signing_client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+//   _   _           _
+//  | | | | ___  ___| |_ ___
+//  | |_| |/ _ \/ __| __/ __|
+//  |  _  | (_) \__ \ |_\__ \
+//  |_| |_|\___/|___/\__|___/
+//
+
+// TestWithAllowedHosts ensures that requests to hosts outside the allowlist are refused before they are sent.
+func TestWithAllowedHosts(t *testing.T) {
+	sent := 0
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		sent++
+		return response(200, "", nil), nil
+	}, WithAllowedHosts("*.amazonaws.com", "vpce.internal.example.com"))
+
+	cases := []struct {
+		url     string
+		allowed bool
+	}{
+		{"https://my-domain.us-east-1.es.amazonaws.com/", true},
+		{"https://MY-DOMAIN.US-EAST-1.ES.AMAZONAWS.COM:443/", true},
+		{"https://vpce.internal.example.com/", true},
+		{"https://amazonaws.com/", false},
+		{"https://amazonaws.com.evil.example.com/", false},
+		{"https://evilamazonaws.com/", false},
+		{"https://169.254.169.254/latest/meta-data/", false},
+	}
+	for _, tc := range cases {
+		sent = 0
+		_, err := c.Get(tc.url)
+		var hostErr *DisallowedHostError
+		switch {
+		case tc.allowed && (err != nil || sent != 1):
+			t.Errorf("Request to %s should have been sent: %v", tc.url, err)
+		case !tc.allowed && (!errors.As(err, &hostErr) || sent != 0):
+			t.Errorf("Request to %s should have been refused: %v", tc.url, err)
+		}
+	}
+}
+
+// TestWithAllowedHostsEmpty ensures that an empty allowlist refuses every host.
+func TestWithAllowedHostsEmpty(t *testing.T) {
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		return response(200, "", nil), nil
+	}, WithAllowedHosts())
+	var hostErr *DisallowedHostError
+	if _, err := c.Get("https://example.com/"); !errors.As(err, &hostErr) {
+		t.Errorf("Expected a *DisallowedHostError, got %v", err)
+	}
+}
+
+// TestWithHostTransport ensures that requests are signed and sent through the transport registered for their host.
+func TestWithHostTransport(t *testing.T) {
+	var via []string
+	transport := func(name string) http.RoundTripper {
+		return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("Authorization") == "" {
+				t.Errorf("Request to %s was not signed", req.URL.Host)
+			}
+			via = append(via, name)
+			return response(200, "", nil), nil
+		})
+	}
+	c, err := New(v4.NewSigner(creds), &http.Client{Transport: transport("default")}, "es", "us-east-1", nil,
+		WithHostTransport("*.corp.example.com", transport("proxy")),
+		WithHostTransport("search.corp.example.com", transport("unused")))
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+	for _, u := range []string{
+		"https://search.us-east-1.es.amazonaws.com/",
+		"https://search.corp.example.com:9200/",
+		"https://SEARCH.CORP.EXAMPLE.COM./",
+		"https://corp.example.com/",
+	} {
+		if _, err := c.Get(u); err != nil {
+			t.Fatalf("An unexpected error occurred while making a request: %s", err)
+		}
+	}
+	if expected := []string{"default", "proxy", "proxy", "default"}; fmt.Sprint(via) != fmt.Sprint(expected) {
+		t.Errorf("Expected requests to go through %v, got %v", expected, via)
+	}
+	c.CloseIdleConnections()
+}
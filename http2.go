@@ -0,0 +1,57 @@
+package aws_signing_client
+
+import (
+	"net/http"
+	"time"
+)
+
+// HTTP2Mode selects whether the wrapped transport negotiates HTTP/2, as set with WithHTTP2.
+type HTTP2Mode int
+
+const (
+	// HTTP2Default leaves the choice to the wrapped transport. http.DefaultTransport negotiates HTTP/2, but an
+	// *http.Transport with a custom dialer or TLS configuration does not unless ForceAttemptHTTP2 is set.
+	HTTP2Default HTTP2Mode = iota
+	// HTTP2Force negotiates HTTP/2 with servers that support it, even with a custom dialer or TLS configuration.
+	HTTP2Force
+	// HTTP2Disable only ever speaks HTTP/1.1.
+	HTTP2Disable
+)
+
+// WithHTTP2 forces or disables HTTP/2 on the wrapped transport. The wrapped transport must be an *http.Transport (or
+// nil for http.DefaultTransport); it is cloned, and New fails with an *UnsupportedTransportError for any other
+// transport.
+func WithHTTP2(mode HTTP2Mode) Option {
+	return func(s *Signer) {
+		s.transportConfigs = append(s.transportConfigs, transportConfig{option: "WithHTTP2", apply: func(t *http.Transport) error {
+			if mode == HTTP2Default {
+				return nil
+			}
+			p := &http.Protocols{}
+			p.SetHTTP1(true)
+			p.SetHTTP2(mode == HTTP2Force)
+			t.Protocols = p
+			return nil
+		}})
+	}
+}
+
+// WithHTTP2HealthCheck makes the wrapped transport send a ping on an HTTP/2 connection that received no frames for
+// readIdle, and close the connection if the ping is not answered within pingTimeout, failing the requests waiting on
+// it. Without it, requests on a dead connection, e.g. to a VPC endpoint whose path was reset, hang until the
+// operating system gives up on the socket. A zero pingTimeout uses the transport's default of 15 seconds. The wrapped
+// transport must be an *http.Transport (or nil for http.DefaultTransport); it is cloned, and New fails with an
+// *UnsupportedTransportError for any other transport.
+func WithHTTP2HealthCheck(readIdle, pingTimeout time.Duration) Option {
+	return func(s *Signer) {
+		s.transportConfigs = append(s.transportConfigs, transportConfig{option: "WithHTTP2HealthCheck", apply: func(t *http.Transport) error {
+			cfg := http.HTTP2Config{}
+			if t.HTTP2 != nil {
+				cfg = *t.HTTP2
+			}
+			cfg.SendPingTimeout, cfg.PingTimeout = readIdle, pingTimeout
+			t.HTTP2 = &cfg
+			return nil
+		}})
+	}
+}
@@ -0,0 +1,64 @@
+package aws_signing_client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+//   _   _ _____ _____ ____   ______
+//  | | | |_   _|_   _|  _ \ / /___ \
+//  | |_| | | |   | | | |_) / /  __) |
+//  |  _  | | |   | | |  __/ /  / __/
+//  |_| |_| |_|   |_| |_| /_/  |_____|
+//
+
+// TestWithHTTP2 ensures that HTTP/2 can be forced and disabled on the wrapped transport.
+func TestWithHTTP2(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+	tlsConfig := srv.Client().Transport.(*http.Transport).TLSClientConfig
+	url := strings.Replace(srv.URL, "127.0.0.1", "example.com", 1)
+
+	for mode, proto := range map[HTTP2Mode]int{HTTP2Default: 1, HTTP2Force: 2, HTTP2Disable: 1} {
+		// A custom TLS configuration keeps a plain *http.Transport from negotiating HTTP/2 by default.
+		tr := &http.Transport{TLSClientConfig: tlsConfig.Clone()}
+		tr.DialContext = resolvingDialer(&staticResolver{addrs: map[string][]string{"example.com": {"127.0.0.1"}}}, nil)
+		c, err := New(v4.NewSigner(creds), &http.Client{Transport: tr}, "es", "us-east-1", nil, WithHTTP2(mode))
+		if err != nil {
+			t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+		}
+		resp, err := c.Get(url)
+		if err != nil {
+			t.Fatalf("An unexpected error occurred while making a request: %s", err)
+		}
+		resp.Body.Close()
+		if resp.ProtoMajor != proto {
+			t.Errorf("Expected HTTP/%d for mode %d, got %s", proto, mode, resp.Proto)
+		}
+	}
+}
+
+// TestWithHTTP2HealthCheck ensures that the ping settings are applied to a copy of the wrapped transport.
+func TestWithHTTP2HealthCheck(t *testing.T) {
+	orig := &http.Transport{HTTP2: &http.HTTP2Config{MaxConcurrentStreams: 10}}
+	c, err := New(v4.NewSigner(creds), &http.Client{Transport: orig}, "es", "us-east-1", nil, WithHTTP2HealthCheck(10*time.Second, 5*time.Second))
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+	cfg := c.Transport.(*Signer).transport.(*http.Transport).HTTP2
+	switch {
+	case cfg.SendPingTimeout != 10*time.Second || cfg.PingTimeout != 5*time.Second:
+		t.Errorf("Ping settings were not applied: %+v", cfg)
+	case cfg.MaxConcurrentStreams != 10:
+		t.Errorf("Existing HTTP/2 settings were lost: %+v", cfg)
+	case orig.HTTP2.SendPingTimeout != 0:
+		t.Error("The caller's transport was modified")
+	}
+}
@@ -0,0 +1,16 @@
+// Package integration holds the live integration test suite for aws_signing_client and the helpers it is built on.
+// The helpers are exported so that other packages can write integration tests against their own endpoints.
+//
+// The suite only builds with the integration tag, and each test is skipped unless the environment variable naming
+// its endpoint is set. Credentials come from the default aws-sdk-go credential chain and the region from AWS_REGION
+// or AWS_DEFAULT_REGION:
+//
+//	AWS_SIGNING_CLIENT_OPENSEARCH_ENDPOINT=https://search-domain.us-east-1.es.amazonaws.com \
+//	AWS_SIGNING_CLIENT_APIGW_ENDPOINT=https://abc123.execute-api.us-east-1.amazonaws.com/test \
+//	AWS_SIGNING_CLIENT_APIGW_WEBSOCKET_ENDPOINT=https://def456.execute-api.us-east-1.amazonaws.com/test \
+//	go test -tags integration ./integration
+//
+// The OpenSearch domain must allow the caller to create and delete indices prefixed with
+// "aws-signing-client-it-". The API Gateway stage must accept any method and path with IAM authorization, e.g. a
+// {proxy+} resource with a mock integration.
+package integration
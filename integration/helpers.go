@@ -0,0 +1,98 @@
+//go:build !nosdkv1
+
+package integration
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+
+	"github.com/Nextdoor/aws_signing_client"
+)
+
+// Environment variables naming the endpoints used by the suite.
+const (
+	EnvOpenSearchEndpoint = "AWS_SIGNING_CLIENT_OPENSEARCH_ENDPOINT"
+	EnvAPIGatewayEndpoint = "AWS_SIGNING_CLIENT_APIGW_ENDPOINT"
+	EnvWebSocketEndpoint  = "AWS_SIGNING_CLIENT_APIGW_WEBSOCKET_ENDPOINT"
+)
+
+// Endpoint returns the value of the environment variable key, skipping the test when it is unset.
+func Endpoint(t testing.TB, key string) string {
+	t.Helper()
+	v := os.Getenv(key)
+	if v == "" {
+		t.Skipf("%s is not set", key)
+	}
+	return v
+}
+
+// Client returns a client that signs requests for service with the default aws-sdk-go credential chain and region.
+func Client(t testing.TB, service string, opts ...aws_signing_client.Option) *http.Client {
+	t.Helper()
+	sess, err := session.NewSessionWithOptions(session.Options{SharedConfigState: session.SharedConfigEnable})
+	if err != nil {
+		t.Fatalf("Could not load AWS configuration: %s", err)
+	}
+	region := aws.StringValue(sess.Config.Region)
+	if region == "" {
+		t.Skip("No AWS region is configured")
+	}
+	c, err := aws_signing_client.New(v4.NewSigner(sess.Config.Credentials), &http.Client{}, service, region, testLogger{t}, opts...)
+	if err != nil {
+		t.Fatalf("Could not create signing client: %s", err)
+	}
+	return c
+}
+
+// Do sends req with c and returns the response with its body read, failing the test on transport errors.
+func Do(t testing.TB, c *http.Client, req *http.Request) (*http.Response, []byte) {
+	t.Helper()
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %s", req.Method, req.URL, err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Reading the response to %s %s failed: %s", req.Method, req.URL, err)
+	}
+	return resp, body
+}
+
+// DoSuccess sends req with c like Do and fails the test unless the response has a 2xx status, as RequireSuccess.
+func DoSuccess(t testing.TB, c *http.Client, req *http.Request) []byte {
+	t.Helper()
+	resp, body := Do(t, c, req)
+	RequireSuccess(t, resp, body)
+	return body
+}
+
+// RequireSuccess fails the test unless resp has a 2xx status, reporting the parsed AWS error otherwise. A 403 with
+// a SignatureDoesNotMatch or InvalidSignatureException code indicates a signing bug.
+func RequireSuccess(t testing.TB, resp *http.Response, body []byte) {
+	t.Helper()
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return
+	}
+	awsErr := aws_signing_client.NewAWSError(resp, body)
+	if awsErr.Message == "" {
+		t.Fatalf("%s %s: %s: %s", resp.Request.Method, resp.Request.URL, awsErr, body)
+	}
+	t.Fatalf("%s %s: %s", resp.Request.Method, resp.Request.URL, awsErr)
+}
+
+// testLogger routes signing logs to the test log, so that they are shown for failing tests.
+type testLogger struct {
+	t testing.TB
+}
+
+func (l testLogger) Printf(ctx context.Context, format string, v ...interface{}) {
+	l.t.Logf(format, v...)
+}
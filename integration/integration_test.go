@@ -0,0 +1,147 @@
+//go:build integration
+
+package integration
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Nextdoor/aws_signing_client"
+)
+
+func randomSuffix(t *testing.T) string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+//    ___                   ____                      _
+//   / _ \ _ __   ___ _ __ / ___|  ___  __ _ _ __ ___| |__
+//  | | | | '_ \ / _ \ '_ \\___ \ / _ \/ _` | '__/ __| '_ \
+//  | |_| | |_) |  __/ | | |___) |  __/ (_| | | | (__| | | |
+//   \___/| .__/ \___|_| |_|____/ \___|\__,_|_|  \___|_| |_|
+//        |_|
+
+// withIndex creates an index for the duration of the test and returns its URL.
+func withIndex(t *testing.T, c *http.Client, endpoint string) string {
+	idx := endpoint + "/aws-signing-client-it-" + randomSuffix(t)
+	req, _ := http.NewRequest("PUT", idx, nil)
+	DoSuccess(t, c, req)
+	t.Cleanup(func() {
+		req, _ := http.NewRequest("DELETE", idx, nil)
+		Do(t, c, req)
+	})
+	return idx
+}
+
+// TestOpenSearchClusterHealth ensures that a signed GET without a body is accepted.
+func TestOpenSearchClusterHealth(t *testing.T) {
+	endpoint := Endpoint(t, EnvOpenSearchEndpoint)
+	req, _ := http.NewRequest("GET", endpoint+"/_cluster/health", nil)
+	DoSuccess(t, Client(t, "es"), req)
+}
+
+// TestOpenSearchEscaping ensures that paths and queries that need escaping are signed the way OpenSearch checks
+// them: comma-separated index lists, document IDs with reserved characters, and queries with spaces.
+func TestOpenSearchEscaping(t *testing.T) {
+	endpoint := Endpoint(t, EnvOpenSearchEndpoint)
+	c := Client(t, "es")
+	idx := withIndex(t, c, endpoint)
+	name := idx[strings.LastIndex(idx, "/")+1:]
+
+	for _, id := range []string{"plain", "with space", "plus+sign", "comma,id", "star*tilde~", "ünïcødé"} {
+		req, _ := http.NewRequest("PUT", idx+"/_doc/"+url.PathEscape(id)+"?refresh=true", strings.NewReader(`{"id":"x"}`))
+		req.Header.Set("Content-Type", "application/json")
+		DoSuccess(t, c, req)
+	}
+
+	for _, u := range []string{
+		endpoint + "/" + name + "," + name + "/_search",
+		endpoint + "/" + name + "%2C" + name + "/_search",
+		idx + "/_search?q=" + url.QueryEscape("id:x AND id:x") + "&size=1",
+	} {
+		req, _ := http.NewRequest("GET", u, nil)
+		DoSuccess(t, c, req)
+	}
+}
+
+// TestOpenSearchLargeBody ensures that a multi-megabyte bulk request is signed correctly.
+func TestOpenSearchLargeBody(t *testing.T) {
+	endpoint := Endpoint(t, EnvOpenSearchEndpoint)
+	c := Client(t, "es")
+	idx := withIndex(t, c, endpoint)
+
+	var b bytes.Buffer
+	for i := 0; b.Len() < 5<<20; i++ {
+		fmt.Fprintf(&b, "{\"index\":{\"_id\":\"%d\"}}\n{\"payload\":\"%s\"}\n", i, strings.Repeat("x", 1024))
+	}
+	req, _ := http.NewRequest("POST", idx+"/_bulk", &b)
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	DoSuccess(t, c, req)
+}
+
+//      _    ____ ___    ____       _
+//     / \  |  _ \_ _|  / ___| __ _| |_ _____      ____ _ _   _
+//    / _ \ | |_) | |  | |  _ / _` | __/ _ \ \ /\ / / _` | | | |
+//   / ___ \|  __/| |  | |_| | (_| | ||  __/\ V  V / (_| | |_| |
+//  /_/   \_\_|  |___|  \____|\__,_|\__\___| \_/\_/ \__,_|\__, |
+//                                                        |___/
+
+// TestAPIGatewayEscaping ensures that API Gateway accepts signatures over paths and queries that need escaping.
+func TestAPIGatewayEscaping(t *testing.T) {
+	endpoint := Endpoint(t, EnvAPIGatewayEndpoint)
+	c := Client(t, "execute-api")
+	for _, p := range []string{"/plain", "/with%20space", "/plus+sign", "/comma%2Cpath", "/star*tilde~", "/%C3%BCn%C3%AFc%C3%B8d%C3%A9"} {
+		req, _ := http.NewRequest("GET", endpoint+p+"?a=1&b=two%20words&c=%2B", nil)
+		DoSuccess(t, c, req)
+	}
+}
+
+// TestAPIGatewayLargeBody ensures that a large POST body is signed correctly.
+func TestAPIGatewayLargeBody(t *testing.T) {
+	endpoint := Endpoint(t, EnvAPIGatewayEndpoint)
+	req, _ := http.NewRequest("POST", endpoint+"/large", bytes.NewReader(bytes.Repeat([]byte("x"), 5<<20)))
+	req.Header.Set("Content-Type", "application/octet-stream")
+	DoSuccess(t, Client(t, "execute-api"), req)
+}
+
+// TestAPIGatewayWebSocketHandshake ensures that a WebSocket $connect handshake is signed and accepted, and that an
+// unsigned one is rejected.
+func TestAPIGatewayWebSocketHandshake(t *testing.T) {
+	endpoint := Endpoint(t, EnvWebSocketEndpoint)
+	for _, tc := range []struct {
+		name   string
+		opts   []aws_signing_client.Option
+		status int
+	}{
+		{"signed", nil, http.StatusSwitchingProtocols},
+		{"unsigned", []aws_signing_client.Option{aws_signing_client.WithUnsignedUpgrades()}, http.StatusForbidden},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			key := make([]byte, 16)
+			rand.Read(key)
+
+			req, _ := http.NewRequest("GET", endpoint, nil)
+			req.Header.Set("Connection", "Upgrade")
+			req.Header.Set("Upgrade", "websocket")
+			req.Header.Set("Sec-WebSocket-Version", "13")
+			req.Header.Set("Sec-WebSocket-Key", hex.EncodeToString(key)[:22]+"==")
+			resp, err := Client(t, "execute-api", tc.opts...).Do(req)
+			if err != nil {
+				t.Fatalf("WebSocket handshake failed: %s", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tc.status {
+				t.Fatalf("Expected %d, got %s", tc.status, resp.Status)
+			}
+		})
+	}
+}
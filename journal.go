@@ -0,0 +1,246 @@
+package aws_signing_client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JournalBufferSize is the number of journal records that wait to be written before further records are dropped.
+const JournalBufferSize = 4096
+
+type (
+	// JournalRecord is the compact record written by WithJournal for every signed request, enough to tell after the
+	// fact what was sent with which credentials without keeping the request itself.
+	JournalRecord struct {
+		Time        time.Time `json:"time"`
+		AccessKeyID string    `json:"access_key_id"`
+		Method      string    `json:"method"`
+		URL         string    `json:"url"`
+		// SignedHeaders are the names of the signed headers, separated by semicolons as in the Authorization header.
+		SignedHeaders string `json:"signed_headers"`
+		// HeadersDigest is the hex-encoded SHA-256 of the canonical headers block of the signed headers, so that
+		// headers can be matched against the record without it holding their values, such as session tokens.
+		HeadersDigest string `json:"headers_digest"`
+		// PayloadHash is the payload hash the request was signed with.
+		PayloadHash string `json:"payload_hash"`
+		StatusCode  int    `json:"status,omitempty"`
+		Error       string `json:"error,omitempty"`
+	}
+
+	// JournalSink stores journal records. WriteRecords is called from a single goroutine, with records in the order
+	// their requests completed.
+	JournalSink interface {
+		WriteRecords(ctx context.Context, records []JournalRecord) error
+	}
+
+	// FileJournalSink is a JournalSink that appends one JSON-encoded JournalRecord per line to a file.
+	FileJournalSink struct {
+		f *os.File
+	}
+
+	// KinesisJournalSink is a JournalSink that puts each JournalRecord, JSON-encoded, to a Kinesis data stream
+	// with the request's host as its partition key.
+	KinesisJournalSink struct {
+		Client *KinesisClient
+		Stream string
+	}
+
+	// CloudWatchLogsJournalSink is a JournalSink that writes each JournalRecord, JSON-encoded, as an event of a
+	// CloudWatch Logs log stream, which must exist.
+	CloudWatchLogsJournalSink struct {
+		// Client is the HTTP client used to send requests, typically derived with Client.WithScope for the "logs"
+		// service from the client the sink journals, and set before it sends requests. If nil, http.DefaultClient
+		// is used.
+		Client *http.Client
+		// URL is the CloudWatch Logs endpoint, e.g. "https://logs.us-east-1.amazonaws.com".
+		URL       string
+		LogGroup  string
+		LogStream string
+	}
+
+	// journal hands records to the sinks on a background goroutine, so that a slow sink never delays requests.
+	journal struct {
+		sinks   []JournalSink
+		logger  func(ctx context.Context, format string, v ...interface{})
+		mu      sync.RWMutex // guards closed against records being sent on a closed channel
+		closed  bool
+		records chan JournalRecord
+		done    chan struct{}
+	}
+
+	journalKey struct{}
+)
+
+// WithJournal writes a JournalRecord for every signed request, including each retried attempt, to sinks in batches
+// from a background goroutine. Signer.Close writes the waiting records and closes the sinks.
+func WithJournal(sinks ...JournalSink) Option {
+	return func(s *Signer) {
+		j := &journal{
+			sinks:   sinks,
+			logger:  s.logf,
+			records: make(chan JournalRecord, JournalBufferSize),
+			done:    make(chan struct{}),
+		}
+		go j.run()
+		s.journal = j
+		s.closers = append(s.closers, j)
+	}
+}
+
+// NewFileJournalSink returns a FileJournalSink appending to the file at path, which is created if it does not exist.
+func NewFileJournalSink(path string) (*FileJournalSink, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileJournalSink{f: f}, nil
+}
+
+// journalRequest adds the record of req, signed and sent with the payload p, to the journal.
+func (s *Signer) journalRequest(ctx context.Context, req *http.Request, p *payload, timing RequestTiming) {
+	if ctx.Value(journalKey{}) != nil {
+		return
+	}
+	signed := signedHeaderNames(req)
+	digest := sha256.Sum256([]byte(canonicalHeaders(req, signed)))
+	rec := JournalRecord{
+		Time:          timing.Start.UTC(),
+		AccessKeyID:   timing.AccessKeyID,
+		Method:        req.Method,
+		URL:           req.URL.String(),
+		SignedHeaders: strings.Join(signed, ";"),
+		HeadersDigest: hex.EncodeToString(digest[:]),
+		PayloadHash:   signedPayloadHash(req, p.bytes()),
+		StatusCode:    timing.StatusCode,
+	}
+	if timing.Err != nil {
+		rec.Error = timing.Err.Error()
+	}
+
+	s.journal.mu.RLock()
+	defer s.journal.mu.RUnlock()
+	if s.journal.closed {
+		return
+	}
+	select {
+	case s.journal.records <- rec:
+	default:
+		s.logf(ctx, "Journal buffer is full. Dropping record of %s %s.", rec.Method, rec.URL)
+	}
+}
+
+// run writes the records in batches of what is waiting, up to MaxRecordsPerRequest, until the journal is closed.
+func (j *journal) run() {
+	defer close(j.done)
+	ctx := context.WithValue(context.Background(), journalKey{}, true)
+	for rec := range j.records {
+		batch := []JournalRecord{rec}
+	fill:
+		for len(batch) < MaxRecordsPerRequest {
+			select {
+			case rec, ok := <-j.records:
+				if !ok {
+					break fill
+				}
+				batch = append(batch, rec)
+			default:
+				break fill
+			}
+		}
+		for _, sink := range j.sinks {
+			if err := sink.WriteRecords(ctx, batch); err != nil {
+				j.logger(ctx, "Error while attempting to write %d journal records: '%s'", len(batch), err)
+			}
+		}
+	}
+}
+
+// Close writes the waiting records and closes the sinks that implement io.Closer. It implements io.Closer.
+func (j *journal) Close() error {
+	j.mu.Lock()
+	if !j.closed {
+		j.closed = true
+		close(j.records)
+	}
+	j.mu.Unlock()
+	<-j.done
+
+	var err error
+	for _, sink := range j.sinks {
+		if c, ok := sink.(io.Closer); ok {
+			if cerr := c.Close(); cerr != nil && err == nil {
+				err = cerr
+			}
+		}
+	}
+	return err
+}
+
+// WriteRecords implements JournalSink.
+func (fs *FileJournalSink) WriteRecords(ctx context.Context, records []JournalRecord) error {
+	var buf []byte
+	for _, rec := range records {
+		d, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		buf = append(append(buf, d...), '\n')
+	}
+	_, err := fs.f.Write(buf)
+	return err
+}
+
+// Close closes the file. It implements io.Closer.
+func (fs *FileJournalSink) Close() error {
+	return fs.f.Close()
+}
+
+// WriteRecords implements JournalSink.
+func (ks *KinesisJournalSink) WriteRecords(ctx context.Context, records []JournalRecord) error {
+	batch := make([]Record, 0, len(records))
+	for _, rec := range records {
+		d, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		key := rec.URL
+		if u, err := url.Parse(rec.URL); err == nil {
+			key = u.Host
+		}
+		batch = append(batch, Record{Data: d, PartitionKey: key})
+	}
+	return ks.Client.PutRecords(ctx, ks.Stream, batch)
+}
+
+// WriteRecords implements JournalSink. The events are sorted by time, as PutLogEvents requires.
+func (cs *CloudWatchLogsJournalSink) WriteRecords(ctx context.Context, records []JournalRecord) error {
+	type logEvent struct {
+		Timestamp int64  `json:"timestamp"`
+		Message   string `json:"message"`
+	}
+	in := struct {
+		LogGroupName  string     `json:"logGroupName"`
+		LogStreamName string     `json:"logStreamName"`
+		LogEvents     []logEvent `json:"logEvents"`
+	}{LogGroupName: cs.LogGroup, LogStreamName: cs.LogStream}
+	for _, rec := range records {
+		d, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		in.LogEvents = append(in.LogEvents, logEvent{Timestamp: rec.Time.UnixNano() / int64(time.Millisecond), Message: string(d)})
+	}
+	sort.SliceStable(in.LogEvents, func(i, k int) bool { return in.LogEvents[i].Timestamp < in.LogEvents[k].Timestamp })
+	var out struct{}
+	return doJSON11(ctx, cs.Client, cs.URL, "Logs_20140328.PutLogEvents", in, &out)
+}
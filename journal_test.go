@@ -0,0 +1,116 @@
+package aws_signing_client
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// TestWithJournal ensures that a record of every signed request is written to the sinks, including one sending
+// through the same client, whose own requests are not journaled.
+func TestWithJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	file, err := NewFileJournalSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cw := &CloudWatchLogsJournalSink{URL: "https://logs.us-east-1.amazonaws.com", LogGroup: "g", LogStream: "s"}
+
+	var mu sync.Mutex
+	var events []string
+	c, err := NewClient(v4.NewSigner(creds), &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("X-Amz-Target") == "Logs_20140328.PutLogEvents" {
+			var in struct {
+				LogEvents []struct{ Message string } `json:"logEvents"`
+			}
+			d, _ := ioutil.ReadAll(req.Body)
+			json.Unmarshal(d, &in)
+			mu.Lock()
+			for _, e := range in.LogEvents {
+				events = append(events, e.Message)
+			}
+			mu.Unlock()
+			return response(200, "{}", nil), nil
+		}
+		return response(201, "", nil), nil
+	})}, "es", "us-east-1", nil, WithJournal(file, cw))
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+
+	logs, _ := c.WithScope("logs", "us-east-1")
+	cw.Client = logs.HTTPClient()
+
+	for _, body := range []string{"", `{"query":{}}`} {
+		req, _ := http.NewRequest(http.MethodPost, "https://search.example.com/_search?q=1", strings.NewReader(body))
+		if _, err := c.Do(req); err != nil {
+			t.Fatalf("An unexpected error occurred: %s", err)
+		}
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("An unexpected error occurred while closing: %s", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var records []JournalRecord
+	for sc := bufio.NewScanner(f); sc.Scan(); {
+		var rec JournalRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			t.Fatalf("Invalid journal record %q: %s", sc.Text(), err)
+		}
+		records = append(records, rec)
+	}
+	if len(records) != 2 || len(events) != 2 {
+		t.Fatalf("Expected 2 records in each sink, got %d and %d", len(records), len(events))
+	}
+	for i, body := range []string{"", `{"query":{}}`} {
+		rec := records[i]
+		sum := sha256.Sum256([]byte(body))
+		switch {
+		case rec.URL != "https://search.example.com/_search?q=1" || rec.Method != "POST" || rec.StatusCode != 201 || rec.AccessKeyID != "ID":
+			t.Errorf("Unexpected record: %+v", rec)
+		case !strings.Contains(rec.SignedHeaders, "host;x-amz-date") || len(rec.HeadersDigest) != 64:
+			t.Errorf("Unexpected signed headers: %+v", rec)
+		case rec.PayloadHash != hex.EncodeToString(sum[:]):
+			t.Errorf("Unexpected payload hash: %s", rec.PayloadHash)
+		}
+	}
+}
+
+// TestJournalFailedNew ensures that the journal and the hash workers are stopped, and the sinks closed, when New
+// fails after the options were applied.
+func TestJournalFailedNew(t *testing.T) {
+	file, err := NewFileJournalSink(filepath.Join(t.TempDir(), "journal.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s *Signer
+	_, err = New(v4.NewSigner(creds), &http.Client{Transport: roundTripFunc(nil)}, "es", "us-east-1", nil,
+		WithJournal(file), WithHashWorkers(2), WithRequireTLSVerification(), func(signer *Signer) { s = signer })
+	if err == nil {
+		t.Fatal("Expected New to fail for an unverifiable transport")
+	}
+	if _, err := file.f.Write([]byte("{}\n")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Expected the file sink to be closed, got %v", err)
+	}
+	select {
+	case <-s.hashPool.quit:
+	default:
+		t.Error("Expected the hash workers to be stopped")
+	}
+}
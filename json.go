@@ -0,0 +1,112 @@
+package aws_signing_client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+type (
+	// JSONClient sends and receives JSON documents over an *http.Client, typically one returned by New, so that
+	// every request is signed before sending. Use it with DoJSON.
+	JSONClient struct {
+		// Client is the HTTP client used to send requests. If nil, http.DefaultClient is used.
+		Client *http.Client
+		// Header holds headers that are added to every request before it is signed.
+		Header http.Header
+	}
+
+	// NoBody may be used as the request type for DoJSON when no request body should be sent, e.g. for GET and
+	// DELETE requests.
+	NoBody struct{}
+)
+
+// NewJSONClient returns a JSONClient that sends requests using the provided client.
+func NewJSONClient(client *http.Client) *JSONClient {
+	return &JSONClient{Client: client, Header: http.Header{}}
+}
+
+// DoJSON marshals body as JSON, sends it to url with the provided method using jc, and unmarshals a 2xx response
+// into a TResp. An empty response body leaves the returned TResp as its zero value. Non-2xx responses are returned
+// as an *AWSError. If TReq is NoBody or body is a nil interface, no request body is sent.
+func DoJSON[TReq, TResp any](ctx context.Context, jc *JSONClient, method, url string, body TReq) (TResp, error) {
+	var out TResp
+	resp, d, err := jc.send(ctx, method, url, nil, body)
+	if err != nil {
+		return out, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return out, responseError(resp, d)
+	}
+	return out, decodeJSON(method, url, d, &out)
+}
+
+// send marshals body as JSON and sends it to url with the provided method and any extra headers, returning the
+// response with its body read into memory.
+func (jc *JSONClient) send(ctx context.Context, method, url string, header http.Header, body interface{}) (*http.Response, []byte, error) {
+	var r io.Reader
+	if !isNoBody(body) {
+		d, err := json.Marshal(body)
+		if err != nil {
+			return nil, nil, err
+		}
+		r = bytes.NewReader(d)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, r)
+	if err != nil {
+		return nil, nil, err
+	}
+	for k, v := range jc.Header {
+		req.Header[k] = append([]string(nil), v...)
+	}
+	for k, v := range header {
+		req.Header[k] = append([]string(nil), v...)
+	}
+	if r != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := jc.httpClient().Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	d, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, d, nil
+}
+
+// decodeJSON unmarshals the response body d into out. An empty body leaves out unchanged.
+func decodeJSON(method, url string, d []byte, out interface{}) error {
+	if len(bytes.TrimSpace(d)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(d, out); err != nil {
+		return fmt.Errorf("decoding %s %s response: %w", method, url, err)
+	}
+	return nil
+}
+
+func (jc *JSONClient) httpClient() *http.Client {
+	if jc.Client == nil {
+		return http.DefaultClient
+	}
+	return jc.Client
+}
+
+func isNoBody(body interface{}) bool {
+	switch body.(type) {
+	case nil, NoBody, *NoBody:
+		return true
+	}
+	return false
+}
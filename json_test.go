@@ -0,0 +1,125 @@
+package aws_signing_client
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func response(status int, body string, header http.Header) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     header,
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
+}
+
+// newTestClient returns a client for es in us-east-1 that sends requests to rt, and its Signer.
+func newTestClient(t *testing.T, rt roundTripFunc, opts ...Option) (*http.Client, *Signer) {
+	t.Helper()
+	c, err := New(v4.NewSigner(creds), &http.Client{Transport: rt}, "es", "us-east-1", nil, opts...)
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+	return c, c.Transport.(*Signer)
+}
+
+func jsonClient(t *testing.T, f roundTripFunc) *JSONClient {
+	c, _ := newTestClient(t, f)
+	return NewJSONClient(c)
+}
+
+type doc struct {
+	Name string `json:"name"`
+}
+
+//   ____        _  ____   ___  _   _
+//  |  _ \  ___ | |/ ___| / _ \| \ | |
+//  | | | |/ _ \| |\___ \| | | |  \| |
+//  | |_| | (_) | |___) | |_| | |\  |
+//  |____/ \___/|_|____/ \___/|_| \_|
+//
+
+// TestDoJSONRoundTrip ensures that the request body is marshalled and signed and that the response is unmarshalled.
+func TestDoJSONRoundTrip(t *testing.T) {
+	jc := jsonClient(t, func(req *http.Request) (*http.Response, error) {
+		d, _ := ioutil.ReadAll(req.Body)
+		switch {
+		case string(d) != `{"name":"in"}`:
+			t.Errorf("Unexpected request body: %s", d)
+		case req.Header.Get("Content-Type") != "application/json":
+			t.Error("Content-Type header was not set to application/json")
+		case !strings.HasPrefix(req.Header.Get("Authorization"), "AWS4-HMAC-SHA256 "):
+			t.Error("Request was not signed")
+		}
+		return response(200, `{"name":"out"}`, nil), nil
+	})
+	out, err := DoJSON[doc, doc](context.Background(), jc, "POST", "https://example.com/idx/_doc", doc{Name: "in"})
+	switch {
+	case err != nil:
+		t.Errorf("An unexpected error occurred: %s", err)
+	case out.Name != "out":
+		t.Errorf("Unexpected response document: %+v", out)
+	}
+}
+
+// TestDoJSONNoBody ensures that NoBody requests are sent without a body or Content-Type.
+func TestDoJSONNoBody(t *testing.T) {
+	jc := jsonClient(t, func(req *http.Request) (*http.Response, error) {
+		if req.Body != nil && req.Body != http.NoBody {
+			t.Error("A body was sent for a NoBody request")
+		}
+		if req.Header.Get("Content-Type") != "" {
+			t.Error("Content-Type header was set for a NoBody request")
+		}
+		return response(204, "", nil), nil
+	})
+	if _, err := DoJSON[NoBody, doc](context.Background(), jc, "GET", "https://example.com/", NoBody{}); err != nil {
+		t.Errorf("An unexpected error occurred: %s", err)
+	}
+}
+
+// TestDoJSONErrors ensures that the common AWS error body formats are parsed into an *AWSError.
+func TestDoJSONErrors(t *testing.T) {
+	cases := []struct {
+		body, header, code, message string
+	}{
+		{`{"__type":"com.amazonaws.es#ValidationException","message":"bad"}`, "", "ValidationException", "bad"},
+		{`{"Message":"User: anonymous is not authorized"}`, "AccessDeniedException:http://internal", "AccessDeniedException", "User: anonymous is not authorized"},
+		{`{"error":{"type":"index_not_found_exception","reason":"no such index"},"status":404}`, "", "index_not_found_exception", "no such index"},
+		{`not json`, "", "", ""},
+	}
+	for _, c := range cases {
+		jc := jsonClient(t, func(req *http.Request) (*http.Response, error) {
+			h := http.Header{"X-Amzn-Requestid": {"req-1"}}
+			if c.header != "" {
+				h.Set("X-Amzn-Errortype", c.header)
+			}
+			return response(400, c.body, h), nil
+		})
+		_, err := DoJSON[NoBody, doc](context.Background(), jc, "GET", "https://example.com/", NoBody{})
+		var awsErr *AWSError
+		switch {
+		case !errors.As(err, &awsErr):
+			t.Errorf("Error was not of type *AWSError: %v", err)
+		case awsErr.Code != c.code || awsErr.Message != c.message:
+			t.Errorf("Unexpected code/message for %s: %q, %q", c.body, awsErr.Code, awsErr.Message)
+		case awsErr.RequestID != "req-1" || string(awsErr.Body) != c.body:
+			t.Errorf("Request ID or body was not preserved: %+v", awsErr)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package aws_signing_client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type (
+	// KeyUsage is a Hooks implementation that counts the requests signed with each access key ID, e.g. so that
+	// key-rotation automation can verify that an old key has drained before revoking it. Every signed attempt is
+	// counted, including retries; requests sent unsigned are not. Register it with WithHooks.
+	KeyUsage struct {
+		NopHooks
+		mu   sync.Mutex
+		keys map[string]KeyStats
+	}
+
+	// KeyStats is the usage of an access key ID recorded by KeyUsage.
+	KeyStats struct {
+		// Requests is the number of requests signed with the key.
+		Requests int64
+		// LastUsed is the time the most recent of them was signed at.
+		LastUsed time.Time
+	}
+)
+
+// NewKeyUsage returns an empty KeyUsage.
+func NewKeyUsage() *KeyUsage {
+	return &KeyUsage{keys: map[string]KeyStats{}}
+}
+
+// OnRequest implements Hooks.
+func (ku *KeyUsage) OnRequest(ctx context.Context, timing RequestTiming) {
+	if timing.AccessKeyID == "" {
+		return
+	}
+	ku.mu.Lock()
+	defer ku.mu.Unlock()
+	ks := ku.keys[timing.AccessKeyID]
+	ks.Requests++
+	if timing.Start.After(ks.LastUsed) {
+		ks.LastUsed = timing.Start
+	}
+	ku.keys[timing.AccessKeyID] = ks
+}
+
+// Stats returns a snapshot of the usage of every access key ID that has signed a request.
+func (ku *KeyUsage) Stats() map[string]KeyStats {
+	ku.mu.Lock()
+	defer ku.mu.Unlock()
+	stats := make(map[string]KeyStats, len(ku.keys))
+	for k, ks := range ku.keys {
+		stats[k] = ks
+	}
+	return stats
+}
+
+// Drained reports whether no request has been signed with accessKeyID for at least quiet, including if it never
+// signed one.
+func (ku *KeyUsage) Drained(accessKeyID string, quiet time.Duration) bool {
+	ku.mu.Lock()
+	defer ku.mu.Unlock()
+	ks, ok := ku.keys[accessKeyID]
+	return !ok || time.Since(ks.LastUsed) >= quiet
+}
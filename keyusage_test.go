@@ -0,0 +1,49 @@
+package aws_signing_client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+//   _  __
+//  | |/ /___ _   _ ___
+//  | ' // _ \ | | / __|
+//  | . \  __/ |_| \__ \
+//  |_|\_\___|\__, |___/
+//            |___/
+
+// TestKeyUsage ensures that signed requests are counted per access key ID across a credential rotation.
+func TestKeyUsage(t *testing.T) {
+	ku := NewKeyUsage()
+	c, s := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		return response(200, "", nil), nil
+	}, WithHooks(ku))
+	get := func() {
+		if _, err := c.Get("https://example.com/"); err != nil {
+			t.Fatalf("An unexpected error occurred while making a request: %s", err)
+		}
+	}
+
+	get()
+	get()
+	if err := s.SetCredentials(&credentials.StaticProvider{Value: credentials.Value{AccessKeyID: "NEWID", SecretAccessKey: "SECRET"}}); err != nil {
+		t.Fatalf("An unexpected error occurred while rotating credentials: %s", err)
+	}
+	get()
+
+	stats := ku.Stats()
+	if stats["ID"].Requests != 2 || stats["NEWID"].Requests != 1 || len(stats) != 2 {
+		t.Errorf("Unexpected key usage: %+v", stats)
+	}
+	switch {
+	case ku.Drained("ID", time.Hour):
+		t.Error("Expected the old key not to be drained within an hour")
+	case !ku.Drained("ID", 0):
+		t.Error("Expected the old key to be drained without a quiet period")
+	case !ku.Drained("OTHER", time.Hour):
+		t.Error("Expected an unused key to be drained")
+	}
+}
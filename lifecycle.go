@@ -0,0 +1,134 @@
+package aws_signing_client
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// MaxDrainSize is the number of bytes of a response body DrainAndClose reads before giving up on reusing the
+// connection.
+const MaxDrainSize = 256 << 10
+
+// CloseIdleConnections closes any idle connections of the wrapped transport and of the transports registered with
+// WithHostTransport, if they support doing so. Since the Signer implements this method,
+// (*http.Client).CloseIdleConnections reaches through it to the wrapped transports.
+func (s *Signer) CloseIdleConnections() {
+	for _, rt := range s.transports() {
+		if t, ok := rt.(interface{ CloseIdleConnections() }); ok {
+			t.CloseIdleConnections()
+		}
+	}
+}
+
+// Close closes idle connections and releases the resources held by the Signer: the wrapped transports, the logger
+// and Hooks are closed if they implement io.Closer, as is every io.Closer registered with WithCloser. Close is safe to
+// call more than once; later calls return the result of the first. The Signer must not be used after Close.
+func (s *Signer) Close() error {
+	s.closeOnce.Do(func() {
+		s.CloseIdleConnections()
+		closers := append([]io.Closer{}, s.closers...)
+		for _, rt := range s.transports() {
+			if c, ok := rt.(io.Closer); ok {
+				closers = append(closers, c)
+			}
+		}
+		if c, ok := s.logger.(io.Closer); ok {
+			closers = append(closers, c)
+		}
+		for _, h := range s.hooks {
+			if c, ok := h.(io.Closer); ok {
+				closers = append(closers, c)
+			}
+		}
+		for _, c := range closers {
+			if err := c.Close(); err != nil && s.closeErr == nil {
+				s.closeErr = err
+			}
+		}
+	})
+	return s.closeErr
+}
+
+// ShutdownError is an implementation of the error interface that is returned by RoundTrip for requests made after
+// Shutdown was called.
+type ShutdownError struct{}
+
+// Shutdown makes new requests fail with a ShutdownError and closes the Signer as Close does once the requests in
+// flight have returned their response headers. If ctx is done first, it returns ctx.Err() and the Signer is closed in
+// the background.
+func (s *Signer) Shutdown(ctx context.Context) error {
+	s.lifeMu.Lock()
+	s.shuttingDown = true
+	if s.inFlight > 0 && s.drained == nil {
+		s.drained = make(chan struct{})
+	}
+	drained := s.drained
+	s.lifeMu.Unlock()
+
+	closed := make(chan struct{})
+	go func() {
+		if drained != nil {
+			<-drained
+		}
+		s.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		return s.closeErr
+	case <-ctx.Done():
+		s.logf(ctx, "Shutdown did not complete before its context was done: %s", ctx.Err())
+		return ctx.Err()
+	}
+}
+
+// startRequest registers a request as in flight, or returns a ShutdownError if Shutdown was called. The requests
+// of journal sinks are accepted until the Signer is closed.
+func (s *Signer) startRequest(ctx context.Context) error {
+	s.lifeMu.Lock()
+	defer s.lifeMu.Unlock()
+	if s.shuttingDown && ctx.Value(journalKey{}) == nil {
+		return ShutdownError{}
+	}
+	s.inFlight++
+	return nil
+}
+
+// endRequest unregisters a request registered with startRequest.
+func (s *Signer) endRequest() {
+	s.lifeMu.Lock()
+	defer s.lifeMu.Unlock()
+	s.inFlight--
+	if s.inFlight == 0 && s.drained != nil {
+		close(s.drained)
+		s.drained = nil
+	}
+}
+
+// Error implements the error interface.
+func (err ShutdownError) Error() string {
+	return "The Signer is shutting down. Refusing to send request."
+}
+
+// WithCloser registers an io.Closer, such as a metrics flusher, to be closed when the Signer is closed, or by New if
+// it fails.
+func WithCloser(c io.Closer) Option {
+	return func(s *Signer) {
+		s.closers = append(s.closers, c)
+	}
+}
+
+// DrainAndClose reads what is left of the body of resp, up to MaxDrainSize bytes, and closes it, so that the
+// connection it arrived on goes back to the idle pool rather than being torn down. Call it for every response that is
+// discarded without reading it, e.g. when retrying. Bodies larger than MaxDrainSize are closed without reading them
+// to the end, which closes their connection. It returns the error of closing the body, and does nothing if resp is
+// nil.
+func DrainAndClose(resp *http.Response) error {
+	if resp == nil || resp.Body == nil {
+		return nil
+	}
+	io.CopyN(ioutil.Discard, resp.Body, MaxDrainSize)
+	return resp.Body.Close()
+}
@@ -0,0 +1,191 @@
+package aws_signing_client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+type closingTransport struct {
+	roundTripFunc
+	idleClosed, closed int
+}
+
+func (ct *closingTransport) CloseIdleConnections() {
+	ct.idleClosed++
+}
+
+func (ct *closingTransport) Close() error {
+	ct.closed++
+	return nil
+}
+
+type closingLogger struct {
+	closed int
+}
+
+func (cl *closingLogger) Printf(ctx context.Context, format string, v ...interface{}) {}
+
+func (cl *closingLogger) Close() error {
+	cl.closed++
+	return errors.New("logger close failed")
+}
+
+//   _     _  __                      _
+//  | |   (_)/ _| ___  ___ _   _  ___| | ___
+//  | |   | | |_ / _ \/ __| | | |/ __| |/ _ \
+//  | |___| |  _|  __/ (__| |_| | (__| |  __/
+//  |_____|_|_|  \___|\___|\__, |\___|_|\___|
+//                         |___/
+
+// TestCloseIdleConnections ensures that closing idle connections on the client reaches the wrapped transport.
+func TestCloseIdleConnections(t *testing.T) {
+	ct := &closingTransport{}
+	c, _ := New(v4.NewSigner(creds), &http.Client{Transport: ct}, "es", "us-east-1", nil)
+	c.CloseIdleConnections()
+	if ct.idleClosed != 1 {
+		t.Error("CloseIdleConnections was not forwarded to the wrapped transport")
+	}
+}
+
+// TestClose ensures that Close() releases the transport, logger and registered closers exactly once.
+func TestClose(t *testing.T) {
+	ct := &closingTransport{}
+	cl := &closingLogger{}
+	extra := &closingTransport{}
+	c, _ := New(v4.NewSigner(creds), &http.Client{Transport: ct}, "es", "us-east-1", cl, WithCloser(extra))
+	s := c.Transport.(*Signer)
+
+	err := s.Close()
+	s.Close()
+	switch {
+	case err == nil || err.Error() != "logger close failed":
+		t.Errorf("Close did not return the logger's error: %v", err)
+	case ct.idleClosed != 1 || ct.closed != 1:
+		t.Errorf("Transport was not closed exactly once: %d idle, %d closed", ct.idleClosed, ct.closed)
+	case cl.closed != 1 || extra.closed != 1:
+		t.Errorf("Logger or registered closer was not closed exactly once: %d, %d", cl.closed, extra.closed)
+	}
+}
+
+// TestDrainAndClose ensures that drained responses leave their connection reusable.
+func TestDrainAndClose(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, strings.Repeat("x", 64<<10))
+	}))
+	defer srv.Close()
+
+	c, err := New(v4.NewSigner(creds), srv.Client(), "es", "us-east-1", nil, WithConnectionMetrics())
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+	for i := 0; i < 3; i++ {
+		resp, err := c.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("An unexpected error occurred while making a request: %s", err)
+		}
+		if err := DrainAndClose(resp); err != nil {
+			t.Errorf("An unexpected error occurred while draining: %s", err)
+		}
+	}
+	if ps := c.Transport.(*Signer).PoolStats(); ps.Opened != 1 || ps.Reused != 2 {
+		t.Errorf("Expected one connection to be reused, got %+v", ps)
+	}
+
+	closed := 0
+	DrainAndClose(&http.Response{Body: closeCounter{ioutil.NopCloser(strings.NewReader("abc")), &closed}})
+	if closed != 1 || DrainAndClose(nil) != nil {
+		t.Errorf("Expected the body to be closed once, got %d", closed)
+	}
+}
+
+// TestShutdown ensures that Shutdown refuses new requests and waits for the requests in flight before closing.
+func TestShutdown(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/slow" {
+			close(started)
+			<-release
+		}
+		return response(http.StatusOK, "", nil), nil
+	})
+	closer := &closingLogger{}
+	c, err := New(v4.NewSigner(creds), &http.Client{Transport: rt}, "es", "us-east-1", nil, WithCloser(closer))
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+	s := c.Transport.(*Signer)
+
+	sent := make(chan error, 1)
+	go func() {
+		resp, err := c.Get("https://example.com/slow")
+		if err == nil {
+			resp.Body.Close()
+		}
+		sent <- err
+	}()
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- s.Shutdown(context.Background()) }()
+	for {
+		s.lifeMu.Lock()
+		shuttingDown := s.shuttingDown
+		s.lifeMu.Unlock()
+		if shuttingDown {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := c.Get("https://example.com/"); !errors.As(err, &ShutdownError{}) {
+		t.Errorf("Expected a ShutdownError, got %v", err)
+	}
+	select {
+	case err := <-shutdown:
+		t.Fatalf("Expected Shutdown to wait for the request in flight, got %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-sent; err != nil {
+		t.Errorf("An unexpected error occurred while making a request: %s", err)
+	}
+	if err := <-shutdown; err == nil || err.Error() != "logger close failed" {
+		t.Errorf("Expected the error of the closer, got %v", err)
+	}
+	if closer.closed != 1 {
+		t.Errorf("Expected the closer to be closed once, got %d", closer.closed)
+	}
+}
+
+// TestShutdownDeadline ensures that Shutdown gives up waiting when its context is done.
+func TestShutdownDeadline(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		close(started)
+		<-release
+		return response(http.StatusOK, "", nil), nil
+	})
+	c, err := New(v4.NewSigner(creds), &http.Client{Transport: rt}, "es", "us-east-1", nil)
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+	defer close(release)
+	go c.Get("https://example.com/")
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := c.Transport.(*Signer).Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected the deadline to be exceeded, got %v", err)
+	}
+}
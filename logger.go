@@ -0,0 +1,20 @@
+package aws_signing_client
+
+import (
+	"context"
+)
+
+type requestLoggerKey struct{}
+
+// WithRequestLogger returns a copy of ctx that makes the Signer log a request made with it to logger instead of
+// the logger the client was created with, e.g. to route the logs of a tenant's requests to that tenant's log
+// stream.
+func WithRequestLogger(ctx context.Context, logger ContextLogger) context.Context {
+	return context.WithValue(ctx, requestLoggerKey{}, logger)
+}
+
+// requestLogger returns the logger attached to ctx with WithRequestLogger, or nil.
+func requestLogger(ctx context.Context) ContextLogger {
+	logger, _ := ctx.Value(requestLoggerKey{}).(ContextLogger)
+	return logger
+}
@@ -0,0 +1,43 @@
+package aws_signing_client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+//   _
+//  | |    ___   __ _  __ _  ___ _ __
+//  | |   / _ \ / _` |/ _` |/ _ \ '__|
+//  | |__| (_) | (_| | (_| |  __/ |
+//  |_____\___/ \__, |\__, |\___|_|
+//              |___/ |___/
+
+// TestWithRequestLogger ensures that a request's logs go to the logger attached to its context, and only those.
+func TestWithRequestLogger(t *testing.T) {
+	global, tenant := &recordingLogger{}, &recordingLogger{}
+	c, err := New(v4.NewSigner(creds), &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return response(200, "", nil), nil
+	})}, "es", "us-east-1", global)
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+
+	req, _ := http.NewRequestWithContext(WithRequestLogger(context.Background(), tenant), "GET", "https://example.com/", nil)
+	if _, err := c.Do(req); err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err)
+	}
+	if len(tenant.lines) == 0 || len(global.lines) != 0 {
+		t.Errorf("Expected the logs to go to the request logger only, got %d and %d lines", len(tenant.lines), len(global.lines))
+	}
+
+	if _, err := c.Get("https://example.com/"); err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err)
+	}
+	if len(global.lines) == 0 || !strings.Contains(strings.Join(global.lines, "\n"), "Signing succesful") {
+		t.Errorf("Expected requests without a request logger to use the client's logger, got %q", global.lines)
+	}
+}
@@ -0,0 +1,137 @@
+package aws_signing_client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	// DefaultMirrorTimeout is how long a mirrored request may take when Mirror.Timeout is zero.
+	DefaultMirrorTimeout = 30 * time.Second
+	// DefaultMirrorMaxInFlight is the number of mirrored requests that may be in flight at once when
+	// Mirror.MaxInFlight is zero. Requests that would exceed it are not mirrored.
+	DefaultMirrorMaxInFlight = 64
+)
+
+type (
+	// Mirror is a second destination that a share of the client's requests is duplicated to, for WithMirror.
+	Mirror struct {
+		// Host is the host, with an optional port, mirrored requests are sent to, e.g.
+		// "abc123.us-east-1.aoss.amazonaws.com".
+		Host string
+		// Service and Region are what mirrored requests are signed for, e.g. "aoss". Empty values keep those of
+		// the client.
+		Service string
+		Region  string
+		// Percent is the share of requests that are mirrored, from 0 to 100.
+		Percent float64
+		// Timeout bounds each mirrored request. It defaults to DefaultMirrorTimeout.
+		Timeout time.Duration
+		// MaxInFlight bounds the number of mirrored requests in flight. It defaults to DefaultMirrorMaxInFlight.
+		MaxInFlight int
+	}
+
+	// mirror is a Mirror with its in-flight slots.
+	mirror struct {
+		Mirror
+		slots chan struct{}
+	}
+)
+
+// WithMirror duplicates Percent percent of the client's requests to a second destination, signed for its own service
+// and region with the client's credentials, e.g. to validate a migration from Amazon Elasticsearch Service to
+// OpenSearch Serverless with production traffic. Mirrored requests are sent asynchronously, after the original
+// request's body has been read and before it is sent; their responses and errors are logged and discarded, and
+// they are neither retried nor reported to Hooks. Requests whose body is not held in memory, see WithBodySpooling,
+// are not mirrored. New fails with a *DisallowedHostError if Host is outside WithAllowedHosts. It may be given more
+// than once.
+func WithMirror(m Mirror) Option {
+	return func(s *Signer) {
+		if m.Timeout == 0 {
+			m.Timeout = DefaultMirrorTimeout
+		}
+		if m.MaxInFlight == 0 {
+			m.MaxInFlight = DefaultMirrorMaxInFlight
+		}
+		s.mirrors = append(s.mirrors, &mirror{Mirror: m, slots: make(chan struct{}, m.MaxInFlight)})
+	}
+}
+
+// checkMirrors returns a *DisallowedHostError if the host of a mirror is outside WithAllowedHosts.
+func (s *Signer) checkMirrors() error {
+	for _, m := range s.mirrors {
+		if host := (&url.URL{Host: m.Host}).Hostname(); !s.hostAllowed(host) {
+			return &DisallowedHostError{Host: host}
+		}
+	}
+	return nil
+}
+
+// mirror sends copies of req, whose buffered body is p, to the destinations selected for it.
+func (s *Signer) mirror(ctx context.Context, req *http.Request, sc *signingScope, p *payload) {
+	if p != nil && p.file != nil {
+		return
+	}
+	for _, m := range s.mirrors {
+		if rand.Float64()*100 >= m.Percent {
+			continue
+		}
+		select {
+		case m.slots <- struct{}{}:
+		default:
+			s.logf(ctx, "Too many mirrored requests in flight. Not mirroring request to '%s'.", m.Host)
+			continue
+		}
+		if err := s.startRequest(ctx); err != nil {
+			<-m.slots
+			return
+		}
+		mreq := req.Clone(context.Background())
+		mreq.URL.Host, mreq.Host = m.Host, ""
+		go func(m *mirror) {
+			defer func() { <-m.slots }()
+			defer s.endRequest()
+			s.sendMirror(ctx, mreq, sc, p.bytes(), m)
+		}(m)
+	}
+}
+
+// sendMirror signs and sends a mirrored request, discarding the response.
+func (s *Signer) sendMirror(ctx context.Context, req *http.Request, sc *signingScope, d []byte, m *mirror) {
+	mctx, cancel := context.WithTimeout(context.Background(), m.Timeout)
+	defer cancel()
+	req = req.WithContext(mctx)
+	name, region := s.signingValues(sc)
+	if m.Service != "" {
+		name = m.Service
+	}
+	if m.Region != "" {
+		region = m.Region
+	}
+
+	var body io.ReadSeeker
+	if len(d) > 0 {
+		req.Body, req.ContentLength = ioutil.NopCloser(bytes.NewReader(d)), int64(len(d))
+		body = bytes.NewReader(d)
+	} else if d != nil {
+		req.Body, req.ContentLength = http.NoBody, 0
+	}
+	_, err := sc.signer.Sign(req, body, name, region, time.Now())
+	if err != nil {
+		s.logf(ctx, "Error while attempting to sign mirrored request to '%s': '%s'", m.Host, err)
+		return
+	}
+	resp, err := s.transportFor(req.URL.Hostname()).RoundTrip(req)
+	if err != nil {
+		s.logf(ctx, "Error from mirrored request to '%s': %s", m.Host, err)
+		return
+	}
+	s.logf(ctx, "Mirrored request to '%s' returned status %d.", m.Host, resp.StatusCode)
+	DrainAndClose(resp)
+}
@@ -0,0 +1,83 @@
+package aws_signing_client
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// TestWithMirror ensures that mirrored requests are signed for the mirror's scope and sent asynchronously, without
+// delaying or canceling the original request.
+func TestWithMirror(t *testing.T) {
+	type sentRequest struct {
+		host, auth, body string
+	}
+	sent := make(chan sentRequest, 4)
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		d, _ := ioutil.ReadAll(req.Body)
+		sent <- sentRequest{host: req.URL.Host, auth: req.Header.Get("Authorization"), body: string(d)}
+		if req.URL.Host == "mirror.example.com" {
+			// The mirrored request outlives the original request's context.
+			select {
+			case <-req.Context().Done():
+				t.Error("The mirrored request was canceled")
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+		return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+	}, WithMirror(Mirror{Host: "mirror.example.com", Service: "aoss", Region: "eu-west-1", Percent: 100}),
+		WithMirror(Mirror{Host: "never.example.com", Percent: 0}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, "POST", "https://search.example.com/idx/_doc", strings.NewReader(`{"a":1}`))
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while making a request: %s", err)
+	}
+	resp.Body.Close()
+	cancel()
+
+	got := map[string]sentRequest{}
+	for i := 0; i < 2; i++ {
+		select {
+		case r := <-sent:
+			got[r.host] = r
+		case <-time.After(time.Second):
+			t.Fatal("The mirrored request was not sent")
+		}
+	}
+	original, mirrored := got["search.example.com"], got["mirror.example.com"]
+	switch {
+	case !strings.Contains(original.auth, "/us-east-1/es/aws4_request"):
+		t.Errorf("Unexpected signature of the original request: %s", original.auth)
+	case !strings.Contains(mirrored.auth, "/eu-west-1/aoss/aws4_request"):
+		t.Errorf("Unexpected signature of the mirrored request: %s", mirrored.auth)
+	case original.body != `{"a":1}` || mirrored.body != original.body:
+		t.Errorf("Unexpected bodies: %q and %q", original.body, mirrored.body)
+	}
+	select {
+	case r := <-sent:
+		t.Errorf("Unexpected request to %s", r.host)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+// TestMirrorDisallowedHost ensures that a mirror outside the allowed hosts is refused when the client is created.
+func TestMirrorDisallowedHost(t *testing.T) {
+	_, err := New(v4.NewSigner(creds), &http.Client{}, "es", "us-east-1", nil, WithAllowedHosts("*.es.amazonaws.com"),
+		WithMirror(Mirror{Host: "evil.example.com:443", Percent: 100}))
+	var dhe *DisallowedHostError
+	if !errors.As(err, &dhe) || dhe.Host != "evil.example.com" {
+		t.Errorf("Expected a *DisallowedHostError for the mirror, got %v", err)
+	}
+	if _, err := New(v4.NewSigner(creds), &http.Client{}, "es", "us-east-1", nil, WithAllowedHosts("*.aoss.amazonaws.com"),
+		WithMirror(Mirror{Host: "abc.us-east-1.aoss.amazonaws.com", Percent: 100})); err != nil {
+		t.Errorf("An unexpected error occurred for an allowed mirror: %s", err)
+	}
+}
@@ -0,0 +1,28 @@
+package aws_signing_client
+
+import (
+	"io"
+	"net/http"
+	"time"
+)
+
+// DefaultNoopHeader is the header stamped by a NoopSigner with no Header set.
+const DefaultNoopHeader = "X-Signing-Client-Noop"
+
+// NoopSigner is a RequestSigner that does not sign requests. It stamps a marker header naming the service and
+// region instead, so the same client wiring (NewWithRequestSigner, options and hooks) can be used against
+// unauthenticated local mocks and switched to a real signer through configuration.
+type NoopSigner struct {
+	// Header is the marker header to set. If empty, DefaultNoopHeader is used.
+	Header string
+}
+
+// Sign stamps the marker header on r, consuming nothing from body. It implements RequestSigner.
+func (n NoopSigner) Sign(r *http.Request, body io.ReadSeeker, service, region string, signTime time.Time) (http.Header, error) {
+	h := n.Header
+	if h == "" {
+		h = DefaultNoopHeader
+	}
+	r.Header.Set(h, service+"/"+region)
+	return http.Header{h: r.Header[http.CanonicalHeaderKey(h)]}, nil
+}
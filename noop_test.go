@@ -0,0 +1,34 @@
+package aws_signing_client
+
+import (
+	"net/http"
+	"testing"
+)
+
+//   _   _
+//  | \ | | ___   ___  _ __
+//  |  \| |/ _ \ / _ \| '_ \
+//  | |\  | (_) | (_) | |_) |
+//  |_| \_|\___/ \___/| .__/
+//                    |_|
+
+// TestNoopSigner ensures that the NoopSigner stamps its marker header without signing.
+func TestNoopSigner(t *testing.T) {
+	var sent *http.Request
+	c, err := NewWithRequestSigner(NoopSigner{}, &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent = req
+		return response(200, "", nil), nil
+	})}, "es", "us-east-1", nil)
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+	if _, err := c.Get("https://localhost:9200/"); err != nil {
+		t.Fatalf("An unexpected error occurred while making a request: %s", err)
+	}
+	switch {
+	case sent.Header.Get(DefaultNoopHeader) != "es/us-east-1":
+		t.Errorf("Marker header was not stamped: %v", sent.Header)
+	case sent.Header.Get("Authorization") != "" || sent.Header.Get("X-Amz-Date") != "":
+		t.Error("Request was signed by the NoopSigner")
+	}
+}
@@ -0,0 +1,357 @@
+package aws_signing_client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QueueOrdering selects how an OfflineQueue handles a request that cannot be sent yet.
+type QueueOrdering int
+
+const (
+	// OrderStrict sends queued requests in the order they were enqueued and stops a flush at the first request that
+	// cannot be sent yet, so that a request never overtakes an earlier one. This is the default.
+	OrderStrict QueueOrdering = iota
+	// OrderBestEffort sends every queued request it can, leaving those that cannot be sent yet for the next flush.
+	OrderBestEffort
+)
+
+// staleCodes are the error codes of requests rejected because of expired credentials or a skewed clock, which an
+// OfflineQueue keeps for a later flush.
+var staleCodes = map[string]bool{
+	"ExpiredToken":          true,
+	"ExpiredTokenException": true,
+	"RequestExpired":        true,
+	"RequestTimeTooSkewed":  true,
+}
+
+// Reasons an OfflineQueue drops a request, as passed to OfflineQueue.OnDrop.
+const (
+	DropExpired  = "expired"
+	DropOverflow = "overflow"
+	DropRejected = "rejected"
+)
+
+type (
+	// OfflineQueue stores requests while connectivity is unavailable and sends them when it returns, for edge
+	// deployments with flaky links. Requests are stored unsigned and signed when they are sent by Flush, through an
+	// *http.Client typically returned by New, so that signatures cannot expire while requests wait. A request is
+	// removed once it gets a 2xx response, or dropped if it is rejected with an error that is not retryable (see
+	// IsRetryable). Connection errors, retryable errors and errors caused by stale credentials or a skewed clock,
+	// such as ExpiredToken or RequestTimeTooSkewed, leave it queued. Requests can be enqueued while a flush sends.
+	OfflineQueue struct {
+		// Client is the HTTP client used to send requests. If nil, http.DefaultClient is used.
+		Client *http.Client
+		// Store holds the queued requests. If nil, they are kept in memory and lost when the process exits.
+		Store QueueStore
+		// Ordering decides what a flush does with a request that cannot be sent yet.
+		Ordering QueueOrdering
+		// MaxAge is how long a request is kept before it is dropped as DropExpired. Zero keeps requests forever.
+		MaxAge time.Duration
+		// MaxRequests is the number of requests kept; when it is exceeded, the oldest are dropped as DropOverflow.
+		// Zero means no limit.
+		MaxRequests int
+		// OnDrop, if set, is called with every request that is dropped and the reason it was dropped.
+		OnDrop func(r *QueuedRequest, reason string)
+
+		mu      sync.Mutex // serializes changes to the store
+		flushMu sync.Mutex // serializes flushes
+		seq     uint64
+	}
+
+	// QueuedRequest is a request stored by an OfflineQueue.
+	QueuedRequest struct {
+		// ID orders the requests of a queue: a request enqueued later has a greater ID.
+		ID       string      `json:"id"`
+		Method   string      `json:"method"`
+		URL      string      `json:"url"`
+		Header   http.Header `json:"header,omitempty"`
+		Body     []byte      `json:"body,omitempty"`
+		Enqueued time.Time   `json:"enqueued"`
+	}
+
+	// QueueStore persists the requests of an OfflineQueue. Implementations must be safe for concurrent use.
+	QueueStore interface {
+		// Put stores r.
+		Put(r *QueuedRequest) error
+		// List returns the stored requests ordered by ID.
+		List() ([]*QueuedRequest, error)
+		// Delete removes the request with the given ID. Deleting a request that is not stored is not an error.
+		Delete(id string) error
+	}
+
+	// FlushResult counts what a flush of an OfflineQueue did.
+	FlushResult struct {
+		Sent    int
+		Dropped int
+		// Remaining is the number of requests still queued.
+		Remaining int
+	}
+
+	// FileQueueStore is a QueueStore that keeps each request in a JSON file of a directory, so that queued requests
+	// survive restarts.
+	FileQueueStore struct {
+		dir string
+	}
+
+	memoryQueueStore struct {
+		mu       sync.Mutex
+		requests []*QueuedRequest
+	}
+)
+
+// NewOfflineQueue returns an OfflineQueue that sends requests using the provided client and stores them in store.
+func NewOfflineQueue(client *http.Client, store QueueStore) *OfflineQueue {
+	return &OfflineQueue{Client: client, Store: store}
+}
+
+// Enqueue reads req, including its body, and stores it to be sent by a later flush. Signature headers it may carry
+// are removed, as it is signed when it is sent.
+func (q *OfflineQueue) Enqueue(req *http.Request) error {
+	r := &QueuedRequest{
+		Method:   req.Method,
+		URL:      req.URL.String(),
+		Header:   req.Header.Clone(),
+		Enqueued: time.Now(),
+	}
+	for _, h := range []string{"Authorization", "X-Amz-Date", "X-Amz-Security-Token", "X-Amz-Content-Sha256"} {
+		r.Header.Del(h)
+	}
+	if req.Body != nil {
+		d, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return err
+		}
+		r.Body = d
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.seq++
+	r.ID = fmt.Sprintf("%019d-%06d", r.Enqueued.UnixNano(), q.seq%1000000)
+	if err := q.store().Put(r); err != nil {
+		return err
+	}
+	if q.MaxRequests > 0 {
+		requests, err := q.store().List()
+		if err != nil {
+			return err
+		}
+		for _, old := range requests[:max(len(requests)-q.MaxRequests, 0)] {
+			q.drop(q.store(), old, DropOverflow)
+		}
+	}
+	return nil
+}
+
+// Flush sends the requests queued when it is called, in order. It returns the error of the request that stopped it,
+// if any; with OrderBestEffort, the error of the last request that could not be sent.
+func (q *OfflineQueue) Flush(ctx context.Context) (FlushResult, error) {
+	q.flushMu.Lock()
+	defer q.flushMu.Unlock()
+	q.mu.Lock()
+	store := q.store()
+	requests, err := store.List()
+	q.mu.Unlock()
+	if err != nil {
+		return FlushResult{}, err
+	}
+
+	var res FlushResult
+	var lastErr error
+	for i, r := range requests {
+		if q.MaxAge > 0 && time.Since(r.Enqueued) > q.MaxAge {
+			q.drop(store, r, DropExpired)
+			res.Dropped++
+			continue
+		}
+		if ctx.Err() != nil {
+			res.Remaining += len(requests) - i
+			return res, ctx.Err()
+		}
+		resp, awsErr, err := q.send(ctx, r)
+		switch {
+		case err == nil && awsErr == nil:
+			if err := store.Delete(r.ID); err != nil {
+				return res, err
+			}
+			res.Sent++
+			continue
+		case err == nil && !IsRetryable(nil, awsErr) && !staleCodes[awsErr.Code]:
+			q.drop(store, r, DropRejected)
+			res.Dropped++
+			continue
+		case err == nil:
+			err = fmt.Errorf("%s %s: %s", r.Method, r.URL, resp.Status)
+		}
+		lastErr = err
+		if q.Ordering == OrderStrict {
+			res.Remaining += len(requests) - i
+			return res, err
+		}
+		res.Remaining++
+	}
+	return res, lastErr
+}
+
+// Run flushes the queue every interval until ctx is done, and returns ctx.Err().
+func (q *OfflineQueue) Run(ctx context.Context, interval time.Duration) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		q.Flush(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// send signs and sends a queued request. It returns the error parsed from the response if its status is not 2xx.
+func (q *OfflineQueue) send(ctx context.Context, r *QueuedRequest) (*http.Response, *AWSError, error) {
+	req, err := http.NewRequestWithContext(ctx, r.Method, r.URL, bytes.NewReader(r.Body))
+	if err != nil {
+		return nil, nil, err
+	}
+	if r.Body == nil {
+		req.Body, req.ContentLength = nil, 0
+	}
+	for k, v := range r.Header {
+		req.Header[k] = v
+	}
+	client := q.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	var awsErr *AWSError
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		d, _ := ioutil.ReadAll(io.LimitReader(resp.Body, MaxErrorBodySize))
+		awsErr = responseError(resp, d)
+	}
+	DrainAndClose(resp)
+	return resp, awsErr, nil
+}
+
+// drop removes r from store and reports it to OnDrop.
+func (q *OfflineQueue) drop(store QueueStore, r *QueuedRequest, reason string) {
+	store.Delete(r.ID)
+	if q.OnDrop != nil {
+		q.OnDrop(r, reason)
+	}
+}
+
+// store returns the Store of q, creating an in-memory one on first use if it is nil. The caller must hold mu.
+func (q *OfflineQueue) store() QueueStore {
+	if q.Store == nil {
+		q.Store = &memoryQueueStore{}
+	}
+	return q.Store
+}
+
+// NewFileQueueStore returns a FileQueueStore that keeps requests in dir, which is created if it does not exist.
+func NewFileQueueStore(dir string) (*FileQueueStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileQueueStore{dir: dir}, nil
+}
+
+// Put implements QueueStore. The file is written under a temporary name and renamed, so that a crash never leaves a
+// partial request behind.
+func (fs *FileQueueStore) Put(r *QueuedRequest) error {
+	d, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(fs.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(d)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(fs.dir, r.ID+".json"))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// List implements QueueStore.
+func (fs *FileQueueStore) List() ([]*QueuedRequest, error) {
+	entries, err := os.ReadDir(fs.dir)
+	if err != nil {
+		return nil, err
+	}
+	var requests []*QueuedRequest
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		d, err := os.ReadFile(filepath.Join(fs.dir, e.Name()))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		r := &QueuedRequest{}
+		if err := json.Unmarshal(d, r); err != nil {
+			return nil, fmt.Errorf("decoding queued request %s: %w", e.Name(), err)
+		}
+		requests = append(requests, r)
+	}
+	return requests, nil
+}
+
+// Delete implements QueueStore.
+func (fs *FileQueueStore) Delete(id string) error {
+	if err := os.Remove(filepath.Join(fs.dir, id+".json")); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (ms *memoryQueueStore) Put(r *QueuedRequest) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.requests = append(ms.requests, r)
+	return nil
+}
+
+func (ms *memoryQueueStore) List() ([]*QueuedRequest, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return append([]*QueuedRequest(nil), ms.requests...), nil
+}
+
+func (ms *memoryQueueStore) Delete(id string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	for i, r := range ms.requests {
+		if r.ID == id {
+			ms.requests = append(ms.requests[:i], ms.requests[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
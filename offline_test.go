@@ -0,0 +1,144 @@
+package aws_signing_client
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestOfflineQueue ensures that queued requests survive a restart and are signed and sent in order once connectivity
+// returns.
+func TestOfflineQueue(t *testing.T) {
+	online := false
+	var sent []string
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		if !online {
+			return nil, &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+		}
+		if req.Header.Get("Authorization") == "" {
+			t.Errorf("Request to %s was not signed", req.URL)
+		}
+		body, _ := ioutil.ReadAll(req.Body)
+		sent = append(sent, req.URL.Path+" "+string(body))
+		if req.URL.Path == "/bad" {
+			return response(400, "", nil), nil
+		}
+		return response(200, "", nil), nil
+	})
+	store, err := NewFileQueueStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dropped []string
+	q := NewOfflineQueue(c, store)
+	for i, path := range []string{"/a", "/bad", "/b"} {
+		req, _ := http.NewRequest(http.MethodPost, "https://api.example.com"+path, strings.NewReader(fmt.Sprint(i)))
+		req.Header.Set("Authorization", "AWS4-HMAC-SHA256 stale")
+		if err := q.Enqueue(req); err != nil {
+			t.Fatalf("An unexpected error occurred while enqueueing: %s", err)
+		}
+	}
+	if res, err := q.Flush(context.Background()); err == nil || res != (FlushResult{Remaining: 3}) {
+		t.Errorf("Expected the flush to stop at the first request while offline, got %+v and %v", res, err)
+	}
+
+	online = true
+	q = NewOfflineQueue(c, store)
+	q.OnDrop = func(r *QueuedRequest, reason string) {
+		dropped = append(dropped, r.URL+" "+reason)
+	}
+	res, err := q.Flush(context.Background())
+	if err != nil || res != (FlushResult{Sent: 2, Dropped: 1}) {
+		t.Errorf("Expected every request to be flushed, got %+v and %v", res, err)
+	}
+	if fmt.Sprint(sent) != "[/a 0 /bad 1 /b 2]" {
+		t.Errorf("Unexpected requests: %q", sent)
+	}
+	if fmt.Sprint(dropped) != "[https://api.example.com/bad rejected]" {
+		t.Errorf("Unexpected dropped requests: %q", dropped)
+	}
+	if requests, _ := store.List(); len(requests) != 0 {
+		t.Errorf("Expected the queue to be empty, got %d requests", len(requests))
+	}
+}
+
+// TestOfflineQueueRetention ensures that old requests and those beyond MaxRequests are dropped.
+func TestOfflineQueueRetention(t *testing.T) {
+	var sent []string
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		sent = append(sent, req.URL.Path)
+		return response(200, "", nil), nil
+	})
+
+	var dropped []string
+	q := &OfflineQueue{Client: c, MaxRequests: 2, MaxAge: time.Hour, OnDrop: func(r *QueuedRequest, reason string) {
+		dropped = append(dropped, r.URL+" "+reason)
+	}}
+	for _, path := range []string{"/a", "/b", "/c"} {
+		req, _ := http.NewRequest(http.MethodGet, "https://api.example.com"+path, nil)
+		q.Enqueue(req)
+	}
+	requests, _ := q.Store.List()
+	requests[0].Enqueued = time.Now().Add(-2 * time.Hour)
+
+	res, err := q.Flush(context.Background())
+	if err != nil || res != (FlushResult{Sent: 1, Dropped: 1}) {
+		t.Errorf("Unexpected flush result %+v and %v", res, err)
+	}
+	if fmt.Sprint(sent) != "[/c]" {
+		t.Errorf("Unexpected requests: %q", sent)
+	}
+	if fmt.Sprint(dropped) != "[https://api.example.com/a overflow https://api.example.com/b expired]" {
+		t.Errorf("Unexpected dropped requests: %q", dropped)
+	}
+}
+
+// TestOfflineQueueKeepsRecoverable ensures that throttled requests and requests rejected for stale credentials or a
+// skewed clock stay queued, and that requests can be enqueued while a flush is sending.
+func TestOfflineQueueKeepsRecoverable(t *testing.T) {
+	var q *OfflineQueue
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		switch req.URL.Path {
+		case "/expired":
+			return response(403, `{"__type":"ExpiredTokenException","message":"The security token included in the request is expired"}`, nil), nil
+		case "/skewed":
+			return response(403, `<Error><Code>RequestTimeTooSkewed</Code></Error>`, nil), nil
+		case "/throttled":
+			return response(400, `{"__type":"ThrottlingException","message":"Rate exceeded"}`, nil), nil
+		}
+		enqueued := make(chan error)
+		go func() {
+			req, _ := http.NewRequest(http.MethodGet, "https://api.example.com/later", nil)
+			enqueued <- q.Enqueue(req)
+		}()
+		select {
+		case err := <-enqueued:
+			if err != nil {
+				t.Errorf("An unexpected error occurred while enqueueing: %s", err)
+			}
+		case <-time.After(time.Second):
+			t.Error("Enqueue was blocked by the flush")
+		}
+		return response(200, "", nil), nil
+	})
+
+	q = &OfflineQueue{Client: c, Ordering: OrderBestEffort}
+	for _, path := range []string{"/expired", "/skewed", "/throttled", "/ok"} {
+		req, _ := http.NewRequest(http.MethodGet, "https://api.example.com"+path, nil)
+		q.Enqueue(req)
+	}
+	res, err := q.Flush(context.Background())
+	if err == nil || res != (FlushResult{Sent: 1, Remaining: 3}) {
+		t.Errorf("Expected the recoverable requests to stay queued, got %+v and %v", res, err)
+	}
+	if requests, _ := q.Store.List(); len(requests) != 4 {
+		t.Errorf("Expected 4 queued requests, got %d", len(requests))
+	}
+}
@@ -0,0 +1,42 @@
+package aws_signing_client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+// PanicError is an implementation of the error interface that is returned by RoundTrip in place of a panic when
+// WithPanicRecovery is enabled.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value interface{}
+	// Stack is the stack trace of the goroutine at the time of the panic.
+	Stack []byte
+}
+
+// WithPanicRecovery makes RoundTrip recover from panics raised while signing or sending a request, including in the
+// RequestSigner, hooks and the wrapped transport. The panic is logged with its stack trace and returned as a
+// *PanicError instead of crashing the goroutine.
+func WithPanicRecovery() Option {
+	return func(s *Signer) {
+		s.recoverPanics = true
+	}
+}
+
+// recoverPanic must be deferred directly by RoundTrip.
+func (s *Signer) recoverPanic(ctx context.Context, resp **http.Response, err *error) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	perr := &PanicError{Value: v, Stack: debug.Stack()}
+	s.logf(ctx, "Recovered from panic while handling request: %v\n%s", v, perr.Stack)
+	*resp, *err = nil, perr
+}
+
+// Error implements the error interface.
+func (err *PanicError) Error() string {
+	return fmt.Sprintf("Panic while signing or sending request: %v", err.Value)
+}
@@ -0,0 +1,44 @@
+package aws_signing_client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type panickingSigner struct{}
+
+func (panickingSigner) Sign(r *http.Request, body io.ReadSeeker, service, region string, signTime time.Time) (http.Header, error) {
+	panic("signer exploded")
+}
+
+//   ____             _
+//  |  _ \ __ _ _ __ (_) ___
+//  | |_) / _` | '_ \| |/ __|
+//  |  __/ (_| | | | | | (__
+//  |_|   \__,_|_| |_|_|\___|
+//
+
+// TestPanicRecovery ensures that a panic in the signer is returned as a *PanicError.
+func TestPanicRecovery(t *testing.T) {
+	c, err := NewWithRequestSigner(panickingSigner{}, &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return response(200, "", nil), nil
+	})}, "es", "us-east-1", nil, WithPanicRecovery())
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+
+	_, err = c.Get("https://example.com/")
+	var perr *PanicError
+	switch {
+	case !errors.As(err, &perr):
+		t.Fatalf("Error was not of type *PanicError: %v", err)
+	case perr.Value != "signer exploded":
+		t.Errorf("Unexpected panic value: %v", perr.Value)
+	case !strings.Contains(string(perr.Stack), "panickingSigner"):
+		t.Error("Stack trace does not include the panicking function")
+	}
+}
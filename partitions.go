@@ -0,0 +1,108 @@
+package aws_signing_client
+
+import (
+	"strings"
+)
+
+// Partition is a group of AWS regions that share a DNS suffix and an identity namespace, such as the commercial
+// regions, AWS GovCloud (US) or the China regions. Credentials of one partition are not valid in another.
+type Partition struct {
+	// ID is the partition's identifier, as found in ARNs, e.g. "aws-us-gov".
+	ID string
+	// DNSSuffix is the suffix of the partition's endpoints, e.g. "amazonaws.com.cn".
+	DNSSuffix string
+	// RegionPrefixes are the prefixes of the names of the partition's regions, e.g. "cn-".
+	RegionPrefixes []string
+	// GlobalSigningRegions are the regions requests to the global endpoints of the partition are signed for, by
+	// signing name.
+	GlobalSigningRegions map[string]string
+	// SigningNames are the signing names of services that sign under a different name in this partition than
+	// their service name, by service name.
+	SigningNames map[string]string
+}
+
+var (
+	// PartitionAWS is the partition of the commercial regions. Regions that match no other partition belong to it.
+	PartitionAWS = Partition{ID: "aws", DNSSuffix: "amazonaws.com", GlobalSigningRegions: globalSigningRegions}
+	// PartitionAWSCN is the partition of the China regions.
+	PartitionAWSCN = Partition{
+		ID:             "aws-cn",
+		DNSSuffix:      "amazonaws.com.cn",
+		RegionPrefixes: []string{"cn-"},
+		GlobalSigningRegions: map[string]string{
+			"iam":           "cn-north-1",
+			"organizations": "cn-northwest-1",
+			"route53":       "cn-northwest-1",
+		},
+	}
+	// PartitionAWSUSGov is the partition of the AWS GovCloud (US) regions.
+	PartitionAWSUSGov = Partition{
+		ID:             "aws-us-gov",
+		DNSSuffix:      "amazonaws.com",
+		RegionPrefixes: []string{"us-gov-"},
+		GlobalSigningRegions: map[string]string{
+			"iam":           "us-gov-west-1",
+			"organizations": "us-gov-west-1",
+			"route53":       "us-gov-west-1",
+		},
+	}
+	// PartitionAWSISO and PartitionAWSISOB are the partitions of the US ISO regions.
+	PartitionAWSISO  = Partition{ID: "aws-iso", DNSSuffix: "c2s.ic.gov", RegionPrefixes: []string{"us-iso-"}}
+	PartitionAWSISOB = Partition{ID: "aws-iso-b", DNSSuffix: "sc2s.sgov.gov", RegionPrefixes: []string{"us-isob-"}}
+)
+
+// WithPartitions adds partitions the client's region is looked up in before the built-in ones, e.g. to add a
+// partition or to change the signing names or global signing regions of a built-in one by giving it again with the
+// same region prefixes. The partition of the region decides the DNS suffix of the endpoints built by the client, such
+// as the STS endpoint of VerifyCredentials, as well as the signing name and global signing region of requests.
+func WithPartitions(partitions ...Partition) Option {
+	return func(s *Signer) {
+		s.partitions = append(s.partitions, partitions...)
+	}
+}
+
+// PartitionForRegion returns the built-in partition region belongs to, or PartitionAWS if it matches none.
+func PartitionForRegion(region string) Partition {
+	p, _ := matchPartition(region, []Partition{PartitionAWSCN, PartitionAWSUSGov, PartitionAWSISOB, PartitionAWSISO})
+	return p
+}
+
+// Host returns the host of the regional endpoint of service in region, e.g. "sts.cn-north-1.amazonaws.com.cn".
+func (p Partition) Host(service, region string) string {
+	return service + "." + region + "." + p.DNSSuffix
+}
+
+// SigningName returns the name requests to service are signed under in the partition.
+func (p Partition) SigningName(service string) string {
+	if name, ok := p.SigningNames[service]; ok {
+		return name
+	}
+	return service
+}
+
+// GlobalSigningRegion is like the function of the same name, for the global endpoints of the partition.
+func (p Partition) GlobalSigningRegion(name string) (region string, ok bool) {
+	region, ok = p.GlobalSigningRegions[name]
+	return region, ok
+}
+
+// partition returns the partition region belongs to, looking it up in the partitions added with WithPartitions
+// first.
+func (s *Signer) partition(region string) Partition {
+	if p, ok := matchPartition(region, s.partitions); ok {
+		return p
+	}
+	return PartitionForRegion(region)
+}
+
+// matchPartition returns the first of partitions with a region prefix of region, or PartitionAWS.
+func matchPartition(region string, partitions []Partition) (Partition, bool) {
+	for _, p := range partitions {
+		for _, prefix := range p.RegionPrefixes {
+			if strings.HasPrefix(region, prefix) {
+				return p, true
+			}
+		}
+	}
+	return PartitionAWS, false
+}
@@ -0,0 +1,54 @@
+package aws_signing_client
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// TestPartitionForRegion tests the partitions and endpoints of regions.
+func TestPartitionForRegion(t *testing.T) {
+	for region, host := range map[string]string{
+		"us-east-1":      "sts.us-east-1.amazonaws.com",
+		"us-gov-west-1":  "sts.us-gov-west-1.amazonaws.com",
+		"cn-northwest-1": "sts.cn-northwest-1.amazonaws.com.cn",
+		"us-iso-east-1":  "sts.us-iso-east-1.c2s.ic.gov",
+		"us-isob-east-1": "sts.us-isob-east-1.sc2s.sgov.gov",
+	} {
+		if got := PartitionForRegion(region).Host("sts", region); got != host {
+			t.Errorf("%s: expected %s, got %s", region, host, got)
+		}
+	}
+	if got := PrometheusWorkspaceURL("cn-north-1", "ws-1"); got != "https://aps-workspaces.cn-north-1.amazonaws.com.cn/workspaces/ws-1" {
+		t.Errorf("Unexpected workspace URL: %s", got)
+	}
+}
+
+// TestPartitionSigning ensures that global services are signed for the global signing region of the client's
+// partition, and that WithPartitions changes the signing names and endpoints of a partition.
+func TestPartitionSigning(t *testing.T) {
+	for region, scope := range map[string]string{
+		"us-west-2":      "/us-east-1/iam/",
+		"us-gov-east-1":  "/us-gov-west-1/iam/",
+		"cn-northwest-1": "/cn-north-1/iam/",
+	} {
+		c, s, sent := scopeClient(t)
+		s.SetScope("iam", region)
+		c.Get("https://iam.example.com/")
+		if !strings.Contains(sent().Header.Get("Authorization"), scope) {
+			t.Errorf("%s: expected a request signed for %s, got %s", region, scope, sent().Header.Get("Authorization"))
+		}
+	}
+
+	custom := Partition{ID: "aws-eusc", DNSSuffix: "amazonaws.eu", RegionPrefixes: []string{"eusc-"}, SigningNames: map[string]string{"es": "es-eusc"}}
+	c, s, sent := scopeClient(t, WithPartitions(custom))
+	s.SetScope("es", "eusc-de-east-1")
+	c.Get("https://search.example.com/")
+	if !strings.Contains(sent().Header.Get("Authorization"), "/eusc-de-east-1/es-eusc/") {
+		t.Errorf("Expected the partition's signing name to be used, got %s", sent().Header.Get("Authorization"))
+	}
+	s.VerifyCredentials(context.Background())
+	if sent().URL.Host != "sts.eusc-de-east-1.amazonaws.eu" {
+		t.Errorf("Expected STS to be called in the partition, got %s", sent().URL.Host)
+	}
+}
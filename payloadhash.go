@@ -0,0 +1,141 @@
+package aws_signing_client
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type (
+	// PayloadHasher supplies the hex-encoded SHA-256 hash of request bodies the Signer signs, e.g. from a cache or
+	// with a hardware-accelerated implementation. Implementations must be safe for concurrent use and must not
+	// modify or retain body.
+	PayloadHasher interface {
+		HashPayload(ctx context.Context, body []byte) (string, error)
+	}
+
+	// PayloadHasherFunc is a function that implements PayloadHasher.
+	PayloadHasherFunc func(ctx context.Context, body []byte) (string, error)
+
+	// PayloadHashCache is a PayloadHasher that remembers the hashes of up to a fixed number of payloads by the
+	// content key given with WithPayloadKey, evicting the least recently used, so that a payload sent many times,
+	// e.g. to several destinations, is hashed once. Payloads without a key are hashed every time.
+	PayloadHashCache struct {
+		// Hasher computes the hashes that are not cached. If nil, they are computed with crypto/sha256.
+		Hasher PayloadHasher
+
+		mu         sync.Mutex
+		maxEntries int
+		entries    map[string]*list.Element
+		lru        *list.List
+	}
+
+	payloadHashEntry struct {
+		key  string
+		hash string
+	}
+
+	payloadHashKey struct{}
+	payloadKeyKey  struct{}
+)
+
+// WithPayloadHasher makes the Signer get the hash of request bodies from h, on the workers of WithHashWorkers if it
+// is used, and send it as the X-Amz-Content-Sha256 header, instead of letting the RequestSigner hash them. Empty
+// bodies and requests that already carry the header are not hashed. The time spent is reported to Hooks as
+// RequestTiming.Hash.
+func WithPayloadHasher(h PayloadHasher) Option {
+	return func(s *Signer) {
+		s.payloadHasher = h
+	}
+}
+
+// WithPayloadHash returns a copy of ctx that signs a request sent with it with hash, the hex-encoded SHA-256 of its
+// body computed beforehand, without hashing the body again, including bodies spooled to disk by WithBodySpooling.
+// The hash is not checked.
+func WithPayloadHash(ctx context.Context, hash string) context.Context {
+	return context.WithValue(ctx, payloadHashKey{}, hash)
+}
+
+// WithPayloadKey returns a copy of ctx that identifies the body of a request sent with it by key for a
+// PayloadHashCache. Requests with the same key must have the same body.
+func WithPayloadKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, payloadKeyKey{}, key)
+}
+
+// NewPayloadHashCache returns a PayloadHashCache that holds the hashes of up to maxEntries payloads.
+func NewPayloadHashCache(maxEntries int) *PayloadHashCache {
+	return &PayloadHashCache{maxEntries: maxEntries, entries: map[string]*list.Element{}, lru: list.New()}
+}
+
+// HashPayload implements PayloadHasher.
+func (f PayloadHasherFunc) HashPayload(ctx context.Context, body []byte) (string, error) {
+	return f(ctx, body)
+}
+
+// HashPayload implements PayloadHasher.
+func (c *PayloadHashCache) HashPayload(ctx context.Context, body []byte) (string, error) {
+	key, ok := ctx.Value(payloadKeyKey{}).(string)
+	if !ok {
+		return hashPayload(ctx, c.Hasher, body)
+	}
+	c.mu.Lock()
+	if el, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(el)
+		c.mu.Unlock()
+		return el.Value.(*payloadHashEntry).hash, nil
+	}
+	c.mu.Unlock()
+
+	hash, err := hashPayload(ctx, c.Hasher, body)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok {
+		c.entries[key] = c.lru.PushFront(&payloadHashEntry{key: key, hash: hash})
+	}
+	for c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*payloadHashEntry).key)
+	}
+	return hash, nil
+}
+
+// precomputeHash sets the X-Amz-Content-Sha256 header of req to the hash of its in-memory payload p, given with
+// WithPayloadHash or computed by the PayloadHasher or the workers of WithHashWorkers, and sets the Hash and QueueWait
+// of timing. It does nothing if the RequestSigner is left to hash p.
+func (s *Signer) precomputeHash(ctx context.Context, req *http.Request, p *payload, timing *RequestTiming) error {
+	if len(p.bytes()) == 0 || req.Header.Get("X-Amz-Content-Sha256") != "" {
+		return nil
+	}
+	hash, ok := ctx.Value(payloadHashKey{}).(string)
+	var err error
+	if !ok && s.hashPool != nil {
+		hash, err = s.hashBody(ctx, p, timing)
+	}
+	if hash == "" && err == nil && s.payloadHasher != nil {
+		start := time.Now()
+		hash, err = s.payloadHasher.HashPayload(ctx, p.data)
+		timing.Hash = time.Since(start)
+	}
+	if err != nil || hash == "" {
+		return err
+	}
+	req.Header.Set("X-Amz-Content-Sha256", hash)
+	return nil
+}
+
+// hashPayload hashes body with h, or with crypto/sha256 if h is nil.
+func hashPayload(ctx context.Context, h PayloadHasher, body []byte) (string, error) {
+	if h != nil {
+		return h.HashPayload(ctx, body)
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
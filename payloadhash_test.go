@@ -0,0 +1,74 @@
+package aws_signing_client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// TestWithPayloadHasher ensures that bodies are signed with the hash given in the context or supplied by the
+// PayloadHasher, and that a PayloadHashCache hashes a payload once per key.
+func TestWithPayloadHasher(t *testing.T) {
+	for _, workers := range []int{0, 2} {
+		var hashed int32
+		cache := NewPayloadHashCache(1)
+		cache.Hasher = PayloadHasherFunc(func(ctx context.Context, body []byte) (string, error) {
+			atomic.AddInt32(&hashed, 1)
+			sum := sha256.Sum256(body)
+			return hex.EncodeToString(sum[:]), nil
+		})
+		c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+			return response(200, "", nil), nil
+		}, WithPayloadHasher(cache), WithHashWorkers(workers))
+
+		for _, tc := range []struct {
+			key, body string
+		}{
+			{"a", "first"},
+			{"a", "first"},
+			{"b", "second"},
+			{"a", "first"},
+		} {
+			req, _ := http.NewRequestWithContext(WithPayloadKey(context.Background(), tc.key), http.MethodPut, "https://example.com/", strings.NewReader(tc.body))
+			if _, err := c.Do(req); err != nil {
+				t.Fatalf("An unexpected error occurred: %s", err)
+			}
+			sum := sha256.Sum256([]byte(tc.body))
+			if got := req.Header.Get("X-Amz-Content-Sha256"); got != hex.EncodeToString(sum[:]) {
+				t.Errorf("Unexpected payload hash for %q: %s", tc.body, got)
+			}
+		}
+		if n := atomic.LoadInt32(&hashed); n != 3 {
+			t.Errorf("Expected the cache to hash 3 payloads, got %d", n)
+		}
+
+		req, _ := http.NewRequestWithContext(WithPayloadHash(context.Background(), "precomputed"), http.MethodPut, "https://example.com/", strings.NewReader("body"))
+		c.Do(req)
+		if got := req.Header.Get("X-Amz-Content-Sha256"); got != "precomputed" || atomic.LoadInt32(&hashed) != 3 {
+			t.Errorf("Expected the precomputed hash to be used without hashing, got %s", got)
+		}
+	}
+}
+
+// TestPayloadHashSpooled ensures that a hash given with WithPayloadHash signs a body spooled to disk.
+func TestPayloadHashSpooled(t *testing.T) {
+	var sent *http.Request
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		sent = req
+		return response(200, "", nil), nil
+	}, WithMaxBufferedBody(8), WithBodySpooling(t.TempDir()))
+	hash := strings.Repeat("ab", 32)
+	req, _ := http.NewRequestWithContext(WithPayloadHash(context.Background(), hash), "PUT", "https://example.com/",
+		ioutil.NopCloser(strings.NewReader("0123456789abcdef")))
+	if _, err := c.Do(req); err != nil {
+		t.Fatalf("An unexpected error occurred while making a request: %s", err)
+	}
+	if got := sent.Header.Get("X-Amz-Content-Sha256"); got != hash {
+		t.Errorf("Expected the given hash to be signed, got %s", got)
+	}
+}
@@ -0,0 +1,63 @@
+package aws_signing_client
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+//   ____                           _   _
+//  |  _ \ _ __ ___  _ __ ___   ___| |_| |__   ___ _   _ ___
+//  | |_) | '__/ _ \| '_ ` _ \ / _ \ __| '_ \ / _ \ | | / __|
+//  |  __/| | | (_) | | | | | |  __/ |_| | | |  __/ |_| \__ \
+//  |_|   |_|  \___/|_| |_| |_|\___|\__|_| |_|\___|\__,_|___/
+//
+
+// TestPrometheusClient ensures that requests are signed for aps and rewritten into the workspace.
+func TestPrometheusClient(t *testing.T) {
+	var paths []string
+	c, err := NewPrometheusClient(v4.NewSigner(creds), &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if !strings.Contains(req.Header.Get("Authorization"), "/us-west-2/aps/aws4_request") {
+			t.Errorf("Request was not signed for aps: %s", req.Header.Get("Authorization"))
+		}
+		paths = append(paths, req.URL.Path)
+		return response(200, `{"status":"success"}`, nil), nil
+	})}, "us-west-2", "ws-1234", nil)
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+
+	base := PrometheusWorkspaceURL("us-west-2", "ws-1234")
+	if base != "https://aps-workspaces.us-west-2.amazonaws.com/workspaces/ws-1234" {
+		t.Errorf("Unexpected workspace URL: %s", base)
+	}
+	host := "https://aps-workspaces.us-west-2.amazonaws.com"
+	for _, u := range []string{
+		base + "/api/v1/query?query=up",
+		host + "/api/v1/query?query=up",
+		host + "/api/datasources/proxy/7/api/v1/labels",
+		host + "/api/datasources/proxy/uid/abc/api/v1/labels",
+	} {
+		if _, err := c.Get(u); err != nil {
+			t.Fatalf("An unexpected error occurred while making a request: %s", err)
+		}
+	}
+	expected := []string{
+		"/workspaces/ws-1234/api/v1/query",
+		"/workspaces/ws-1234/api/v1/query",
+		"/workspaces/ws-1234/api/v1/labels",
+		"/workspaces/ws-1234/api/v1/labels",
+	}
+	if strings.Join(paths, " ") != strings.Join(expected, " ") {
+		t.Errorf("Unexpected paths: %v", paths)
+	}
+}
+
+// TestPrometheusClientMissingWorkspace ensures that a workspace ID is required.
+func TestPrometheusClientMissingWorkspace(t *testing.T) {
+	if _, err := NewPrometheusClient(v4.NewSigner(creds), nil, "us-west-2", "", nil); err != (MissingWorkspaceError{}) {
+		t.Errorf("Expected a MissingWorkspaceError, got %v", err)
+	}
+}
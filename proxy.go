@@ -0,0 +1,43 @@
+package aws_signing_client
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+)
+
+// signatureHeaders are removed from inbound proxy requests so that a stale or foreign signature is never forwarded
+// in place of the one computed for the target endpoint.
+var signatureHeaders = []string{
+	"Authorization",
+	"X-Amz-Date",
+	"X-Amz-Security-Token",
+	"X-Amz-Content-Sha256",
+}
+
+// NewReverseProxy returns an http.Handler that accepts unsigned requests, rewrites them to target, signs them by
+// sending them through transport, and streams the responses back to the caller. transport is typically the
+// Transport of a client returned by New (i.e. a *Signer). If cl is nil, proxy errors are discarded.
+func NewReverseProxy(target *url.URL, transport http.RoundTripper, cl ContextLogger) *httputil.ReverseProxy {
+	if cl == nil {
+		cl = discardLogger()
+	}
+
+	p := httputil.NewSingleHostReverseProxy(target)
+	director := p.Director
+	p.Director = func(req *http.Request) {
+		director(req)
+		// The signature covers the Host header, so it must name the AWS endpoint rather than the proxy.
+		req.Host = target.Host
+		for _, h := range signatureHeaders {
+			req.Header.Del(h)
+		}
+	}
+	p.Transport = transport
+	p.FlushInterval = -1
+	p.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		cl.Printf(req.Context(), "Error while proxying request to '%s': %s", target.Host, err)
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	return p
+}
@@ -0,0 +1,67 @@
+package aws_signing_client
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+func proxyTransport(t *testing.T, f roundTripFunc) http.RoundTripper {
+	c, err := New(v4.NewSigner(creds), &http.Client{Transport: f}, "es", "us-east-1", nil)
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+	return c.Transport
+}
+
+//   ____
+//  |  _ \ _ __ _____  ___   _
+//  | |_) | '__/ _ \ \/ / | | |
+//  |  __/| | | (_) >  <| |_| |
+//  |_|   |_|  \___/_/\_\\__, |
+//                       |___/
+
+// TestReverseProxySignsForTarget ensures that proxied requests are rewritten to the target and freshly signed.
+func TestReverseProxySignsForTarget(t *testing.T) {
+	target, _ := url.Parse("https://search-domain.us-east-1.es.amazonaws.com/base")
+	var out *http.Request
+	p := NewReverseProxy(target, proxyTransport(t, func(req *http.Request) (*http.Response, error) {
+		out = req
+		return response(200, "ok", nil), nil
+	}), nil)
+
+	in := httptest.NewRequest("GET", "http://localhost:9200/_search?q=x", nil)
+	in.Header.Set("Authorization", "Basic Zm9vOmJhcg==")
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, in)
+
+	switch {
+	case w.Code != 200 || w.Body.String() != "ok":
+		t.Errorf("Unexpected proxied response: %d %q", w.Code, w.Body.String())
+	case out.Host != target.Host || out.URL.Host != target.Host:
+		t.Errorf("Request was not rewritten to the target host: %s %s", out.Host, out.URL.Host)
+	case out.URL.Path != "/base/_search" || out.URL.RawQuery != "q=x":
+		t.Errorf("Request path was not joined with the target path: %s", out.URL)
+	case !strings.HasPrefix(out.Header.Get("Authorization"), "AWS4-HMAC-SHA256 "):
+		t.Errorf("Inbound Authorization header was forwarded instead of a signature: %s", out.Header.Get("Authorization"))
+	}
+}
+
+// TestReverseProxyTransportError ensures that transport errors are reported as 502 Bad Gateway.
+func TestReverseProxyTransportError(t *testing.T) {
+	target, _ := url.Parse("https://search-domain.us-east-1.es.amazonaws.com")
+	p := NewReverseProxy(target, proxyTransport(t, func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	}), nil)
+
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, httptest.NewRequest("GET", "http://localhost:9200/", nil))
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("Expected status 502, got %d", w.Code)
+	}
+}
@@ -0,0 +1,77 @@
+package aws_signing_client
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// QueryClient calls AWS query-protocol services, such as SQS, SNS, STS and IAM, over an *http.Client returned by
+// New, so that every call is signed before sending. Parameters are sent as an application/x-www-form-urlencoded body
+// in a fixed order and with RFC 3986 encoding, so that the signed body is the one the service parses.
+type QueryClient struct {
+	// Client is the HTTP client used to send requests. If nil, http.DefaultClient is used.
+	Client *http.Client
+	// URL is the service endpoint, e.g. "https://sns.us-east-1.amazonaws.com/".
+	URL string
+	// Version is the API version sent with every call, e.g. "2010-03-31" for SNS.
+	Version string
+}
+
+// NewQueryClient returns a QueryClient that calls the service at url with API version over client.
+func NewQueryClient(client *http.Client, url, version string) *QueryClient {
+	return &QueryClient{Client: client, URL: url, Version: version}
+}
+
+// Call invokes action with params and unmarshals the XML response, whose root element is <ActionResponse>, into out
+// if it is not nil. Lists and maps are passed with the service's member names, e.g. "Attributes.entry.1.key" or
+// "MessageAttribute.1.Name". Non-2xx responses are returned as an *AWSError.
+func (qc *QueryClient) Call(ctx context.Context, action string, params map[string]string, out interface{}) error {
+	v := url.Values{"Action": {action}}
+	if qc.Version != "" {
+		v.Set("Version", qc.Version)
+	}
+	for k, p := range params {
+		v.Set(k, p)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, qc.URL, strings.NewReader(EncodeQuery(v)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	client := qc.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	d, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return responseError(resp, d)
+	}
+	if out == nil || len(bytes.TrimSpace(d)) == 0 {
+		return nil
+	}
+	if err := xml.Unmarshal(d, out); err != nil {
+		return fmt.Errorf("decoding %s response: %w", action, err)
+	}
+	return nil
+}
+
+// EncodeQuery encodes v sorted by key, as url.Values.Encode does, but with the RFC 3986 encoding AWS signs with:
+// spaces become "%20" rather than "+", "*" is encoded and "~" is not.
+func EncodeQuery(v url.Values) string {
+	return strings.NewReplacer("+", "%20", "*", "%2A", "%7E", "~").Replace(v.Encode())
+}
@@ -0,0 +1,60 @@
+package aws_signing_client
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// TestEncodeQuery ensures that parameters are sorted and encoded as AWS signs them.
+func TestEncodeQuery(t *testing.T) {
+	got := EncodeQuery(url.Values{"Message": {"a b*c~d+e"}, "Action": {"Publish"}})
+	if want := "Action=Publish&Message=a%20b%2Ac~d%2Be"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+// TestQueryClient ensures that calls are sent as signed form bodies and their responses decoded.
+func TestQueryClient(t *testing.T) {
+	var sent *http.Request
+	var body string
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		sent = req
+		d, _ := ioutil.ReadAll(req.Body)
+		body = string(d)
+		if strings.Contains(body, "Action=Fail") {
+			return &http.Response{StatusCode: 400, Header: http.Header{}, Body: ioutil.NopCloser(strings.NewReader(
+				`<ErrorResponse><Error><Code>InvalidParameter</Code><Message>bad</Message></Error><RequestId>r1</RequestId></ErrorResponse>`))}, nil
+		}
+		return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(
+			`<PublishResponse><PublishResult><MessageId>m1</MessageId></PublishResult></PublishResponse>`))}, nil
+	})
+	qc := NewQueryClient(c, "https://sns.us-east-1.amazonaws.com/", "2010-03-31")
+
+	var out struct {
+		XMLName   xml.Name `xml:"PublishResponse"`
+		MessageID string   `xml:"PublishResult>MessageId"`
+	}
+	if err := qc.Call(context.Background(), "Publish", map[string]string{"TopicArn": "arn:aws:sns:us-east-1:1:t", "Message": "hi there"}, &out); err != nil {
+		t.Fatalf("An unexpected error occurred while calling: %s", err)
+	}
+	switch {
+	case out.MessageID != "m1":
+		t.Errorf("Unexpected response: %+v", out)
+	case body != "Action=Publish&Message=hi%20there&TopicArn=arn%3Aaws%3Asns%3Aus-east-1%3A1%3At&Version=2010-03-31":
+		t.Errorf("Unexpected body: %s", body)
+	case !strings.HasPrefix(sent.Header.Get("Content-Type"), "application/x-www-form-urlencoded") || sent.Header.Get("Authorization") == "":
+		t.Errorf("Unexpected headers: %v", sent.Header)
+	}
+
+	err := qc.Call(context.Background(), "Fail", nil, nil)
+	var awsErr *AWSError
+	if !errors.As(err, &awsErr) || awsErr.Code != "InvalidParameter" {
+		t.Errorf("Expected an *AWSError, got %v", err)
+	}
+}
@@ -0,0 +1,213 @@
+package aws_signing_client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+const (
+	// MaxRecordsPerRequest is the largest number of records Kinesis PutRecords and Firehose PutRecordBatch accept
+	// in one request. Larger slices are split.
+	MaxRecordsPerRequest = 500
+	// DefaultRecordMaxAttempts is the default number of times a record that keeps failing with a retryable error is
+	// sent.
+	DefaultRecordMaxAttempts = 3
+)
+
+// retryableRecordErrors are the per-record error codes of PutRecords and PutRecordBatch that are retried.
+var retryableRecordErrors = map[string]bool{
+	"ProvisionedThroughputExceededException": true,
+	"InternalFailure":                        true,
+	"ServiceUnavailableException":            true,
+}
+
+type (
+	// KinesisClient puts records to Kinesis Data Streams over an *http.Client returned by New for the "kinesis"
+	// service. Records that fail individually with a throughput or internal error are retried on their own, with a
+	// backoff between rounds.
+	KinesisClient struct {
+		// Client is the HTTP client used to send requests. If nil, http.DefaultClient is used.
+		Client *http.Client
+		// URL is the Kinesis endpoint, e.g. "https://kinesis.us-east-1.amazonaws.com".
+		URL string
+		// MaxAttempts is the number of times a record is sent before its failure is final. Zero means
+		// DefaultRecordMaxAttempts.
+		MaxAttempts int
+		// Backoff decides the delay between rounds of retries. If nil, ExponentialBackoff is used.
+		Backoff Backoff
+	}
+
+	// FirehoseClient puts records to Kinesis Data Firehose over an *http.Client returned by New for the "firehose"
+	// service, retrying failed records like KinesisClient.
+	FirehoseClient struct {
+		// Client is the HTTP client used to send requests. If nil, http.DefaultClient is used.
+		Client *http.Client
+		// URL is the Firehose endpoint, e.g. "https://firehose.us-east-1.amazonaws.com".
+		URL string
+		// MaxAttempts is the number of times a record is sent before its failure is final. Zero means
+		// DefaultRecordMaxAttempts.
+		MaxAttempts int
+		// Backoff decides the delay between rounds of retries. If nil, ExponentialBackoff is used.
+		Backoff Backoff
+	}
+
+	// Record is a data record. PartitionKey is required by Kinesis and ignored by Firehose.
+	Record struct {
+		Data         []byte
+		PartitionKey string
+	}
+
+	// RecordFailure describes a record that was not put.
+	RecordFailure struct {
+		Record  Record
+		Code    string
+		Message string
+	}
+
+	// RecordsError is returned when some records failed permanently or are still failing after MaxAttempts.
+	RecordsError struct {
+		Failures []RecordFailure
+		// Total is the number of records that were submitted, including those that failed.
+		Total int
+	}
+
+	// recordResult is the outcome of one record of a request; ErrorCode is empty on success.
+	recordResult struct {
+		ErrorCode    string `json:"ErrorCode"`
+		ErrorMessage string `json:"ErrorMessage"`
+	}
+)
+
+// NewKinesisClient returns a KinesisClient that sends requests to url using the provided client.
+func NewKinesisClient(client *http.Client, url string) *KinesisClient {
+	return &KinesisClient{Client: client, URL: url}
+}
+
+// NewFirehoseClient returns a FirehoseClient that sends requests to url using the provided client.
+func NewFirehoseClient(client *http.Client, url string) *FirehoseClient {
+	return &FirehoseClient{Client: client, URL: url}
+}
+
+// PutRecords puts records to the stream, splitting them into requests of at most MaxRecordsPerRequest. It returns
+// a *RecordsError listing the records that could not be put, or the error of the first request that failed as a
+// whole.
+func (k *KinesisClient) PutRecords(ctx context.Context, stream string, records []Record) error {
+	return putRecords(ctx, records, k.MaxAttempts, k.Backoff, func(batch []Record) ([]recordResult, error) {
+		in := struct {
+			StreamName string
+			Records    []Record
+		}{stream, batch}
+		var out struct {
+			Records []recordResult
+		}
+		err := doJSON11(ctx, k.Client, k.URL, "Kinesis_20131202.PutRecords", in, &out)
+		return out.Records, err
+	})
+}
+
+// PutRecordBatch puts records to the delivery stream, splitting them into requests of at most
+// MaxRecordsPerRequest. It returns a *RecordsError listing the records that could not be put, or the error of the
+// first request that failed as a whole.
+func (f *FirehoseClient) PutRecordBatch(ctx context.Context, deliveryStream string, records []Record) error {
+	return putRecords(ctx, records, f.MaxAttempts, f.Backoff, func(batch []Record) ([]recordResult, error) {
+		type firehoseRecord struct {
+			Data []byte
+		}
+		in := struct {
+			DeliveryStreamName string
+			Records            []firehoseRecord
+		}{DeliveryStreamName: deliveryStream}
+		for _, r := range batch {
+			in.Records = append(in.Records, firehoseRecord{r.Data})
+		}
+		var out struct {
+			RequestResponses []recordResult
+		}
+		err := doJSON11(ctx, f.Client, f.URL, "Firehose_20150804.PutRecordBatch", in, &out)
+		return out.RequestResponses, err
+	})
+}
+
+// putRecords sends records in batches with send and retries the records that fail with a retryable error code.
+func putRecords(ctx context.Context, records []Record, maxAttempts int, backoff Backoff, send func([]Record) ([]recordResult, error)) error {
+	if backoff == nil {
+		backoff = ExponentialBackoff{}
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultRecordMaxAttempts
+	}
+
+	split := func(pending []Record) [][]Record {
+		var batches [][]Record
+		for start := 0; start < len(pending); start += MaxRecordsPerRequest {
+			batches = append(batches, pending[start:min(start+MaxRecordsPerRequest, len(pending))])
+		}
+		return batches
+	}
+	failed, err := retryItems(ctx, records, maxAttempts, backoff, split, func(batch []Record) ([]itemFailure[Record, RecordFailure], error) {
+		results, err := send(batch)
+		var failures []itemFailure[Record, RecordFailure]
+		for i, r := range results {
+			if r.ErrorCode == "" || i >= len(batch) {
+				continue
+			}
+			f := RecordFailure{Record: batch[i], Code: r.ErrorCode, Message: r.ErrorMessage}
+			failures = append(failures, itemFailure[Record, RecordFailure]{item: batch[i], failure: f, retryable: retryableRecordErrors[r.ErrorCode]})
+		}
+		return failures, err
+	})
+	if err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return &RecordsError{Failures: failed, Total: len(records)}
+	}
+	return nil
+}
+
+// doJSON11 calls target of an AWS JSON 1.1 API at url, marshalling in as the request and unmarshalling the
+// response into out. Non-2xx responses are returned as an *AWSError.
+func doJSON11(ctx context.Context, client *http.Client, url, target string, in, out interface{}) error {
+	d, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(d))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", target)
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if d, err = ioutil.ReadAll(resp.Body); err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return responseError(resp, d)
+	}
+	if err := json.Unmarshal(d, out); err != nil {
+		return fmt.Errorf("decoding %s response: %w", target, err)
+	}
+	return nil
+}
+
+// Error implements the error interface.
+func (err *RecordsError) Error() string {
+	msg := fmt.Sprintf("%d of %d records failed", len(err.Failures), err.Total)
+	if len(err.Failures) > 0 {
+		msg += fmt.Sprintf(": %s: %s", err.Failures[0].Code, err.Failures[0].Message)
+	}
+	return msg
+}
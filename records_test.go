@@ -0,0 +1,118 @@
+package aws_signing_client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+//   ____                        _
+//  |  _ \ ___  ___ ___  _ __ __| |___
+//  | |_) / _ \/ __/ _ \| '__/ _` / __|
+//  |  _ <  __/ (_| (_) | | | (_| \__ \
+//  |_| \_\___|\___\___/|_|  \__,_|___/
+//
+
+// recordsServer returns a client for service whose endpoint answers each record with the error code chosen by code
+// for its data and the number of times it has been seen, and records the data of every request.
+func recordsServer(t *testing.T, service, target string, code func(data string, seen int) string) (*http.Client, *[][]string) {
+	var requests [][]string
+	seen := map[string]int{}
+	c, err := New(v4.NewSigner(creds), &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		switch {
+		case req.Header.Get("X-Amz-Target") != target || req.Header.Get("Content-Type") != "application/x-amz-json-1.1":
+			t.Errorf("Unexpected target headers: %q", req.Header)
+		case !strings.Contains(req.Header.Get("Authorization"), "/"+service+"/aws4_request"):
+			t.Errorf("Request was not signed for %s: %s", service, req.Header.Get("Authorization"))
+		}
+		d, _ := ioutil.ReadAll(req.Body)
+		var in struct {
+			Records []struct {
+				Data []byte
+			}
+		}
+		json.Unmarshal(d, &in)
+		var data, results []string
+		failed := 0
+		for _, r := range in.Records {
+			seen[string(r.Data)]++
+			data = append(data, string(r.Data))
+			if c := code(string(r.Data), seen[string(r.Data)]); c != "" {
+				failed++
+				results = append(results, fmt.Sprintf(`{"ErrorCode":"%s","ErrorMessage":"failed"}`, c))
+			} else {
+				results = append(results, `{"SequenceNumber":"1","RecordId":"1"}`)
+			}
+		}
+		requests = append(requests, data)
+		field := "Records"
+		if service == "firehose" {
+			field = "RequestResponses"
+		}
+		return response(200, fmt.Sprintf(`{"FailedRecordCount":%d,"FailedPutCount":%[1]d,"%s":[%s]}`, failed, field, strings.Join(results, ",")), nil), nil
+	})}, service, "us-east-1", nil)
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+	return c, &requests
+}
+
+// TestKinesisPutRecords ensures that only records failing with a retryable error are sent again.
+func TestKinesisPutRecords(t *testing.T) {
+	c, requests := recordsServer(t, "kinesis", "Kinesis_20131202.PutRecords", func(data string, seen int) string {
+		switch {
+		case data == "b" && seen == 1:
+			return "ProvisionedThroughputExceededException"
+		case data == "c":
+			return "KMSAccessDeniedException"
+		}
+		return ""
+	})
+	k := NewKinesisClient(c, "https://kinesis.us-east-1.amazonaws.com")
+	k.Backoff = constantBackoff(time.Millisecond)
+	err := k.PutRecords(context.Background(), "events", []Record{
+		{Data: []byte("a"), PartitionKey: "1"},
+		{Data: []byte("b"), PartitionKey: "2"},
+		{Data: []byte("c"), PartitionKey: "3"},
+	})
+
+	var recErr *RecordsError
+	switch {
+	case !errors.As(err, &recErr):
+		t.Fatalf("Expected a *RecordsError, got %v", err)
+	case len(recErr.Failures) != 1 || string(recErr.Failures[0].Record.Data) != "c" || recErr.Failures[0].Code != "KMSAccessDeniedException":
+		t.Errorf("Unexpected failures: %+v", recErr.Failures)
+	case fmt.Sprint(*requests) != "[[a b c] [b]]":
+		t.Errorf("Unexpected requests: %v", *requests)
+	}
+}
+
+// TestFirehosePutRecordBatch ensures that records still failing after MaxAttempts are reported.
+func TestFirehosePutRecordBatch(t *testing.T) {
+	c, requests := recordsServer(t, "firehose", "Firehose_20150804.PutRecordBatch", func(data string, seen int) string {
+		if data == "b" {
+			return "ServiceUnavailableException"
+		}
+		return ""
+	})
+	f := NewFirehoseClient(c, "https://firehose.us-east-1.amazonaws.com")
+	f.Backoff = constantBackoff(time.Millisecond)
+	f.MaxAttempts = 2
+	err := f.PutRecordBatch(context.Background(), "logs", []Record{{Data: []byte("a")}, {Data: []byte("b")}})
+
+	var recErr *RecordsError
+	switch {
+	case !errors.As(err, &recErr) || len(recErr.Failures) != 1 || recErr.Failures[0].Code != "ServiceUnavailableException":
+		t.Errorf("Expected record b to fail, got %v", err)
+	case fmt.Sprint(*requests) != "[[a b] [b]]":
+		t.Errorf("Unexpected requests: %v", *requests)
+	}
+}
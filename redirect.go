@@ -0,0 +1,39 @@
+package aws_signing_client
+
+import (
+	"net/http"
+)
+
+// WithRedirectResigning makes the Signer sign every hop of a redirect chain followed by the *http.Client.
+//
+// New leaves the wrapped client's CheckRedirect and Jar in place: CheckRedirect still decides whether a redirect is
+// followed, and cookies from the Jar are added before the request reaches the Signer, so they are covered by the
+// signature. When the client follows a redirect it copies the original request's headers, including its
+// Authorization and X-Amz-* headers, to the next hop. Without this option, that hop is treated as already signed
+// and is sent with the stale signature of the previous URL, which AWS rejects.
+//
+// With this option, the copied signature headers are removed from each hop. Hops to the host of the original
+// request are then signed anew; hops to any other host are sent unsigned, so the Signer never signs for a host the
+// caller did not address.
+func WithRedirectResigning() Option {
+	return func(s *Signer) {
+		s.resignRedirects = true
+	}
+}
+
+// prepareRedirect removes the signature headers copied onto a redirect hop and reports whether the hop targets the
+// host of the original request and should be signed.
+func (s *Signer) prepareRedirect(req *http.Request) bool {
+	for _, h := range signatureHeaders {
+		req.Header.Del(h)
+	}
+	orig := req
+	for orig.Response != nil {
+		if orig.Response.Request == nil {
+			// The transport did not record the request of the redirect response, so the original host is unknown.
+			return false
+		}
+		orig = orig.Response.Request
+	}
+	return orig.URL.Host == req.URL.Host
+}
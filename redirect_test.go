@@ -0,0 +1,74 @@
+package aws_signing_client
+
+import (
+	"net/http"
+	"testing"
+)
+
+//   ____          _ _               _
+//  |  _ \ ___  __| (_)_ __ ___  ___| |_
+//  | |_) / _ \/ _` | | '__/ _ \/ __| __|
+//  |  _ <  __/ (_| | | | |  __/ (__| |_
+//  |_| \_\___|\__,_|_|_|  \___|\___|\__|
+//
+
+func redirectClient(t *testing.T, location string, opts ...Option) (*http.Client, *[]*http.Request) {
+	var sent []*http.Request
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		sent = append(sent, req)
+		resp := response(200, "", nil)
+		if len(sent) == 1 {
+			resp = response(http.StatusTemporaryRedirect, "", http.Header{"Location": {location}})
+		}
+		resp.Request = req
+		return resp, nil
+	}, opts...)
+	return c, &sent
+}
+
+// TestRedirectResigning ensures that a same-host redirect hop is signed for its own URL.
+func TestRedirectResigning(t *testing.T) {
+	c, sent := redirectClient(t, "https://example.com/moved", WithRedirectResigning())
+	if _, err := c.Get("https://example.com/original"); err != nil {
+		t.Fatalf("An unexpected error occurred while making a request: %s", err)
+	}
+	if len(*sent) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(*sent))
+	}
+	first, second := (*sent)[0].Header.Get("Authorization"), (*sent)[1].Header.Get("Authorization")
+	switch {
+	case second == "":
+		t.Error("Redirect hop was not signed")
+	case first == second:
+		t.Error("Redirect hop was sent with the signature of the original request")
+	}
+}
+
+// TestRedirectResigningOtherHost ensures that a redirect to a different host is not signed.
+func TestRedirectResigningOtherHost(t *testing.T) {
+	c, sent := redirectClient(t, "https://sub.example.com/moved", WithRedirectResigning())
+	if _, err := c.Get("https://example.com/original"); err != nil {
+		t.Fatalf("An unexpected error occurred while making a request: %s", err)
+	}
+	if len(*sent) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(*sent))
+	}
+	if h := (*sent)[1].Header; h.Get("Authorization") != "" || h.Get("X-Amz-Security-Token") != "" {
+		t.Error("Redirect hop to a different host carried signature headers")
+	}
+}
+
+// TestRedirectCheckRedirectPreserved ensures that the wrapped client's CheckRedirect is still consulted.
+func TestRedirectCheckRedirectPreserved(t *testing.T) {
+	c, sent := redirectClient(t, "https://example.com/moved", WithRedirectResigning())
+	c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	resp, err := c.Get("https://example.com/original")
+	switch {
+	case err != nil:
+		t.Fatalf("An unexpected error occurred while making a request: %s", err)
+	case resp.StatusCode != http.StatusTemporaryRedirect || len(*sent) != 1:
+		t.Error("CheckRedirect of the wrapped client was not honored")
+	}
+}
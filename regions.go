@@ -0,0 +1,163 @@
+package aws_signing_client
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultRegionFailureThreshold is the number of consecutive failed requests after which a region set with
+	// WithRegions is excluded.
+	DefaultRegionFailureThreshold = 3
+	// DefaultRegionCooldown is how long a failing region set with WithRegions is excluded before it is tried again.
+	DefaultRegionCooldown = 30 * time.Second
+)
+
+// RegionBalancing selects how WithRegions spreads requests across regions.
+type RegionBalancing int
+
+const (
+	// RoundRobin sends requests to each healthy region in turn.
+	RoundRobin RegionBalancing = iota
+	// LowestLatency sends requests to the healthy region with the lowest recent latency. Regions without a
+	// measurement yet are tried first.
+	LowestLatency
+)
+
+type (
+	// RegionEndpoint is a regional endpoint of the client's service.
+	RegionEndpoint struct {
+		// Region is the region requests to Host are signed for, e.g. "eu-west-1".
+		Region string
+		// Host is the endpoint's host, with an optional port, e.g. "sqs.eu-west-1.amazonaws.com".
+		Host string
+	}
+
+	// regionPool holds the endpoints set with WithRegions and their health.
+	regionPool struct {
+		balancing RegionBalancing
+		threshold int
+		cooldown  time.Duration
+		next      uint64 // accessed atomically
+		regions   []*regionState
+	}
+
+	regionState struct {
+		RegionEndpoint
+		mu            sync.Mutex
+		latency       time.Duration // moving average; zero until measured
+		failures      int
+		excludedUntil time.Time
+	}
+)
+
+// WithRegions spreads the requests of the client across the regional endpoints of its service, e.g. for
+// active-active deployments. Every attempt, including those made by WithRetries, is sent to an endpoint chosen by
+// balancing, with the request's host replaced by the endpoint's host, and is signed for the endpoint's region. A
+// region whose requests failed with a transport error or a 5xx status DefaultRegionFailureThreshold times in a row is
+// excluded for DefaultRegionCooldown; both can be changed with WithRegionHealth. If every region is excluded, the
+// one that becomes eligible first is used. Redirect hops keep their host and are signed for the region whose endpoint
+// they target. Endpoint hosts are subject to WithAllowedHosts.
+func WithRegions(balancing RegionBalancing, endpoints ...RegionEndpoint) Option {
+	return func(s *Signer) {
+		p := &regionPool{balancing: balancing, threshold: DefaultRegionFailureThreshold, cooldown: DefaultRegionCooldown}
+		if s.regions != nil {
+			p.threshold, p.cooldown = s.regions.threshold, s.regions.cooldown
+		}
+		for _, ep := range endpoints {
+			p.regions = append(p.regions, &regionState{RegionEndpoint: ep})
+		}
+		s.regions = p
+	}
+}
+
+// WithRegionHealth sets the number of consecutive failures after which a region set with WithRegions is excluded,
+// and how long it is excluded for.
+func WithRegionHealth(threshold int, cooldown time.Duration) Option {
+	return func(s *Signer) {
+		if s.regions == nil {
+			s.regions = &regionPool{}
+		}
+		s.regions.threshold, s.regions.cooldown = threshold, cooldown
+	}
+}
+
+// selectRegion picks the region an attempt is sent to and points req at its endpoint. Redirect hops keep their host.
+// It returns nil if WithRegions is not used or the host of a redirect hop is not a regional endpoint.
+func (s *Signer) selectRegion(req *http.Request) *regionState {
+	if s.regions == nil {
+		return nil
+	}
+	if req.Response != nil {
+		return s.regions.regionFor(req.URL.Host)
+	}
+	r := s.regions.pick()
+	if r != nil {
+		req.URL.Host, req.Host = r.Host, ""
+	}
+	return r
+}
+
+// pick returns the region the next attempt is sent to.
+func (p *regionPool) pick() *regionState {
+	now := time.Now()
+	n := uint64(len(p.regions))
+	start := atomic.AddUint64(&p.next, 1) - 1
+	var best, soonest *regionState
+	var bestLatency time.Duration
+	var soonestTime time.Time
+	for i := uint64(0); i < n; i++ {
+		r := p.regions[(start+i)%n]
+		r.mu.Lock()
+		latency, excludedUntil := r.latency, r.excludedUntil
+		r.mu.Unlock()
+		if now.Before(excludedUntil) {
+			if soonest == nil || excludedUntil.Before(soonestTime) {
+				soonest, soonestTime = r, excludedUntil
+			}
+			continue
+		}
+		if p.balancing == RoundRobin {
+			return r
+		}
+		if best == nil || latency < bestLatency {
+			best, bestLatency = r, latency
+		}
+	}
+	if best != nil {
+		return best
+	}
+	return soonest
+}
+
+// regionFor returns the region whose endpoint is host, or nil.
+func (p *regionPool) regionFor(host string) *regionState {
+	for _, r := range p.regions {
+		if strings.EqualFold(r.Host, host) {
+			return r
+		}
+	}
+	return nil
+}
+
+// report records the outcome of an attempt sent to r.
+func (p *regionPool) report(r *regionState, latency time.Duration, resp *http.Response, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil || resp.StatusCode >= 500 {
+		r.failures++
+		if r.failures >= p.threshold {
+			r.excludedUntil = time.Now().Add(p.cooldown)
+		}
+		return
+	}
+	r.failures = 0
+	if r.latency == 0 {
+		r.latency = latency
+	} else {
+		r.latency = (r.latency*7 + latency*3) / 10
+	}
+}
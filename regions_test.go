@@ -0,0 +1,96 @@
+package aws_signing_client
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+//   ____            _
+//  |  _ \ ___  __ _(_) ___  _ __  ___
+//  | |_) / _ \/ _` | |/ _ \| '_ \/ __|
+//  |  _ <  __/ (_| | | (_) | | | \__ \
+//  |_| \_\___|\__, |_|\___/|_| |_|___/
+//             |___/
+
+// regionsClient returns a client spreading requests across two regions whose endpoint answers requests to a host
+// with status(host), and records the hosts and signing regions of the requests.
+func regionsClient(t *testing.T, balancing RegionBalancing, status func(host string) int, opts ...Option) (*http.Client, *[]string) {
+	var sent []string
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		auth := req.Header.Get("Authorization")
+		region := strings.Split(auth[strings.Index(auth, "Credential="):], "/")[2]
+		sent = append(sent, req.URL.Host+" "+region)
+		if code := status(req.URL.Host); code != 0 {
+			return response(code, "", nil), nil
+		}
+		return nil, errors.New("connection refused")
+	}, append([]Option{WithRegions(balancing,
+		RegionEndpoint{Region: "us-east-1", Host: "sqs.us-east-1.amazonaws.com"},
+		RegionEndpoint{Region: "eu-west-1", Host: "sqs.eu-west-1.amazonaws.com"},
+	)}, opts...)...)
+	return c, &sent
+}
+
+// TestWithRegionsRoundRobin ensures that requests alternate between regions and are signed for each.
+func TestWithRegionsRoundRobin(t *testing.T) {
+	c, sent := regionsClient(t, RoundRobin, func(string) int { return 200 })
+	for i := 0; i < 4; i++ {
+		if _, err := c.Get("https://sqs.us-east-1.amazonaws.com/"); err != nil {
+			t.Fatalf("An unexpected error occurred while making a request: %s", err)
+		}
+	}
+	expected := []string{
+		"sqs.us-east-1.amazonaws.com us-east-1", "sqs.eu-west-1.amazonaws.com eu-west-1",
+		"sqs.us-east-1.amazonaws.com us-east-1", "sqs.eu-west-1.amazonaws.com eu-west-1",
+	}
+	if strings.Join(*sent, ",") != strings.Join(expected, ",") {
+		t.Errorf("Unexpected requests: %v", *sent)
+	}
+}
+
+// TestWithRegionsExcludesFailingRegion ensures that a failing region is excluded and retries fail over.
+func TestWithRegionsExcludesFailingRegion(t *testing.T) {
+	c, sent := regionsClient(t, RoundRobin, func(host string) int {
+		if strings.Contains(host, "us-east-1") {
+			return 503
+		}
+		return 200
+	}, WithRegionHealth(1, time.Hour), WithRetries(2), fastRetries)
+	for i := 0; i < 3; i++ {
+		resp, err := c.Get("https://sqs.us-east-1.amazonaws.com/")
+		if err != nil || resp.StatusCode != 200 {
+			t.Fatalf("Expected the request to succeed in eu-west-1, got %v, %v", resp, err)
+		}
+	}
+	expected := []string{
+		"sqs.us-east-1.amazonaws.com us-east-1", "sqs.eu-west-1.amazonaws.com eu-west-1",
+		"sqs.eu-west-1.amazonaws.com eu-west-1", "sqs.eu-west-1.amazonaws.com eu-west-1",
+	}
+	if strings.Join(*sent, ",") != strings.Join(expected, ",") {
+		t.Errorf("Unexpected requests: %v", *sent)
+	}
+}
+
+// TestWithRegionsLowestLatency ensures that the region with the lowest measured latency is preferred.
+func TestWithRegionsLowestLatency(t *testing.T) {
+	c, sent := regionsClient(t, LowestLatency, func(host string) int {
+		if strings.Contains(host, "us-east-1") {
+			time.Sleep(20 * time.Millisecond)
+		}
+		return 200
+	})
+	for i := 0; i < 4; i++ {
+		if _, err := c.Get("https://sqs.us-east-1.amazonaws.com/"); err != nil {
+			t.Fatalf("An unexpected error occurred while making a request: %s", err)
+		}
+	}
+	for _, s := range (*sent)[2:] {
+		if !strings.HasPrefix(s, "sqs.eu-west-1") {
+			t.Errorf("Expected measured requests to go to eu-west-1, got %v", *sent)
+			break
+		}
+	}
+}
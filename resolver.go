@@ -0,0 +1,137 @@
+package aws_signing_client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultResolverTTL is how long a CachingResolver keeps addresses when no TTL is set.
+	DefaultResolverTTL = 30 * time.Second
+	// DefaultResolverMaxStale is how long past their TTL a CachingResolver serves addresses while lookups fail,
+	// when no MaxStale is set.
+	DefaultResolverMaxStale = 5 * time.Minute
+)
+
+type (
+	// Resolver looks up the addresses of a host for the dialer of the wrapped transport. *net.Resolver satisfies it.
+	Resolver interface {
+		LookupHost(ctx context.Context, host string) ([]string, error)
+	}
+
+	// CachingResolver is a Resolver that caches the addresses returned by another Resolver, so that requests to the
+	// same regional endpoint do not each wait for a DNS lookup. When a lookup of an expired host fails, the cached
+	// addresses are served for up to MaxStale longer. It is safe for concurrent use.
+	CachingResolver struct {
+		// Resolver performs the lookups. If nil, net.DefaultResolver is used.
+		Resolver Resolver
+		// TTL is how long addresses are cached. Zero means DefaultResolverTTL.
+		TTL time.Duration
+		// MaxStale is how long past their TTL addresses are still served when a lookup fails. Zero means
+		// DefaultResolverMaxStale.
+		MaxStale time.Duration
+
+		mu      sync.Mutex
+		entries map[string]resolverEntry
+	}
+
+	resolverEntry struct {
+		addrs   []string
+		expires time.Time
+	}
+)
+
+// NewCachingResolver returns a CachingResolver that caches the lookups of r for ttl.
+func NewCachingResolver(r Resolver, ttl time.Duration) *CachingResolver {
+	return &CachingResolver{Resolver: r, TTL: ttl}
+}
+
+// WithResolver makes the dialer of the wrapped transport look up hosts with r, e.g. a CachingResolver, and dial the
+// returned addresses in order until one connects. The wrapped transport must be an *http.Transport (or nil for
+// http.DefaultTransport); it is cloned, and its own DialContext, if any, is used to dial the addresses. New fails
+// with an *UnsupportedTransportError for any other transport.
+func WithResolver(r Resolver) Option {
+	return func(s *Signer) {
+		s.transportConfigs = append(s.transportConfigs, transportConfig{option: "WithResolver", apply: func(t *http.Transport) error {
+			t.DialContext = resolvingDialer(r, t.DialContext)
+			return nil
+		}})
+	}
+}
+
+// resolvingDialer returns a DialContext function that resolves host names with r before dialing them with dial, or
+// with a default net.Dialer if dial is nil.
+func resolvingDialer(r Resolver, dial func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil || net.ParseIP(host) != nil {
+			return dial(ctx, network, addr)
+		}
+		addrs, err := r.LookupHost(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		var firstErr error
+		for _, a := range addrs {
+			conn, err := dial(ctx, network, net.JoinHostPort(a, port))
+			if err == nil {
+				return conn, nil
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		if firstErr == nil {
+			firstErr = &net.DNSError{Err: "no addresses found", Name: host, IsNotFound: true}
+		}
+		return nil, firstErr
+	}
+}
+
+// LookupHost implements Resolver.
+func (cr *CachingResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	now := time.Now()
+	cr.mu.Lock()
+	entry, ok := cr.entries[host]
+	cr.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.addrs, nil
+	}
+
+	r := cr.Resolver
+	if r == nil {
+		r = net.DefaultResolver
+	}
+	addrs, err := r.LookupHost(ctx, host)
+	if err != nil {
+		maxStale := cr.MaxStale
+		if maxStale == 0 {
+			maxStale = DefaultResolverMaxStale
+		}
+		if ok && now.Before(entry.expires.Add(maxStale)) {
+			return entry.addrs, nil
+		}
+		return nil, err
+	}
+
+	ttl := cr.TTL
+	if ttl == 0 {
+		ttl = DefaultResolverTTL
+	}
+	cr.mu.Lock()
+	if cr.entries == nil {
+		cr.entries = map[string]resolverEntry{}
+	}
+	cr.entries[host] = resolverEntry{addrs: addrs, expires: now.Add(ttl)}
+	cr.mu.Unlock()
+	return addrs, nil
+}
@@ -0,0 +1,91 @@
+package aws_signing_client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+//   ____                 _
+//  |  _ \ ___  ___  ___ | |_   _____ _ __
+//  | |_) / _ \/ __|/ _ \| \ \ / / _ \ '__|
+//  |  _ <  __/\__ \ (_) | |\ V /  __/ |
+//  |_| \_\___||___/\___/|_| \_/ \___|_|
+//
+
+type staticResolver struct {
+	addrs   map[string][]string
+	err     error
+	lookups int
+}
+
+func (sr *staticResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	sr.lookups++
+	if sr.err != nil {
+		return nil, sr.err
+	}
+	return sr.addrs[host], nil
+}
+
+// TestCachingResolver ensures that lookups are cached for the TTL and served stale when a refresh fails.
+func TestCachingResolver(t *testing.T) {
+	sr := &staticResolver{addrs: map[string][]string{"es.amazonaws.com": {"10.0.0.1"}}}
+	cr := NewCachingResolver(sr, time.Hour)
+	for i := 0; i < 3; i++ {
+		if addrs, err := cr.LookupHost(context.Background(), "es.amazonaws.com"); err != nil || addrs[0] != "10.0.0.1" {
+			t.Fatalf("Unexpected lookup result: %v, %v", addrs, err)
+		}
+	}
+	if sr.lookups != 1 {
+		t.Errorf("Expected 1 lookup, got %d", sr.lookups)
+	}
+
+	cr.entries["es.amazonaws.com"] = resolverEntry{addrs: []string{"10.0.0.1"}, expires: time.Now().Add(-time.Minute)}
+	sr.err = errors.New("no DNS")
+	if addrs, err := cr.LookupHost(context.Background(), "es.amazonaws.com"); err != nil || addrs[0] != "10.0.0.1" {
+		t.Errorf("Expected the stale addresses to be served, got %v, %v", addrs, err)
+	}
+	cr.entries["es.amazonaws.com"] = resolverEntry{addrs: []string{"10.0.0.1"}, expires: time.Now().Add(-time.Hour)}
+	if _, err := cr.LookupHost(context.Background(), "es.amazonaws.com"); err != sr.err {
+		t.Errorf("Expected the lookup error past MaxStale, got %v", err)
+	}
+}
+
+// TestWithResolver ensures that the wrapped transport dials the addresses returned by the resolver.
+func TestWithResolver(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	sr := &staticResolver{addrs: map[string][]string{"example.com": {"127.0.0.1"}}}
+	c, err := New(v4.NewSigner(creds), srv.Client(), "es", "us-east-1", nil, WithResolver(NewCachingResolver(sr, 0)))
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+	for i := 0; i < 2; i++ {
+		resp, err := c.Get("https://example.com:" + port + "/")
+		if err != nil {
+			t.Fatalf("An unexpected error occurred while making a request: %s", err)
+		}
+		resp.Body.Close()
+		c.CloseIdleConnections()
+	}
+	if sr.lookups != 1 {
+		t.Errorf("Expected 1 lookup, got %d", sr.lookups)
+	}
+}
+
+// TestWithResolverUnsupportedTransport ensures that transports other than *http.Transport are rejected.
+func TestWithResolverUnsupportedTransport(t *testing.T) {
+	_, err := New(v4.NewSigner(creds), &http.Client{Transport: roundTripFunc(nil)}, "es", "us-east-1", nil, WithResolver(net.DefaultResolver))
+	var ute *UnsupportedTransportError
+	if !errors.As(err, &ute) || ute.Option != "WithResolver" {
+		t.Errorf("Expected an *UnsupportedTransportError, got %v", err)
+	}
+}
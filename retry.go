@@ -0,0 +1,415 @@
+package aws_signing_client
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// DefaultRetryBaseDelay is the base delay of the provided Backoffs: the delay before the first retry, before
+	// jitter is applied.
+	DefaultRetryBaseDelay = 100 * time.Millisecond
+	// DefaultRetryMaxDelay caps the delay between two attempts of the provided Backoffs.
+	DefaultRetryMaxDelay = 20 * time.Second
+	// SignatureValidity is how long AWS accepts a signature after the time it was signed at.
+	SignatureValidity = 5 * time.Minute
+	// InvocationIDHeader is the header WithInvocationIDs sets.
+	InvocationIDHeader = "Amz-Sdk-Invocation-Id"
+)
+
+type (
+	// RetryError is an implementation of the error interface that is returned when a request retried with
+	// WithRetries fails after more than one attempt. It wraps the failure of every attempt.
+	RetryError struct {
+		// Errors holds the failure of each attempt, in order. A failure that was a non-2xx response is an
+		// *AWSError.
+		Errors []error
+	}
+
+	// retryPolicy configures the retry subsystem enabled by WithRetries.
+	retryPolicy struct {
+		maxAttempts int
+		backoff     Backoff // nil for the default ExponentialBackoff
+		statusCodes map[int]bool
+		substrings  []string
+		predicate   RetryPredicate
+		maxDuration time.Duration
+	}
+
+	// RetryPredicate decides whether a failed attempt is retried, given its error or non-2xx response. The
+	// response's *AWSError is available through ResponseError.
+	RetryPredicate func(resp *http.Response, err error) bool
+
+	// attemptKey is the context key of the attemptInfo of a request.
+	attemptKey struct{}
+
+	attemptInfo struct {
+		attempt, max int
+	}
+)
+
+// throttleCodes are the error codes AWS services use to signal throttling. They match the SDKs' lists.
+var throttleCodes = map[string]bool{
+	"Throttling":                             true,
+	"ThrottlingException":                    true,
+	"ThrottledException":                     true,
+	"RequestThrottledException":              true,
+	"TooManyRequestsException":               true,
+	"ProvisionedThroughputExceededException": true,
+	"TransactionInProgressException":         true,
+	"RequestLimitExceeded":                   true,
+	"BandwidthLimitExceeded":                 true,
+	"LimitExceededException":                 true,
+	"RequestThrottled":                       true,
+	"SlowDown":                               true,
+	"PriorRequestNotComplete":                true,
+	"EC2ThrottledException":                  true,
+}
+
+// transientCodes are error codes, other than throttling, for transient failures that are safe to retry.
+var transientCodes = map[string]bool{
+	"RequestTimeout":          true,
+	"RequestTimeoutException": true,
+	"InternalError":           true,
+}
+
+// retryableStatusCodes are HTTP status codes that are retried regardless of the error code.
+var retryableStatusCodes = map[int]bool{
+	http.StatusInternalServerError: true,
+	http.StatusBadGateway:          true,
+	http.StatusServiceUnavailable:  true,
+	http.StatusGatewayTimeout:      true,
+}
+
+// WithRetries retries requests that fail with a retryable error, as reported by IsRetryable or customized with
+// WithRetryableStatusCodes, WithRetryableErrorSubstrings and WithRetryPredicate, until maxAttempts attempts in total
+// have been made. Every attempt is signed anew. Attempts are separated by the delay of the Backoff
+// set with WithBackoff, by default an ExponentialBackoff, which is cut short if the request's context is done.
+// Requests of every method are retried, as the SDKs do. A maxAttempts of 1 or less disables retries.
+func WithRetries(maxAttempts int) Option {
+	return func(s *Signer) {
+		s.retry.maxAttempts = maxAttempts
+	}
+}
+
+// WithMaxRetryDuration stops retrying a request once another attempt would start more than d after the first one
+// was signed, returning the failure of the last attempt. Every attempt is signed with its own timestamp, but a d
+// below SignatureValidity also ensures that no attempt of a request outlives the validity window of the first
+// signature, e.g. for idempotency tokens or presigned URLs derived from it.
+func WithMaxRetryDuration(d time.Duration) Option {
+	return func(s *Signer) {
+		s.retry.maxDuration = d
+	}
+}
+
+// WithInvocationIDs sets the InvocationIDHeader of every attempt, including each retry, to a new random UUID before
+// it is signed, so that attempts can be told apart in server-side logs. The ID is reported in RequestTiming.
+func WithInvocationIDs() Option {
+	return func(s *Signer) {
+		s.invocationIDs = true
+	}
+}
+
+// WithRetryableStatusCodes makes the retry subsystem also retry responses with the given status codes, e.g. 409 for
+// Amazon OpenSearch Service snapshot conflicts.
+func WithRetryableStatusCodes(codes ...int) Option {
+	return func(s *Signer) {
+		if s.retry.statusCodes == nil {
+			s.retry.statusCodes = make(map[int]bool, len(codes))
+		}
+		for _, c := range codes {
+			s.retry.statusCodes[c] = true
+		}
+	}
+}
+
+// WithRetryableErrorSubstrings makes the retry subsystem also retry attempts whose error message contains one of
+// substrings. For non-2xx responses, the message of their *AWSError is matched, which includes the error code.
+func WithRetryableErrorSubstrings(substrings ...string) Option {
+	return func(s *Signer) {
+		s.retry.substrings = append(s.retry.substrings, substrings...)
+	}
+}
+
+// WithRetryPredicate replaces the retry subsystem's rules, including those of WithRetryableStatusCodes and
+// WithRetryableErrorSubstrings, with p. Call IsRetryable from p to extend the default rules rather than replace
+// them. Successful responses are never retried.
+func WithRetryPredicate(p RetryPredicate) Option {
+	return func(s *Signer) {
+		s.retry.predicate = p
+	}
+}
+
+// IsThrottle reports whether a response or error returned by a signed client indicates that the request was
+// throttled: a 429 status or one of the throttling error codes used by AWS services. Error codes are only known for
+// an *AWSError in err's chain or a response inspected by WithErrorParsing or WithRetries.
+func IsThrottle(resp *http.Response, err error) bool {
+	status, code := errorStatus(resp, err)
+	return status == http.StatusTooManyRequests || throttleCodes[code]
+}
+
+// IsRetryable reports whether a response or error returned by a signed client indicates a failure that is safe to
+// retry, following the SDKs' standard retry semantics: throttling (see IsThrottle), transient server errors (500,
+// 502, 503, 504 and timeout error codes) and connection errors such as resets, refused connections and timeouts.
+// Canceled contexts, certificate errors and other client errors are not retryable. It may be called with the results
+// of Client.Do or RoundTrip directly.
+func IsRetryable(resp *http.Response, err error) bool {
+	if IsThrottle(resp, err) {
+		return true
+	}
+	status, code := errorStatus(resp, err)
+	if retryableStatusCodes[status] || transientCodes[code] {
+		return true
+	}
+	if err == nil || status != 0 {
+		return false
+	}
+	return isRetryableConnectionError(err)
+}
+
+// errorStatus returns the status and error code of a failed request, if known.
+func errorStatus(resp *http.Response, err error) (status int, code string) {
+	var awsErr *AWSError
+	switch {
+	case errors.As(err, &awsErr):
+		return awsErr.StatusCode, awsErr.Code
+	case resp == nil:
+		return 0, ""
+	}
+	if awsErr := ResponseError(resp); awsErr != nil {
+		return awsErr.StatusCode, awsErr.Code
+	}
+	return resp.StatusCode, ""
+}
+
+func isRetryableConnectionError(err error) bool {
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		invalidCert      x509.CertificateInvalidError
+		hostname         x509.HostnameError
+		opErr            *net.OpError
+		netErr           net.Error
+	)
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.As(err, &unknownAuthority), errors.As(err, &invalidCert), errors.As(err, &hostname):
+		return false
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.EPIPE):
+		return true
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return true
+	case errors.As(err, &opErr) && opErr.Op == "dial":
+		return true
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return true
+	}
+	return false
+}
+
+// sendWithRetries sends req until it succeeds, fails with an error that is not retryable, or the attempts are
+// exhausted. Errors returned after more than one attempt are a *RetryError holding the failure of every attempt.
+func (s *Signer) sendWithRetries(ctx context.Context, req *http.Request, sc *signingScope, body *payload, timing RequestTiming) (*http.Response, error) {
+	var failures []error
+	backoff := newBackoff(s.retry.backoff)
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		actx := context.WithValue(ctx, attemptKey{}, attemptInfo{attempt: attempt, max: s.retry.maxAttempts})
+		resp, sent, err := s.send(actx, req, sc, body, timing)
+		if !sent || attempt >= s.retry.maxAttempts {
+			return resp, withFailures(failures, err)
+		}
+		if err == nil && resp.StatusCode < 300 {
+			return resp, nil
+		}
+		if err == nil && ResponseError(resp) == nil {
+			s.inspectError(actx, resp)
+		}
+		if !s.retry.retryable(resp, err) {
+			return resp, withFailures(failures, err)
+		}
+
+		delay := backoff.NextDelay(attempt, err, resp)
+		if s.retry.maxDuration > 0 && time.Since(start)+delay > s.retry.maxDuration {
+			s.logf(actx, "Not retrying request, the maximum retry duration of %d ms would be exceeded.", s.retry.maxDuration/time.Millisecond)
+			return resp, withFailures(failures, err)
+		}
+		cause := retryCause(resp, err)
+		DrainAndClose(resp)
+		failures = append(failures, cause)
+		if err := budgetExceeded(ctx, delay, retryErrors(failures)); err != nil {
+			s.logf(actx, "Not retrying request, the latency budget would be exceeded: %s", cause)
+			return nil, err
+		}
+		recordRetry(ctx, cause)
+		s.logf(actx, "Retrying request in %d ms: %s", delay/time.Millisecond, cause)
+		for _, h := range s.hooks {
+			h.OnRetry(actx, attempt, delay, cause)
+		}
+
+		wait := time.Now()
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, withFailures(failures, ctx.Err())
+		case <-timer.C:
+		}
+		timing.BodyRead, timing.Hash, timing.QueueWait = 0, 0, time.Since(wait)
+	}
+}
+
+// retryable reports whether a failed attempt is retried.
+func (p *retryPolicy) retryable(resp *http.Response, err error) bool {
+	if p.predicate != nil {
+		return p.predicate(resp, err)
+	}
+	if IsRetryable(resp, err) {
+		return true
+	}
+	if status, _ := errorStatus(resp, err); p.statusCodes[status] {
+		return true
+	}
+	if len(p.substrings) > 0 {
+		msg := retryCause(resp, err).Error()
+		for _, sub := range p.substrings {
+			if strings.Contains(msg, sub) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RequestAttempt returns the number of the attempt a request with context ctx is on, counting from 1, and the
+// maximum number of attempts, for use by loggers and Hooks. ok is false outside of an attempt made with WithRetries.
+func RequestAttempt(ctx context.Context) (attempt, maxAttempts int, ok bool) {
+	info, ok := ctx.Value(attemptKey{}).(attemptInfo)
+	return info.attempt, info.max, ok
+}
+
+// retryCause returns the error of a failed attempt.
+func retryCause(resp *http.Response, err error) error {
+	switch {
+	case err != nil:
+		return err
+	case ResponseError(resp) != nil:
+		return ResponseError(resp)
+	}
+	return fmt.Errorf("AWS request failed with status %s", resp.Status)
+}
+
+// retryErrors returns the error for the failures of a request's attempts.
+func retryErrors(failures []error) error {
+	if len(failures) == 1 {
+		return failures[0]
+	}
+	return &RetryError{Errors: append([]error(nil), failures...)}
+}
+
+// withFailures wraps err, the result of the last attempt, together with the failures of the previous attempts.
+func withFailures(failures []error, err error) error {
+	if err == nil || len(failures) == 0 {
+		return err
+	}
+	return retryErrors(append(failures, err))
+}
+
+// itemFailure is the failure of one item of a batch request, for retryItems.
+type itemFailure[T, F any] struct {
+	item      T
+	failure   F
+	retryable bool
+}
+
+// retryItems sends items in the batches returned by split, and sends the items whose failure is retryable again,
+// after the delay of backoff, until none is left or they have been sent maxAttempts times. It returns the failures
+// that are final, including those still retryable if ctx is done while waiting, or the error of the first batch that
+// failed as a whole.
+func retryItems[T, F any](ctx context.Context, items []T, maxAttempts int, backoff Backoff, split func([]T) [][]T, send func(batch []T) ([]itemFailure[T, F], error)) ([]F, error) {
+	var failed []F
+	pending := items
+	for attempt := 1; ; attempt++ {
+		var retry []T
+		var retryFailures []F
+		for _, batch := range split(pending) {
+			failures, err := send(batch)
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range failures {
+				if f.retryable && attempt < maxAttempts {
+					retry = append(retry, f.item)
+					retryFailures = append(retryFailures, f.failure)
+					continue
+				}
+				failed = append(failed, f.failure)
+			}
+		}
+		if len(retry) == 0 {
+			return failed, nil
+		}
+
+		t := time.NewTimer(backoff.NextDelay(attempt, nil, nil))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return append(failed, retryFailures...), nil
+		case <-t.C:
+		}
+		pending = retry
+	}
+}
+
+// retryCall calls fn until it succeeds, fails with an error that is not retryable, or has been called maxAttempts
+// times, waiting between calls as backoff says. It returns ctx.Err() if ctx is done while waiting.
+func retryCall(ctx context.Context, maxAttempts int, backoff Backoff, fn func() error) error {
+	backoff = newBackoff(backoff)
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= maxAttempts || !IsRetryable(nil, err) {
+			return err
+		}
+
+		t := time.NewTimer(backoff.NextDelay(attempt, err, nil))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// newBackoff returns backoff, or ExponentialBackoff if it is nil, ready for a new sequence of attempts.
+func newBackoff(backoff Backoff) Backoff {
+	if backoff == nil {
+		backoff = ExponentialBackoff{}
+	}
+	if seq, ok := backoff.(backoffSequence); ok {
+		backoff = seq.newSequence()
+	}
+	return backoff
+}
+
+// Error implements the error interface.
+func (err *RetryError) Error() string {
+	msgs := make([]string, len(err.Errors))
+	for i, e := range err.Errors {
+		msgs[i] = fmt.Sprintf("attempt %d: %s", i+1, e)
+	}
+	return fmt.Sprintf("Request failed after %d attempts: %s", len(err.Errors), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the failures of every attempt, so that errors.Is and errors.As match any of them.
+func (err *RetryError) Unwrap() []error {
+	return err.Errors
+}
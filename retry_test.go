@@ -0,0 +1,340 @@
+package aws_signing_client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+//   ____      _
+//  |  _ \ ___| |_ _ __ _   _
+//  | |_) / _ \ __| '__| | | |
+//  |  _ <  __/ |_| |  | |_| |
+//  |_| \_\___|\__|_|   \__, |
+//                      |___/
+
+// constantBackoff waits the same delay before every retry.
+type constantBackoff time.Duration
+
+func (b constantBackoff) NextDelay(attempt int, err error, resp *http.Response) time.Duration {
+	return time.Duration(b)
+}
+
+// fastRetries shortens the backoff of WithRetries for tests.
+var fastRetries = WithBackoff(constantBackoff(time.Millisecond))
+
+// TestIsThrottle tests the throttling classification of responses and errors.
+func TestIsThrottle(t *testing.T) {
+	throttled := response(400, `{"__type":"ThrottlingException"}`, nil)
+	throttled.Body = &errorBody{Reader: bytes.NewReader(nil), err: &AWSError{StatusCode: 400, Code: "ThrottlingException"}}
+	cases := []struct {
+		resp *http.Response
+		err  error
+		want bool
+	}{
+		{response(429, "", nil), nil, true},
+		{throttled, nil, true},
+		{nil, &url.Error{Op: "Get", URL: "https://example.com", Err: &AWSError{StatusCode: 503, Code: "SlowDown"}}, true},
+		{response(400, "", nil), nil, false},
+		{response(200, "", nil), nil, false},
+		{nil, errors.New("boom"), false},
+	}
+	for i, c := range cases {
+		if got := IsThrottle(c.resp, c.err); got != c.want {
+			t.Errorf("Case %d: IsThrottle returned %v, expected %v", i, got, c.want)
+		}
+	}
+}
+
+// TestIsRetryable tests the retryability classification of responses and errors.
+func TestIsRetryable(t *testing.T) {
+	cases := []struct {
+		resp *http.Response
+		err  error
+		want bool
+	}{
+		{response(429, "", nil), nil, true},
+		{response(503, "", nil), nil, true},
+		{response(500, "", nil), nil, true},
+		{response(404, "", nil), nil, false},
+		{response(200, "", nil), nil, false},
+		{nil, &AWSError{StatusCode: 400, Code: "RequestTimeout"}, true},
+		{nil, &AWSError{StatusCode: 400, Code: "ValidationException"}, false},
+		{nil, fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{nil, &net.OpError{Op: "dial", Err: errors.New("no route to host")}, true},
+		{nil, &url.Error{Op: "Get", URL: "https://example.com", Err: context.Canceled}, false},
+		{nil, context.DeadlineExceeded, false},
+		{nil, AnonymousCredentialsError{}, false},
+		{nil, nil, false},
+	}
+	for i, c := range cases {
+		if got := IsRetryable(c.resp, c.err); got != c.want {
+			t.Errorf("Case %d: IsRetryable returned %v, expected %v", i, got, c.want)
+		}
+	}
+}
+
+// TestWithRetries ensures that retryable failures are retried with a fresh signature and the full body until the
+// request succeeds.
+func TestWithRetries(t *testing.T) {
+	var bodies []string
+	responses := []*http.Response{
+		response(400, `{"__type":"com.amazonaws.es#ThrottlingException","message":"slow down"}`, nil),
+		response(503, "", nil),
+		response(200, "ok", nil),
+	}
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("Authorization") == "" {
+			t.Errorf("Attempt %d was not signed", len(bodies)+1)
+		}
+		d, _ := ioutil.ReadAll(req.Body)
+		bodies = append(bodies, string(d))
+		return responses[len(bodies)-1], nil
+	}, WithRetries(3), fastRetries)
+
+	resp, err := c.Post("https://example.com/_bulk", "application/json", bytes.NewBufferString("payload"))
+	if err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err)
+	}
+	if resp.StatusCode != 200 || len(bodies) != 3 {
+		t.Errorf("Expected a 200 after 3 attempts, got %d after %d", resp.StatusCode, len(bodies))
+	}
+	for i, b := range bodies {
+		if b != "payload" {
+			t.Errorf("Attempt %d was sent with body %q", i+1, b)
+		}
+	}
+}
+
+// TestWithRetriesExhausted ensures that the last response is returned once the attempts are exhausted, and that
+// errors that are not retryable are returned immediately.
+func TestWithRetriesExhausted(t *testing.T) {
+	status := 500
+	attempts := 0
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		attempts++
+		return response(status, "", nil), nil
+	}, WithRetries(2), fastRetries)
+
+	resp, err := c.Get("https://example.com/")
+	if err != nil || resp.StatusCode != 500 || attempts != 2 {
+		t.Errorf("Expected a 500 after 2 attempts, got %v, %v after %d", resp, err, attempts)
+	}
+
+	status, attempts = 403, 0
+	resp, err = c.Get("https://example.com/")
+	if err != nil || resp.StatusCode != 403 || attempts != 1 {
+		t.Errorf("Expected a 403 after 1 attempt, got %v, %v after %d", resp, err, attempts)
+	}
+}
+
+// TestWithRetriesContext ensures that the backoff is cut short when the request's context is done.
+func TestWithRetriesContext(t *testing.T) {
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		return response(503, "", nil), nil
+	}, WithRetries(5), WithBackoff(constantBackoff(time.Hour)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/", nil)
+	_, err := c.Do(req)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the context's error, got %v", err)
+	}
+}
+
+// TestRetryCall ensures that retryCall stops at the first success, at an error that is not retryable, after the
+// last attempt, or with the context's error when the context is done while waiting.
+func TestRetryCall(t *testing.T) {
+	throttled := &AWSError{StatusCode: 429, Code: "ThrottlingException"}
+	cases := []struct {
+		errs     []error
+		attempts int
+		err      error
+	}{
+		{[]error{throttled, nil}, 2, nil},
+		{[]error{throttled, &AWSError{StatusCode: 403}}, 2, &AWSError{StatusCode: 403}},
+		{[]error{throttled, throttled, throttled, nil}, 3, throttled},
+	}
+	for _, tc := range cases {
+		attempts := 0
+		err := retryCall(context.Background(), 3, constantBackoff(0), func() error {
+			attempts++
+			return tc.errs[attempts-1]
+		})
+		if attempts != tc.attempts || fmt.Sprint(err) != fmt.Sprint(tc.err) {
+			t.Errorf("Expected %d attempts and %v, got %d and %v", tc.attempts, tc.err, attempts, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := retryCall(ctx, 3, constantBackoff(time.Hour), func() error { return throttled })
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected the context's error, got %v", err)
+	}
+}
+
+// TestRetryAttemptNumbering ensures that log lines and hook events carry the attempt number, and that the final
+// error wraps the failure of every attempt.
+func TestRetryAttemptNumbering(t *testing.T) {
+	rl := &recordingLogger{}
+	rh := &recordingHooks{}
+	c, err := New(v4.NewSigner(creds), &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, fmt.Errorf("read: %w", syscall.ECONNRESET)
+	})}, "es", "us-east-1", rl, WithRetries(3), fastRetries, WithHooks(rh))
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+
+	_, err = c.Get("https://example.com/")
+	var retryErr *RetryError
+	switch {
+	case !errors.As(err, &retryErr):
+		t.Fatalf("Expected a *RetryError, got %v", err)
+	case len(retryErr.Errors) != 3 || !errors.Is(err, syscall.ECONNRESET):
+		t.Errorf("Unexpected attempt errors: %v", retryErr.Errors)
+	}
+
+	if len(rh.requests) != 3 {
+		t.Fatalf("Expected 3 hook events, got %d", len(rh.requests))
+	}
+	for i, timing := range rh.requests {
+		if timing.Attempt != i+1 || timing.MaxAttempts != 3 {
+			t.Errorf("Unexpected attempt in hook event %d: %d/%d", i, timing.Attempt, timing.MaxAttempts)
+		}
+	}
+	for _, want := range []string{"[attempt 1/3]", "[attempt 2/3]", "[attempt 3/3]"} {
+		found := false
+		for _, line := range rl.lines {
+			found = found || strings.HasSuffix(line, want)
+		}
+		if !found {
+			t.Errorf("No log line ends with %s", want)
+		}
+	}
+}
+
+// TestRetryableRules ensures that the retryable status codes, error substrings and predicate can be customized.
+func TestRetryableRules(t *testing.T) {
+	cases := []struct {
+		name string
+		resp *http.Response
+		err  error
+		opt  Option
+		want int
+	}{
+		{"status code", response(409, "", nil), nil, WithRetryableStatusCodes(409), 3},
+		{"default status code", response(409, "", nil), nil, WithRetryableStatusCodes(418), 1},
+		{"error substring", nil, errors.New("use of closed network connection"), WithRetryableErrorSubstrings("closed network"), 3},
+		{"response substring", response(400, `{"__type":"ConcurrentModificationException"}`, nil), nil, WithRetryableErrorSubstrings("ConcurrentModification"), 3},
+		{"predicate", response(503, "", nil), nil, WithRetryPredicate(func(resp *http.Response, err error) bool { return false }), 1},
+		{"predicate extending defaults", response(404, "", nil), nil, WithRetryPredicate(func(resp *http.Response, err error) bool {
+			return IsRetryable(resp, err) || resp.StatusCode == 404
+		}), 3},
+	}
+	for _, tc := range cases {
+		attempts := 0
+		c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+			attempts++
+			if tc.resp != nil {
+				r := *tc.resp
+				r.Body = ioutil.NopCloser(strings.NewReader(`{"__type":"ConcurrentModificationException"}`))
+				return &r, nil
+			}
+			return nil, tc.err
+		}, WithRetries(3), fastRetries, tc.opt)
+		c.Get("https://example.com/")
+		if attempts != tc.want {
+			t.Errorf("%s: expected %d attempts, got %d", tc.name, tc.want, attempts)
+		}
+	}
+
+	attempts := 0
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		attempts++
+		return response(200, "", nil), nil
+	}, WithRetries(3), fastRetries, WithRetryPredicate(func(resp *http.Response, err error) bool { return true }))
+	if _, err := c.Get("https://example.com/"); err != nil || attempts != 1 {
+		t.Errorf("Successful responses should never be retried: %v after %d attempts", err, attempts)
+	}
+}
+
+// TestRetrySignsEachAttempt ensures that every attempt is signed at its own time with a new invocation ID.
+func TestRetrySignsEachAttempt(t *testing.T) {
+	rh := &recordingHooks{}
+	var auths, ids []string
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		auths = append(auths, req.Header.Get("Authorization"))
+		ids = append(ids, req.Header.Get(InvocationIDHeader))
+		return response(503, "", nil), nil
+	}, WithRetries(3), fastRetries, WithInvocationIDs(), WithHooks(rh))
+	if _, err := c.Get("https://example.com/"); err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err)
+	}
+
+	if len(rh.requests) != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", len(rh.requests))
+	}
+	for i := 1; i < 3; i++ {
+		prev, cur := rh.requests[i-1], rh.requests[i]
+		switch {
+		case !cur.Start.After(prev.Start):
+			t.Errorf("Attempt %d was not signed after attempt %d", i+1, i)
+		case cur.InvocationID == "" || cur.InvocationID == prev.InvocationID || cur.InvocationID != ids[i]:
+			t.Errorf("Attempt %d did not get a new invocation ID: %q", i+1, ids)
+		case auths[i] == auths[i-1]:
+			t.Errorf("Attempt %d reused the signature of attempt %d", i+1, i)
+		}
+	}
+}
+
+// TestRetryQueueWait ensures that the backoff before a retry is reported as QueueWait and that the retry is signed
+// after it.
+func TestRetryQueueWait(t *testing.T) {
+	rh := &recordingHooks{}
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		return response(503, "", nil), nil
+	}, WithRetries(2), WithBackoff(constantBackoff(20*time.Millisecond)), WithHooks(rh))
+	if _, err := c.Get("https://example.com/"); err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err)
+	}
+
+	first, second := rh.requests[0], rh.requests[1]
+	switch {
+	case first.QueueWait >= 20*time.Millisecond:
+		t.Errorf("Expected the first attempt not to wait, got %s", first.QueueWait)
+	case second.QueueWait < 20*time.Millisecond:
+		t.Errorf("Expected the retry to report the backoff as QueueWait, got %s", second.QueueWait)
+	case second.Start.Sub(first.Start) < second.QueueWait:
+		t.Errorf("Expected the retry to be signed after waiting %s, got %s after the first attempt", second.QueueWait, second.Start.Sub(first.Start))
+	}
+}
+
+// TestWithMaxRetryDuration ensures that no attempt starts after the maximum retry duration.
+func TestWithMaxRetryDuration(t *testing.T) {
+	attempts := 0
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		attempts++
+		return response(503, "", nil), nil
+	}, WithRetries(10), WithBackoff(constantBackoff(20*time.Millisecond)), WithMaxRetryDuration(50*time.Millisecond))
+	start := time.Now()
+	resp, err := c.Get("https://example.com/")
+	if err != nil || resp.StatusCode != 503 || attempts != 3 {
+		t.Errorf("Expected a 503 after 3 attempts, got %v, %v after %d", resp, err, attempts)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("Retries took %s", elapsed)
+	}
+}
@@ -0,0 +1,148 @@
+package aws_signing_client
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DefaultPostPolicyExpiry is how long a presigned POST policy is valid for when PostPolicy.Expires is zero.
+const DefaultPostPolicyExpiry = time.Hour
+
+type (
+	// PostPolicy describes the uploads a presigned S3 POST form allows.
+	PostPolicy struct {
+		// Bucket is the bucket uploaded to.
+		Bucket string
+		// Key is the key of the uploaded object. If KeyPrefix is true, any key starting with Key is allowed, and
+		// the form's key field is Key followed by "${filename}", which S3 replaces with the name of the uploaded
+		// file.
+		Key       string
+		KeyPrefix bool
+		// Expires is how long the form can be used for. It defaults to DefaultPostPolicyExpiry.
+		Expires time.Duration
+		// MinContentLength and MaxContentLength limit the size of the upload, in bytes, if MaxContentLength is
+		// greater than zero.
+		MinContentLength int64
+		MaxContentLength int64
+		// Fields are form fields the upload must include with exactly these values, e.g. "Content-Type", "acl" or
+		// "success_action_status". They are returned with the form fields and added to the policy's conditions.
+		Fields map[string]string
+		// Conditions are further policy conditions, e.g. []string{"starts-with", "$Content-Type", "image/"}.
+		Conditions []interface{}
+	}
+
+	// PresignedPost is an HTML form that uploads a file directly to S3.
+	PresignedPost struct {
+		// URL is the form's action.
+		URL string
+		// Fields are the hidden fields of the form. The file must be the last field of the multipart body.
+		Fields map[string]string
+	}
+
+	// MissingBucketError is an implementation of the error interface that indicates that no bucket was provided in
+	// order to presign a POST policy.
+	MissingBucketError struct{}
+)
+
+// PresignPost creates a form for uploading directly to S3 from a browser under policy, signed with the client's
+// credentials for its signing region. It returns an *UnsupportedPresignError if the RequestSigner does not implement
+// CredentialsRetriever.
+func (c *Client) PresignPost(ctx context.Context, policy PostPolicy) (*PresignedPost, error) {
+	sc := c.scopeFor()
+	cr, ok := sc.signer.(CredentialsRetriever)
+	if !ok {
+		return nil, &UnsupportedPresignError{Signer: fmt.Sprintf("%T", sc.signer)}
+	}
+	creds, err := cr.RetrieveCredentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewPresignedPost(creds, c.Options().SigningRegion, policy, time.Now())
+}
+
+// NewPresignedPost creates a form for uploading directly to S3 from a browser under policy, signed with creds for
+// region at signTime, without an AWS SDK.
+func NewPresignedPost(creds Credentials, region string, policy PostPolicy, signTime time.Time) (*PresignedPost, error) {
+	switch {
+	case policy.Bucket == "":
+		return nil, MissingBucketError{}
+	case creds.AccessKeyID == "" || creds.SecretAccessKey == "":
+		return nil, MissingCredentialsError{}
+	case region == "":
+		return nil, MissingRegionError{}
+	}
+	expires := policy.Expires
+	if expires == 0 {
+		expires = DefaultPostPolicyExpiry
+	}
+	t := signTime.UTC()
+	date := t.Format("20060102")
+	fields := map[string]string{
+		"key":              policy.Key,
+		"x-amz-algorithm":  "AWS4-HMAC-SHA256",
+		"x-amz-credential": creds.AccessKeyID + "/" + date + "/" + region + "/s3/aws4_request",
+		"x-amz-date":       t.Format("20060102T150405Z"),
+	}
+	if creds.SessionToken != "" {
+		fields["x-amz-security-token"] = creds.SessionToken
+	}
+
+	conditions := []interface{}{map[string]string{"bucket": policy.Bucket}}
+	if policy.KeyPrefix {
+		conditions = append(conditions, []string{"starts-with", "$key", policy.Key})
+		fields["key"] = policy.Key + "${filename}"
+	} else {
+		conditions = append(conditions, map[string]string{"key": policy.Key})
+	}
+	for _, name := range []string{"x-amz-algorithm", "x-amz-credential", "x-amz-date", "x-amz-security-token"} {
+		if v, ok := fields[name]; ok {
+			conditions = append(conditions, map[string]string{name: v})
+		}
+	}
+	if policy.MaxContentLength > 0 {
+		conditions = append(conditions, []interface{}{"content-length-range", policy.MinContentLength, policy.MaxContentLength})
+	}
+	for name, v := range policy.Fields {
+		fields[name] = v
+		conditions = append(conditions, map[string]string{name: v})
+	}
+	conditions = append(conditions, policy.Conditions...)
+
+	doc, err := json.Marshal(map[string]interface{}{
+		"expiration": t.Add(expires).Format("2006-01-02T15:04:05.000Z"),
+		"conditions": conditions,
+	})
+	if err != nil {
+		return nil, err
+	}
+	encoded := base64.StdEncoding.EncodeToString(doc)
+	fields["policy"] = encoded
+	fields["x-amz-signature"] = hex.EncodeToString(hmacSHA256(signingKey(creds.SecretAccessKey, date, region, "s3"), encoded))
+
+	return &PresignedPost{URL: fmt.Sprintf("https://%s.%s/", policy.Bucket, PartitionForRegion(region).Host("s3", region)), Fields: fields}, nil
+}
+
+// signingKey derives the SigV4 signing key for the given date, region and service.
+func signingKey(secret, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// Error implements the error interface.
+func (err MissingBucketError) Error() string {
+	return "No S3 bucket was provided. Cannot presign POST policy."
+}
@@ -0,0 +1,71 @@
+package aws_signing_client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// TestSigningKey ensures that the signing key matches the example of the AWS documentation.
+func TestSigningKey(t *testing.T) {
+	key := hex.EncodeToString(signingKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20120215", "us-east-1", "iam"))
+	if key != "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d" {
+		t.Errorf("Unexpected signing key: %s", key)
+	}
+}
+
+// TestPresignPost ensures that a presigned POST form carries a signed policy with the requested conditions.
+func TestPresignPost(t *testing.T) {
+	c, err := New(v4.NewSigner(creds), &http.Client{}, "s3", "eu-west-1", nil)
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+	fc, _ := WrapClient(c)
+	post, err := fc.PresignPost(context.Background(), PostPolicy{
+		Bucket:           "uploads",
+		Key:              "user/42/",
+		KeyPrefix:        true,
+		Expires:          10 * time.Minute,
+		MaxContentLength: 1 << 20,
+		Fields:           map[string]string{"Content-Type": "image/png"},
+	})
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while presigning: %s", err)
+	}
+	f := post.Fields
+	switch {
+	case post.URL != "https://uploads.s3.eu-west-1.amazonaws.com/":
+		t.Errorf("Unexpected URL: %s", post.URL)
+	case f["key"] != "user/42/${filename}" || f["Content-Type"] != "image/png" || f["x-amz-security-token"] != "TOKEN":
+		t.Errorf("Unexpected fields: %v", f)
+	case len(f["x-amz-signature"]) != 64:
+		t.Errorf("Unexpected signature: %q", f["x-amz-signature"])
+	}
+
+	doc, _ := base64.StdEncoding.DecodeString(f["policy"])
+	var policy struct {
+		Expiration string
+		Conditions []interface{}
+	}
+	if err := json.Unmarshal(doc, &policy); err != nil {
+		t.Fatalf("Policy is not valid JSON: %s", err)
+	}
+	if len(policy.Conditions) != 8 {
+		t.Errorf("Unexpected conditions: %v", policy.Conditions)
+	}
+	date := f["x-amz-date"][:8]
+	want := hex.EncodeToString(hmacSHA256(signingKey("SECRET", date, "eu-west-1", "s3"), f["policy"]))
+	if f["x-amz-signature"] != want {
+		t.Errorf("Expected signature %s, got %s", want, f["x-amz-signature"])
+	}
+
+	if _, err := NewPresignedPost(Credentials{AccessKeyID: "ID", SecretAccessKey: "SECRET"}, "eu-west-1", PostPolicy{}, time.Now()); err != (MissingBucketError{}) {
+		t.Errorf("Expected MissingBucketError, got %v", err)
+	}
+}
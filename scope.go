@@ -0,0 +1,115 @@
+package aws_signing_client
+
+// signingScope holds the values a request is signed with. It is replaced as a whole, so a request being signed
+// never sees a mix of old and new values.
+type signingScope struct {
+	signer  RequestSigner
+	service string
+	region  string
+	// destination is the alias of the Destination a request is routed to, if any.
+	destination string
+	// derived is set for the scopes of destinations and derived clients, which are signed without the Signer's
+	// signing name and region overrides and are not spread by WithRegions.
+	derived bool
+}
+
+func (s *Signer) loadScope() *signingScope {
+	return s.scope.Load().(*signingScope)
+}
+
+// Scope returns the service and region requests are currently signed for.
+func (s *Signer) Scope() (service, region string) {
+	sc := s.loadScope()
+	return sc.service, sc.region
+}
+
+// WithSigningName signs requests under name instead of the client's service. The service passed to New and
+// SetScope still identifies the endpoint, but the signature's credential scope uses name. This is needed when an
+// endpoint signs under a different name than its hostname suggests, e.g. Amazon OpenSearch Serverless ("aoss") or
+// AppStream 2.0 ("appstream2" hosts signed as "appstream"), and for custom domains in front of such services.
+func WithSigningName(name string) Option {
+	return func(s *Signer) {
+		s.signingNameOverride = name
+	}
+}
+
+// WithSigningRegion signs requests for region instead of the client's region. The region passed to New and
+// SetScope still identifies the endpoint. Without this option, services with a global endpoint that are listed by
+// GlobalSigningRegion are signed for their fixed region automatically.
+func WithSigningRegion(region string) Option {
+	return func(s *Signer) {
+		s.signingRegionOverride = region
+	}
+}
+
+// globalSigningRegions are the signing regions of services that are only reachable through a global endpoint in the
+// aws partition.
+var globalSigningRegions = map[string]string{
+	"budgets":           "us-east-1",
+	"ce":                "us-east-1",
+	"cloudfront":        "us-east-1",
+	"globalaccelerator": "us-west-2",
+	"iam":               "us-east-1",
+	"networkmanager":    "us-west-2",
+	"organizations":     "us-east-1",
+	"route53":           "us-east-1",
+	"waf":               "us-east-1",
+}
+
+// GlobalSigningRegion returns the region that requests to the global endpoint of the service signed under name must
+// be signed for in the aws partition, e.g. "us-east-1" for IAM, Route 53 and CloudFront. ok is false for regional
+// services. Requests are signed for the global signing region of the partition of the client's region.
+func GlobalSigningRegion(name string) (region string, ok bool) {
+	region, ok = globalSigningRegions[name]
+	return region, ok
+}
+
+// signingValues returns the name and region requests are signed with for the scope sc.
+func (s *Signer) signingValues(sc *signingScope) (name, region string) {
+	p := s.partition(sc.region)
+	name, region = p.SigningName(sc.service), sc.region
+	if !sc.derived {
+		if s.signingNameOverride != "" {
+			name = s.signingNameOverride
+		}
+		if s.signingRegionOverride != "" {
+			return name, s.signingRegionOverride
+		}
+	}
+	if r, ok := p.GlobalSigningRegion(name); ok {
+		region = r
+	}
+	return name, region
+}
+
+// SetScope atomically changes the service and region that subsequent requests are signed for. Requests that have
+// already been signed are unaffected, and the underlying transport and its connection pool are kept.
+func (s *Signer) SetScope(service, region string) error {
+	s.scopeMu.Lock()
+	defer s.scopeMu.Unlock()
+	sc := s.loadScope()
+	return s.storeScope(sc.signer, service, region)
+}
+
+// ReloadSigner atomically replaces the RequestSigner, service and region that subsequent requests are signed with.
+// Requests that have already been signed are unaffected, and the underlying transport and its connection pool are
+// kept.
+func (s *Signer) ReloadSigner(rs RequestSigner, service, region string) error {
+	s.scopeMu.Lock()
+	defer s.scopeMu.Unlock()
+	return s.storeScope(rs, service, region)
+}
+
+// storeScope validates and stores a new scope. The caller must hold scopeMu.
+func (s *Signer) storeScope(rs RequestSigner, service, region string) error {
+	switch {
+	case rs == nil:
+		return MissingSignerError{}
+	case service == "":
+		return MissingServiceError{}
+	case region == "":
+		return MissingRegionError{}
+	}
+	s.scope.Store(&signingScope{signer: rs, service: service, region: region})
+	return nil
+}
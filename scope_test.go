@@ -0,0 +1,121 @@
+package aws_signing_client
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+//   ____
+//  / ___|  ___ ___  _ __   ___
+//  \___ \ / __/ _ \| '_ \ / _ \
+//   ___) | (_| (_) | |_) |  __/
+//  |____/ \___\___/| .__/ \___|
+//                  |_|
+
+func scopeClient(t *testing.T, opts ...Option) (*http.Client, *Signer, func() *http.Request) {
+	var mu sync.Mutex
+	var sent *http.Request
+	c, s := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		sent = req
+		mu.Unlock()
+		return response(200, "", nil), nil
+	}, opts...)
+	return c, s, func() *http.Request {
+		mu.Lock()
+		defer mu.Unlock()
+		return sent
+	}
+}
+
+// TestSetScope ensures that requests are signed for the new scope after SetScope().
+func TestSetScope(t *testing.T) {
+	c, s, sent := scopeClient(t)
+	if err := s.SetScope("execute-api", "us-west-2"); err != nil {
+		t.Fatalf("An unexpected error occurred while setting the scope: %s", err)
+	}
+	if _, err := c.Get("https://example.com/"); err != nil {
+		t.Fatalf("An unexpected error occurred while making a request: %s", err)
+	}
+	if !strings.Contains(sent().Header.Get("Authorization"), "/us-west-2/execute-api/aws4_request") {
+		t.Errorf("Request was not signed for the new scope: %s", sent().Header.Get("Authorization"))
+	}
+}
+
+// TestSetScopeValidation ensures that an invalid scope is rejected and the previous scope is kept.
+func TestSetScopeValidation(t *testing.T) {
+	_, s, _ := scopeClient(t)
+	if err := s.SetScope("", "us-west-2"); err != (MissingServiceError{}) {
+		t.Error("Error was not of type MissingServiceError")
+	}
+	if err := s.SetScope("es", ""); err != (MissingRegionError{}) {
+		t.Error("Error was not of type MissingRegionError")
+	}
+	if service, region := s.Scope(); service != "es" || region != "us-east-1" {
+		t.Errorf("Scope was changed by an invalid update: %s/%s", service, region)
+	}
+}
+
+// TestReload ensures that Reload() swaps the credentials along with the scope, including under concurrent use.
+func TestReload(t *testing.T) {
+	c, s, sent := scopeClient(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Get("https://example.com/")
+		}()
+	}
+	if err := s.Reload(v4.NewSigner(credentials.NewStaticCredentials("NEWID", "SECRET", "")), "es", "eu-west-1"); err != nil {
+		t.Fatalf("An unexpected error occurred while reloading: %s", err)
+	}
+	wg.Wait()
+	if _, err := c.Get("https://example.com/"); err != nil {
+		t.Fatalf("An unexpected error occurred while making a request: %s", err)
+	}
+	if !strings.Contains(sent().Header.Get("Authorization"), "Credential=NEWID/") {
+		t.Errorf("Request was not signed with the reloaded credentials: %s", sent().Header.Get("Authorization"))
+	}
+}
+
+// TestWithSigningName ensures that the signing name override is used and survives SetScope().
+func TestWithSigningName(t *testing.T) {
+	c, s, sent := scopeClient(t, WithSigningName("aoss"))
+	if err := s.SetScope("es", "us-west-2"); err != nil {
+		t.Fatalf("An unexpected error occurred while setting the scope: %s", err)
+	}
+	if _, err := c.Get("https://example.com/"); err != nil {
+		t.Fatalf("An unexpected error occurred while making a request: %s", err)
+	}
+	if !strings.Contains(sent().Header.Get("Authorization"), "/us-west-2/aoss/aws4_request") {
+		t.Errorf("Request was not signed under the signing name: %s", sent().Header.Get("Authorization"))
+	}
+	if service, _ := s.Scope(); service != "es" {
+		t.Errorf("Signing name override replaced the service: %s", service)
+	}
+}
+
+// TestSigningRegion ensures that the signing region override and the global service presets are applied.
+func TestSigningRegion(t *testing.T) {
+	c, s, sent := scopeClient(t, WithSigningRegion("eu-central-1"))
+	c.Get("https://example.com/")
+	if !strings.Contains(sent().Header.Get("Authorization"), "/eu-central-1/es/aws4_request") {
+		t.Errorf("Request was not signed for the signing region: %s", sent().Header.Get("Authorization"))
+	}
+	if _, region := s.Scope(); region != "us-east-1" {
+		t.Errorf("Signing region override replaced the region: %s", region)
+	}
+
+	c, s, sent = scopeClient(t)
+	s.SetScope("iam", "us-west-2")
+	c.Get("https://iam.amazonaws.com/")
+	if !strings.Contains(sent().Header.Get("Authorization"), "/us-east-1/iam/aws4_request") {
+		t.Errorf("Global service was not signed for us-east-1: %s", sent().Header.Get("Authorization"))
+	}
+}
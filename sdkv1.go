@@ -0,0 +1,133 @@
+//go:build !nosdkv1
+
+package aws_signing_client
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// The aws-sdk-go (v1) backend. Building with the nosdkv1 tag leaves it out, so that programs using only the awsv2
+// backend do not link aws-sdk-go.
+
+// sdkV1Signer adapts an aws-sdk-go *v4.Signer to RequestSigner, RequestPresigner, CredentialsRetriever,
+// CredentialsRefresher and AnonymousDetector.
+type sdkV1Signer struct {
+	*v4.Signer
+}
+
+// New obtains an HTTP client with a RoundTripper that signs AWS requests for the provided service. An
+// existing client can be specified for the `client` value, or--if nil--a new HTTP client will be created. Optional
+// behavior is configured by passing Options.
+func New(v4s *v4.Signer, client *http.Client, service string, region string, cl ContextLogger, opts ...Option) (*http.Client, error) {
+	if v4s == nil {
+		return nil, MissingSignerError{}
+	}
+	return NewWithRequestSigner(&sdkV1Signer{v4s}, client, service, region, cl, opts...)
+}
+
+// NewWithStaticCredentials obtains a new HTTP client that signs requests for the provided service and region with a
+// fixed key pair. sessionToken may be empty for long-term credentials.
+func NewWithStaticCredentials(accessKey, secret, sessionToken, service, region string, opts ...Option) (*http.Client, error) {
+	if accessKey == "" || secret == "" {
+		return nil, MissingCredentialsError{}
+	}
+	v4s := v4.NewSigner(credentials.NewStaticCredentials(accessKey, secret, sessionToken))
+	return New(v4s, &http.Client{}, service, region, nil, opts...)
+}
+
+// NewPrometheusClient obtains a new HTTP client that queries the Amazon Managed Service for Prometheus workspace
+// workspaceID in region, signing requests for the "aps" service and rewriting their paths as described by
+// WithPrometheusWorkspace. Point Prometheus API clients or Grafana data sources at PrometheusWorkspaceURL.
+func NewPrometheusClient(v4s *v4.Signer, client *http.Client, region, workspaceID string, cl ContextLogger, opts ...Option) (*http.Client, error) {
+	if workspaceID == "" {
+		return nil, MissingWorkspaceError{}
+	}
+	return New(v4s, client, PrometheusService, region, cl, append([]Option{WithPrometheusWorkspace(workspaceID)}, opts...)...)
+}
+
+// Reload atomically replaces the v4.Signer, service and region that subsequent requests are signed with. Requests
+// that have already been signed are unaffected, and the underlying transport and its connection pool are kept.
+func (s *Signer) Reload(v4s *v4.Signer, service, region string) error {
+	if v4s == nil {
+		return MissingSignerError{}
+	}
+	return s.ReloadSigner(&sdkV1Signer{v4s}, service, region)
+}
+
+// SetCredentials atomically replaces the credentials that subsequent requests are signed with, keeping the rest of
+// the v4.Signer configuration, the scope and the underlying transport. Requests already in flight complete with the
+// signature they were sent with. It is safe to call from any goroutine. If the Signer was created with a
+// RequestSigner other than a *v4.Signer, it is replaced with a default *v4.Signer.
+func (s *Signer) SetCredentials(provider credentials.Provider) error {
+	if provider == nil {
+		return MissingCredentialsError{}
+	}
+	s.scopeMu.Lock()
+	defer s.scopeMu.Unlock()
+	sc := s.loadScope()
+	v4s := v4.NewSigner(nil)
+	if cur, ok := sc.signer.(*sdkV1Signer); ok {
+		c := *cur.Signer
+		v4s = &c
+	}
+	v4s.Credentials = credentials.NewCredentials(provider)
+	return s.storeScope(&sdkV1Signer{v4s}, sc.service, sc.region)
+}
+
+// Anonymous reports whether the signer's credentials are credentials.AnonymousCredentials or resolve to an empty key
+// pair. Other retrieval errors are left for Sign to report.
+func (s *sdkV1Signer) Anonymous(ctx context.Context) bool {
+	creds := s.Credentials
+	if creds == nil || creds == credentials.AnonymousCredentials {
+		return true
+	}
+	v, err := creds.GetWithContext(ctx)
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "EmptyStaticCreds" {
+			return true
+		}
+		return false
+	}
+	return v.AccessKeyID == "" && v.SecretAccessKey == ""
+}
+
+// RetrieveCredentials returns the signer's current credentials.
+func (s *sdkV1Signer) RetrieveCredentials(ctx context.Context) (Credentials, error) {
+	if s.Credentials == nil {
+		return Credentials{}, MissingCredentialsError{}
+	}
+	v, err := s.Credentials.GetWithContext(ctx)
+	if err != nil {
+		return Credentials{}, err
+	}
+	return Credentials{AccessKeyID: v.AccessKeyID, SecretAccessKey: v.SecretAccessKey, SessionToken: v.SessionToken}, nil
+}
+
+// CredentialsExpiry returns when the signer's credentials expire, retrieving them first if needed. ok is false if
+// their provider does not report an expiry.
+func (s *sdkV1Signer) CredentialsExpiry(ctx context.Context) (time.Time, bool) {
+	if s.Credentials == nil {
+		return time.Time{}, false
+	}
+	if _, err := s.Credentials.GetWithContext(ctx); err != nil {
+		return time.Time{}, false
+	}
+	expires, err := s.Credentials.ExpiresAt()
+	return expires, err == nil && !expires.IsZero()
+}
+
+// RefreshCredentials expires the signer's credentials and retrieves new ones.
+func (s *sdkV1Signer) RefreshCredentials(ctx context.Context) error {
+	if s.Credentials == nil {
+		return MissingCredentialsError{}
+	}
+	s.Credentials.Expire()
+	_, err := s.Credentials.GetWithContext(ctx)
+	return err
+}
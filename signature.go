@@ -0,0 +1,206 @@
+package aws_signing_client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SignatureMismatch compares the canonical request AWS computed for a request rejected with SignatureDoesNotMatch
+// with the one computed by the client, as reported by WithSignatureDiagnostics.
+type SignatureMismatch struct {
+	// Server is the canonical request from the error response.
+	Server string
+	// Client is the canonical request computed from the request as it was sent.
+	Client string
+	// Line is the 1-based number of the first line that differs, or 0 if the canonical requests match, in which
+	// case the signature most likely differs because of the credentials or the signing scope.
+	Line int
+	// Part names the part of the canonical request that differs: "method", "path", "query", "headers",
+	// "signed headers" or "payload hash".
+	Part string
+	// ServerLine and ClientLine are the lines that differ.
+	ServerLine, ClientLine string
+}
+
+// WithSignatureDiagnostics makes the Signer diagnose responses rejected with SignatureDoesNotMatch. The canonical
+// request AWS includes in the error body is compared with the client's, and the first mismatching line is logged
+// and set on the response's *AWSError, which can be retrieved with ResponseError. The client's canonical request
+// is computed from the request as sent, so it also catches headers modified after signing.
+func WithSignatureDiagnostics() Option {
+	return func(s *Signer) {
+		s.diagnoseSignatures = true
+	}
+}
+
+// diagnoseSignature compares the canonical request in awsErr with the one for req and its body, or returns nil if
+// the error carries no canonical request.
+func diagnoseSignature(req *http.Request, body []byte, awsErr *AWSError) *SignatureMismatch {
+	server := serverCanonicalRequest(awsErr)
+	if server == "" {
+		return nil
+	}
+	m := &SignatureMismatch{Server: server, Client: canonicalRequest(req, body)}
+	serverLines, clientLines := strings.Split(m.Server, "\n"), strings.Split(m.Client, "\n")
+	for i := 0; i < len(serverLines) || i < len(clientLines); i++ {
+		var sl, cl string
+		if i < len(serverLines) {
+			sl = serverLines[i]
+		}
+		if i < len(clientLines) {
+			cl = clientLines[i]
+		}
+		if sl != cl {
+			m.Line, m.ServerLine, m.ClientLine = i+1, sl, cl
+			m.Part = canonicalPart(serverLines, i)
+			break
+		}
+	}
+	return m
+}
+
+func (m *SignatureMismatch) String() string {
+	if m.Line == 0 {
+		return "Canonical requests match; check the credentials, clock and signing scope."
+	}
+	return fmt.Sprintf("Canonical request differs in the %s at line %d: server has '%s', client has '%s'.", m.Part, m.Line, m.ServerLine, m.ClientLine)
+}
+
+// canonicalPart names the part of a canonical request split into lines that line i belongs to.
+func canonicalPart(lines []string, i int) string {
+	switch i {
+	case 0:
+		return "method"
+	case 1:
+		return "path"
+	case 2:
+		return "query"
+	}
+	blank := len(lines)
+	for j := 3; j < len(lines); j++ {
+		if lines[j] == "" {
+			blank = j
+			break
+		}
+	}
+	switch {
+	case i <= blank:
+		return "headers"
+	case i == blank+1:
+		return "signed headers"
+	}
+	return "payload hash"
+}
+
+// serverCanonicalRequest extracts the canonical request from a SignatureDoesNotMatch error: the CanonicalRequest
+// element of S3 errors, or the quoted canonical string in the message of other services.
+func serverCanonicalRequest(awsErr *AWSError) string {
+	var doc struct {
+		CanonicalRequest string `xml:"CanonicalRequest"`
+	}
+	if xml.Unmarshal(awsErr.Body, &doc) == nil && doc.CanonicalRequest != "" {
+		return doc.CanonicalRequest
+	}
+	const marker = "The Canonical String for this request should have been\n'"
+	msg := awsErr.Message
+	i := strings.Index(msg, marker)
+	if i < 0 {
+		return ""
+	}
+	msg = msg[i+len(marker):]
+	if j := strings.Index(msg, "'\n"); j >= 0 {
+		return msg[:j]
+	}
+	return strings.TrimSuffix(msg, "'")
+}
+
+// canonicalRequest computes the SigV4 canonical request for req as sent, using the signed headers from its
+// Authorization header. Like both signing backends by default, it escapes the path a second time, which S3 does
+// not expect.
+func canonicalRequest(req *http.Request, body []byte) string {
+	return canonicalRequestFor(req, body, signedHeaderNames(req))
+}
+
+// canonicalRequestFor is like canonicalRequest, for the signed headers signed.
+func canonicalRequestFor(req *http.Request, body []byte, signed []string) string {
+	uri := req.URL.EscapedPath()
+	if uri == "" {
+		uri = "/"
+	}
+	uri = escapePath(uri, false)
+
+	return strings.Join([]string{
+		req.Method,
+		uri,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders(req, signed),
+		strings.Join(signed, ";"),
+		signedPayloadHash(req, body),
+	}, "\n")
+}
+
+// signedHeaderNames returns the names of the signed headers listed in the Authorization header of req.
+func signedHeaderNames(req *http.Request) []string {
+	auth := req.Header.Get("Authorization")
+	if i := strings.Index(auth, "SignedHeaders="); i >= 0 {
+		return strings.Split(strings.SplitN(auth[i+len("SignedHeaders="):], ",", 2)[0], ";")
+	}
+	return nil
+}
+
+// canonicalHeaders returns the canonical headers block of req for the signed headers.
+func canonicalHeaders(req *http.Request, signed []string) string {
+	var headers strings.Builder
+	for _, name := range signed {
+		headers.WriteString(name + ":" + canonicalHeaderValue(req, name) + "\n")
+	}
+	return headers.String()
+}
+
+// signedPayloadHash returns the payload hash req was signed with: its X-Amz-Content-Sha256 header, or the hash of
+// body.
+func signedPayloadHash(req *http.Request, body []byte) string {
+	if hash := req.Header.Get("X-Amz-Content-Sha256"); hash != "" {
+		return hash
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+func canonicalHeaderValue(req *http.Request, name string) string {
+	switch name {
+	case "host":
+		if req.Host != "" {
+			return req.Host
+		}
+		return req.URL.Host
+	case "content-length":
+		if v := req.Header.Get("Content-Length"); v != "" {
+			return v
+		}
+		return strconv.FormatInt(req.ContentLength, 10)
+	}
+	// Like the signers, only spaces are trimmed and folded; tabs are signed as sent.
+	values := req.Header.Values(name)
+	for i, v := range values {
+		values[i] = strings.Join(strings.FieldsFunc(v, func(r rune) bool { return r == ' ' }), " ")
+	}
+	return strings.Join(values, ",")
+}
+
+func canonicalQuery(q url.Values) string {
+	var pairs []string
+	for k, vs := range q {
+		for _, v := range vs {
+			pairs = append(pairs, escapePath(k, true)+"="+escapePath(v, true))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
@@ -0,0 +1,105 @@
+package aws_signing_client
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+//   ____  _                   _
+//  / ___|(_) __ _ _ __   __ _| |_ _   _ _ __ ___
+//  \___ \| |/ _` | '_ \ / _` | __| | | | '__/ _ \
+//   ___) | | (_| | | | | (_| | |_| |_| | | |  __/
+//  |____/|_|\__, |_| |_|\__,_|\__|\__,_|_|  \___|
+//           |___/
+
+// TestCanonicalRequestMatchesSigner ensures that the canonical request computed for diagnostics is the one the
+// aws-sdk-go signer signs.
+func TestCanonicalRequestMatchesSigner(t *testing.T) {
+	var logged string
+	signer := v4.NewSigner(creds, func(s *v4.Signer) {
+		s.Debug = aws.LogDebugWithSigning
+		s.Logger = aws.LoggerFunc(func(args ...interface{}) { logged = args[0].(string) })
+	})
+	for _, service := range []string{"es", "s3"} {
+		body := []byte(`{"query":{}}`)
+		req, _ := http.NewRequest("POST", "https://example.com/logs%2C2020/_search?size=10&q=level:error%20x&a=", nil)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Add("X-Tenant-Id", "  acme   corp ")
+		req.Header.Add("X-Tenant-Id", "a\tb")
+		if _, err := signer.Sign(req, bytes.NewReader(body), service, "us-east-1", time.Now()); err != nil {
+			t.Fatalf("An unexpected error occurred while signing: %s", err)
+		}
+		want := strings.SplitN(strings.SplitN(logged, "---[ CANONICAL STRING  ]-----------------------------\n", 2)[1], "\n---[ STRING TO SIGN ]", 2)[0]
+		if got := canonicalRequest(req, body); got != want {
+			t.Errorf("Canonical request for %s differs:\n got %q\nwant %q", service, got, want)
+		}
+	}
+}
+
+// sigMismatchClient returns a client with signature diagnostics whose server reports the expected canonical request
+// computed by expect from the client's.
+func sigMismatchClient(t *testing.T, xmlBody bool, expect func(canonical string) string) *http.Client {
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		canonical := expect(canonicalRequest(req, nil))
+		if xmlBody {
+			return response(403, "<Error><Code>SignatureDoesNotMatch</Code><Message>The request signature we calculated does not match the signature you provided.</Message><CanonicalRequest>"+canonical+"</CanonicalRequest></Error>", nil), nil
+		}
+		msg, _ := json.Marshal("The request signature we calculated does not match the signature you provided.\n\nThe Canonical String for this request should have been\n'" + canonical + "'\n\nThe String-to-Sign should have been\n'AWS4-HMAC-SHA256\n...'\n")
+		return response(403, `{"message":`+string(msg)+`}`, http.Header{"X-Amzn-Errortype": {"SignatureDoesNotMatch"}}), nil
+	}, WithSignatureDiagnostics())
+	return c
+}
+
+// TestSignatureDiagnostics ensures that the first mismatching line of the canonical request is reported.
+func TestSignatureDiagnostics(t *testing.T) {
+	c := sigMismatchClient(t, false, func(canonical string) string {
+		lines := strings.Split(canonical, "\n")
+		lines[1] = "/logs%25252C2020"
+		return strings.Join(lines, "\n")
+	})
+	resp, err := c.Get("https://example.com/logs%2C2020")
+	if err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err)
+	}
+	awsErr := ResponseError(resp)
+	switch {
+	case awsErr == nil || awsErr.SignatureMismatch == nil:
+		t.Fatalf("Expected a diagnosed signature mismatch, got %+v", awsErr)
+	case awsErr.SignatureMismatch.Line != 2 || awsErr.SignatureMismatch.Part != "path":
+		t.Errorf("Expected the path to differ, got %s", awsErr.SignatureMismatch)
+	case awsErr.SignatureMismatch.ServerLine != "/logs%25252C2020" || awsErr.SignatureMismatch.ClientLine != "/logs%2C2020":
+		t.Errorf("Unexpected lines: %s", awsErr.SignatureMismatch)
+	}
+}
+
+// TestSignatureDiagnosticsXML ensures that S3-style errors are diagnosed, including matching canonical requests.
+func TestSignatureDiagnosticsXML(t *testing.T) {
+	for name, tc := range map[string]struct {
+		expect func(string) string
+		line   int
+		part   string
+	}{
+		"match": {func(c string) string { return c }, 0, ""},
+		"header": {func(c string) string {
+			return strings.Replace(c, "host:example.com", "host:example.com:443", 1)
+		}, 5, "headers"},
+		"payload": {func(c string) string { return c[:strings.LastIndex(c, "\n")+1] + "UNSIGNED-PAYLOAD" }, 10, "payload hash"},
+	} {
+		c := sigMismatchClient(t, true, tc.expect)
+		resp, err := c.Get("https://example.com/")
+		if err != nil {
+			t.Fatalf("An unexpected error occurred: %s", err)
+		}
+		m := ResponseError(resp).SignatureMismatch
+		if m == nil || m.Line != tc.line || m.Part != tc.part {
+			t.Errorf("%s: unexpected mismatch %+v", name, m)
+		}
+	}
+}
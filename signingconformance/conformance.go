@@ -0,0 +1,233 @@
+// Package signingconformance holds table-driven SigV4 test cases with known-good signatures, so that any
+// aws_signing_client.RequestSigner implementation can prove that it signs requests correctly. The cases cover the
+// request methods, paths, query strings, bodies and header sets that signers most often get wrong; several are taken
+// from the AWS Signature Version 4 test suite.
+//
+// Signers under test must sign with the credentials in AccessKeyID and SecretAccessKey, and no session token:
+//
+//	func TestConformance(t *testing.T) {
+//		signingconformance.Run(t, mySigner(signingconformance.AccessKeyID, signingconformance.SecretAccessKey))
+//	}
+package signingconformance
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Nextdoor/aws_signing_client"
+)
+
+const (
+	// AccessKeyID and SecretAccessKey are the credentials every case is signed with.
+	AccessKeyID     = "AKIDEXAMPLE"
+	SecretAccessKey = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+	// Region is the region every case is signed for.
+	Region = "us-east-1"
+)
+
+// SignTime is the time every case is signed at.
+var SignTime = time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+// Case is a request and the Authorization header a correct signer produces for it.
+type Case struct {
+	Name    string
+	Method  string
+	URL     string
+	Header  http.Header
+	Body    string
+	Service string
+	// Authorization is the expected Authorization header.
+	Authorization string
+}
+
+// Request returns the request of c and its body, ready to be passed to a RequestSigner. A nil body means the
+// request has no payload. Like the AWS test suite, the request carries no Content-Length, which signers may or may
+// not sign.
+func (c Case) Request() (*http.Request, io.ReadSeeker, error) {
+	req, err := http.NewRequest(c.Method, c.URL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	var body io.ReadSeeker
+	if c.Body != "" {
+		body = strings.NewReader(c.Body)
+		req.Body = ioutil.NopCloser(strings.NewReader(c.Body))
+	}
+	for k, v := range c.Header {
+		req.Header[k] = append([]string(nil), v...)
+	}
+	return req, body, nil
+}
+
+// Check signs the request of c with rs and compares the resulting Authorization header with the expected one.
+func Check(rs aws_signing_client.RequestSigner, c Case) error {
+	req, body, err := c.Request()
+	if err != nil {
+		return err
+	}
+	if _, err := rs.Sign(req, body, c.Service, Region, SignTime); err != nil {
+		return fmt.Errorf("signing %s: %w", c.Name, err)
+	}
+	if got := req.Header.Get("Authorization"); got != c.Authorization {
+		return fmt.Errorf("%s: Authorization header differs:\n got %s\nwant %s", c.Name, got, c.Authorization)
+	}
+	return nil
+}
+
+// Run checks rs against every case as a subtest of t.
+func Run(t *testing.T, rs aws_signing_client.RequestSigner) {
+	for _, c := range Cases() {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			if err := Check(rs, c); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// Cases returns the conformance cases. The returned slice may be modified by the caller.
+func Cases() []Case {
+	return []Case{
+		{
+			Name:          "get-vanilla",
+			Method:        "GET",
+			URL:           "https://example.amazonaws.com/",
+			Service:       "service",
+			Authorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			Name:          "get-empty-path",
+			Method:        "GET",
+			URL:           "https://example.amazonaws.com",
+			Service:       "service",
+			Authorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			Name:          "get-query-order",
+			Method:        "GET",
+			URL:           "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			Service:       "service",
+			Authorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+		{
+			Name:          "get-query-repeated-empty-encoded",
+			Method:        "GET",
+			URL:           "https://example.amazonaws.com/?b=2&a=&b=1&c=x%20y&d=%2A~",
+			Service:       "service",
+			Authorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=430493490a3edeb1a5037e3a3c2882793b54cc1bfb93c9c97357233b409f40cf",
+		},
+		{
+			Name:          "get-unreserved",
+			Method:        "GET",
+			URL:           "https://example.amazonaws.com/-._~0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
+			Service:       "service",
+			Authorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=07ef7494c76fa4850883e2b006601f940f8a34d404d0cfa977f52a65bbf5f24f",
+		},
+		{
+			Name:          "get-utf8",
+			Method:        "GET",
+			URL:           "https://example.amazonaws.com/%E1%88%B4",
+			Service:       "service",
+			Authorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=697b34846207a3f72246f99d74ae1ee4fe54f44bb06730c58a0d339eb079596d",
+		},
+		{
+			Name:          "get-space",
+			Method:        "GET",
+			URL:           "https://example.amazonaws.com/example%20space/",
+			Service:       "service",
+			Authorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=446b817944c553435b35e813c261ff4e161fff982d1bacdef1c87f6785dd1662",
+		},
+		{
+			Name:          "get-reserved-path",
+			Method:        "GET",
+			URL:           "https://example.amazonaws.com/a%2Bb/c%2Cd/e%3Df",
+			Service:       "service",
+			Authorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=11bca384aaec1aa539263ca563f2c2e1777bf955431d98e94378454a5d53e611",
+		},
+		{
+			Name:          "get-s3-path",
+			Method:        "GET",
+			URL:           "https://bucket.s3.amazonaws.com/photos/2015/08/30/ex%20ample.jpg",
+			Service:       "s3",
+			Authorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=9749b5d3954b3f69389d002967185216ae9e41f19c32647fa4b96740b8a23af5",
+		},
+		{
+			Name:          "get-header-value-trim",
+			Method:        "GET",
+			URL:           "https://example.amazonaws.com/",
+			Header:        http.Header{"My-Header1": {" value1"}, "My-Header2": {` "a   b   c" `}},
+			Service:       "service",
+			Authorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;my-header1;my-header2;x-amz-date, Signature=acc3ed3afb60bb290fc8d2dd0098b9911fcaa05412b367055dee359757a9c736",
+		},
+		{
+			Name:          "get-header-value-multiline",
+			Method:        "GET",
+			URL:           "https://example.amazonaws.com/",
+			Header:        http.Header{"My-Header1": {"value1", "value2", "value3"}},
+			Service:       "service",
+			Authorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;my-header1;x-amz-date, Signature=ba17b383a53190154eb5fa66a1b836cc297cc0a3d70a5d00705980573d8ff790",
+		},
+		{
+			Name:          "get-header-key-case",
+			Method:        "GET",
+			URL:           "https://example.amazonaws.com/",
+			Header:        http.Header{"My-Header1": {"value1"}, "MY-HEADER2": {"value2"}},
+			Service:       "service",
+			Authorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;my-header1;my-header2;x-amz-date, Signature=bfd8a034f49004f6aadef04a23a5af22260f1249608083db22f7fb479c9fa9cb",
+		},
+		{
+			Name:          "post-vanilla-query",
+			Method:        "POST",
+			URL:           "https://example.amazonaws.com/?Param1=value1",
+			Service:       "service",
+			Authorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=28038455d6de14eafc1f9222cf5aa6f1a96197d7deb8263271d420d138af7f11",
+		},
+		{
+			Name:          "post-x-www-form-urlencoded",
+			Method:        "POST",
+			URL:           "https://example.amazonaws.com/",
+			Header:        http.Header{"Content-Type": {"application/x-www-form-urlencoded"}},
+			Body:          "Param1=value1",
+			Service:       "service",
+			Authorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=content-type;host;x-amz-date, Signature=ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a",
+		},
+		{
+			Name:          "put-json",
+			Method:        "PUT",
+			URL:           "https://my-domain.us-east-1.es.amazonaws.com/logs/_doc/1",
+			Header:        http.Header{"Content-Type": {"application/json"}},
+			Body:          `{"message":"hello"}`,
+			Service:       "es",
+			Authorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/es/aws4_request, SignedHeaders=content-type;host;x-amz-date, Signature=8276e72d8dbc4ff98cce44dc5c3b804e1dd4d4c98c0a09553ff4bd91ec39479c",
+		},
+		{
+			Name:          "delete",
+			Method:        "DELETE",
+			URL:           "https://my-domain.us-east-1.es.amazonaws.com/logs",
+			Service:       "es",
+			Authorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/es/aws4_request, SignedHeaders=host;x-amz-date, Signature=dcdd15ace5044a457218cde7d505e2b42cf68dfec5d3eecd69f1ff61be96cdc2",
+		},
+		{
+			Name:          "head",
+			Method:        "HEAD",
+			URL:           "https://my-domain.us-east-1.es.amazonaws.com/logs",
+			Service:       "es",
+			Authorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/es/aws4_request, SignedHeaders=host;x-amz-date, Signature=7af86c70b06daa5d6981de40f9ae5e1eea1e0264a3aca6e09b82a5bed8ba8e93",
+		},
+		{
+			Name:          "patch-unsigned-payload",
+			Method:        "PATCH",
+			URL:           "https://example.amazonaws.com/items/1",
+			Header:        http.Header{"X-Amz-Content-Sha256": {"UNSIGNED-PAYLOAD"}},
+			Body:          `{"name":"new"}`,
+			Service:       "execute-api",
+			Authorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/execute-api/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=9cf1f35546b7adfdcfa96f761ae7714d3171e0b8d133cfe3549837584cfdee11",
+		},
+	}
+}
@@ -0,0 +1,20 @@
+package signingconformance
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+//    ____             __
+//   / ___|___  _ __  / _| ___  _ __ _ __ ___   __ _ _ __   ___ ___
+//  | |   / _ \| '_ \| |_ / _ \| '__| '_ ` _ \ / _` | '_ \ / __/ _ \
+//  | |__| (_) | | | |  _| (_) | |  | | | | | | (_| | | | | (_|  __/
+//   \____\___/|_| |_|_|  \___/|_|  |_| |_| |_|\__,_|_| |_|\___\___|
+//
+
+// TestSDKV1 ensures that the aws-sdk-go signer passes every case.
+func TestSDKV1(t *testing.T) {
+	Run(t, v4.NewSigner(credentials.NewStaticCredentials(AccessKeyID, SecretAccessKey, "")))
+}
@@ -0,0 +1,48 @@
+package aws_signing_client
+
+import (
+	"context"
+	"net/http"
+)
+
+// SkipReason says why the Signer sent a request without signing it, as passed to Hooks.OnSkip.
+type SkipReason string
+
+const (
+	// SkipAlreadySigned is reported for requests that already carried a SigV4 Authorization header, which is
+	// sent as is and may be stale.
+	SkipAlreadySigned SkipReason = "already_signed"
+	// SkipContext is reported for requests whose context was marked with WithoutSigning.
+	SkipContext SkipReason = "context"
+	// SkipRedirect is reported for redirects to another host made with WithRedirectResigning.
+	SkipRedirect SkipReason = "redirect"
+	// SkipAnonymous is reported for requests sent unsigned by AnonymousPassThrough.
+	SkipAnonymous SkipReason = "anonymous"
+	// SkipUpgrade is reported for CONNECT requests and protocol upgrades sent unsigned by WithUnsignedUpgrades.
+	SkipUpgrade SkipReason = "upgrade"
+)
+
+type skipSigningKey struct{}
+
+// WithoutSigning returns a copy of ctx that makes the Signer send a request made with it unsigned, e.g. for
+// health checks against public endpoints. Such requests are reported to Hooks.OnSkip with SkipContext.
+func WithoutSigning(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipSigningKey{}, true)
+}
+
+func signingSkipped(ctx context.Context) bool {
+	skip, _ := ctx.Value(skipSigningKey{}).(bool)
+	return skip
+}
+
+// sendUnsigned reports a skipped request to the Signer's hooks and the request's Stats, if any, and sends it
+// unsigned.
+func (s *Signer) sendUnsigned(ctx context.Context, req *http.Request, reason SkipReason) (*http.Response, error) {
+	if stats := requestStats(ctx); stats != nil {
+		stats.Skipped = reason
+	}
+	for _, h := range s.hooks {
+		h.OnSkip(ctx, req, reason)
+	}
+	return s.transportFor(req.URL.Hostname()).RoundTrip(req)
+}
@@ -0,0 +1,99 @@
+package aws_signing_client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+)
+
+// payload is the body of a request, buffered so that it can be hashed for the signature and replayed on every
+// attempt, either in memory or, when spooled, in a temporary file.
+type payload struct {
+	data []byte
+	file *os.File
+	size int64
+}
+
+// WithBodySpooling makes request bodies that are larger than the limit set with WithMaxBufferedBody be written to a
+// temporary file in dir, or the default directory for temporary files if dir is empty, instead of failing. The body is
+// hashed while it is written, unless its hash was given with WithPayloadHash; the hash is sent as the
+// X-Amz-Content-Sha256 header rather than computed again by the signer, and every attempt streams the body from disk
+// with its Content-Length set. Multi-gigabyte payloads can thereby be signed with bounded memory. The file is removed
+// once the request has been sent.
+func WithBodySpooling(dir string) Option {
+	return func(s *Signer) {
+		s.spoolBodies, s.spoolDir = true, dir
+	}
+}
+
+// spool writes body to a temporary file, setting the payload hash and Content-Length of req.
+func (s *Signer) spool(ctx context.Context, req *http.Request, body io.Reader) (*payload, error) {
+	defer req.Body.Close()
+	f, err := ioutil.TempFile(s.spoolDir, "aws_signing_client-")
+	if err != nil {
+		return nil, err
+	}
+	p := &payload{file: f}
+	hash, ok := ctx.Value(payloadHashKey{}).(string)
+	h := sha256.New()
+	w := io.MultiWriter(f, h)
+	if ok {
+		w = f
+	}
+	if p.size, err = io.Copy(w, body); err != nil {
+		p.close()
+		return nil, err
+	}
+	s.logf(ctx, "Request body exceeds %d bytes. Spooled %d bytes to '%s'.", s.maxBufferedBody, p.size, f.Name())
+	if !ok {
+		hash = hex.EncodeToString(h.Sum(nil))
+	}
+	req.Header.Set("X-Amz-Content-Sha256", hash)
+	return p, nil
+}
+
+// reader returns a new reader over the whole payload.
+func (p *payload) reader() io.ReadSeeker {
+	if p.file != nil {
+		return io.NewSectionReader(p.file, 0, p.size)
+	}
+	return bytes.NewReader(p.data)
+}
+
+// body returns a new request body over the whole payload. An empty payload is http.NoBody, so that the transport
+// sends it without chunked encoding, whatever the method.
+func (p *payload) body() io.ReadCloser {
+	if p.len() == 0 {
+		return http.NoBody
+	}
+	return ioutil.NopCloser(p.reader())
+}
+
+// len returns the size of the payload.
+func (p *payload) len() int64 {
+	if p.file != nil {
+		return p.size
+	}
+	return int64(len(p.data))
+}
+
+// bytes returns the payload if it is held in memory, or nil.
+func (p *payload) bytes() []byte {
+	if p == nil {
+		return nil
+	}
+	return p.data
+}
+
+// close removes the temporary file of a spooled payload.
+func (p *payload) close() {
+	if p.file != nil {
+		p.file.Close()
+		os.Remove(p.file.Name())
+	}
+}
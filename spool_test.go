@@ -0,0 +1,48 @@
+package aws_signing_client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestWithBodySpooling ensures that large bodies are spooled to disk, signed with their hash, replayed on retries
+// and removed afterwards.
+func TestWithBodySpooling(t *testing.T) {
+	dir := t.TempDir()
+	var bodies []string
+	var sent *http.Request
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		sent = req
+		d, _ := ioutil.ReadAll(req.Body)
+		bodies = append(bodies, string(d))
+		if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+			t.Errorf("Expected the body to be spooled to one file, found %d", len(files))
+		}
+		status := 200
+		if len(bodies) == 1 {
+			status = 503
+		}
+		return &http.Response{StatusCode: status, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+	}, WithMaxBufferedBody(8), WithBodySpooling(dir), WithRetries(2))
+
+	const body = "0123456789abcdef"
+	if _, err := c.Post("https://example.com/", "text/plain", ioutil.NopCloser(strings.NewReader(body))); err != nil {
+		t.Fatalf("An unexpected error occurred while making a request: %s", err)
+	}
+	sum := sha256.Sum256([]byte(body))
+	switch {
+	case len(bodies) != 2 || bodies[0] != body || bodies[1] != body:
+		t.Errorf("Expected the body to be sent on both attempts, got %q", bodies)
+	case sent.ContentLength != int64(len(body)):
+		t.Errorf("Expected a Content-Length of %d, got %d", len(body), sent.ContentLength)
+	case sent.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]):
+		t.Errorf("Unexpected payload hash: %s", sent.Header.Get("X-Amz-Content-Sha256"))
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Errorf("Spooled file was not removed: %d files left", len(files))
+	}
+}
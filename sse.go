@@ -0,0 +1,216 @@
+package aws_signing_client
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// EventSubscriber receives Server-Sent Events from a long-lived GET request sent over an *http.Client, typically
+	// one returned by New, e.g. for event-streaming endpoints behind an IAM-authorized API Gateway. Every connection,
+	// including reconnects, is a new request and so is signed with a fresh timestamp and the current credentials.
+	EventSubscriber struct {
+		// Client is the HTTP client used to send requests. If nil, http.DefaultClient is used.
+		Client *http.Client
+		// URL is the URL of the event stream.
+		URL string
+		// Header holds headers that are added to every request before it is signed.
+		Header http.Header
+		// Backoff decides the delay before reconnecting after the stream ended or a reconnect failed. A "retry"
+		// field sent by the server sets the minimum delay. If nil, ExponentialBackoff is used.
+		Backoff Backoff
+		// OnError, if set, is called with the error that ended a connection or failed a reconnect. The subscriber
+		// keeps reconnecting regardless.
+		OnError func(err error)
+	}
+
+	// ServerEvent is an event received by an EventSubscriber.
+	ServerEvent struct {
+		// ID is the last event ID sent by the server, which is also sent as Last-Event-ID when reconnecting.
+		ID string
+		// Type is the event's type, "message" unless the server named it.
+		Type string
+		// Data is the event's data, with the lines of multi-line data joined by "\n".
+		Data string
+	}
+
+	// eventStream holds the state an event stream keeps across connections.
+	eventStream struct {
+		lastID string
+		retry  time.Duration
+	}
+)
+
+// errStreamClosed is returned by connect when the server answered with 204 No Content, which asks the client to stop
+// reconnecting.
+var errStreamClosed = errors.New("event stream closed by the server")
+
+// NewEventSubscriber returns an EventSubscriber that receives events from url using the provided client.
+func NewEventSubscriber(client *http.Client, url string) *EventSubscriber {
+	return &EventSubscriber{Client: client, URL: url, Header: http.Header{}}
+}
+
+// Subscribe connects to the event stream and returns a channel its events are delivered on. The first connection is
+// made before Subscribe returns, and its failure is returned, non-2xx responses as an *AWSError. Afterwards, whenever
+// the connection ends or fails, the subscriber reconnects with the Last-Event-ID of the last event. The channel is
+// closed when ctx is done or the server answers a reconnect with 204 No Content.
+func (es *EventSubscriber) Subscribe(ctx context.Context) (<-chan ServerEvent, error) {
+	stream := &eventStream{}
+	body, err := es.connect(ctx, stream.lastID)
+	if err != nil {
+		return nil, err
+	}
+	events := make(chan ServerEvent)
+	go es.run(ctx, stream, body, events)
+	return events, nil
+}
+
+// run reads events from body into events, and reconnects whenever the stream ends, until ctx is done or the server
+// closes the stream.
+func (es *EventSubscriber) run(ctx context.Context, stream *eventStream, body io.ReadCloser, events chan<- ServerEvent) {
+	defer close(events)
+	backoff := newBackoff(es.Backoff)
+
+	attempt := 0
+	for {
+		var err error
+		if body != nil {
+			err = stream.read(ctx, body, events)
+			body.Close()
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				es.report(err)
+			}
+		}
+
+		attempt++
+		delay := backoff.NextDelay(attempt, err, nil)
+		if delay < stream.retry {
+			delay = stream.retry
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+
+		switch body, err = es.connect(ctx, stream.lastID); {
+		case err == errStreamClosed || ctx.Err() != nil:
+			return
+		case err != nil:
+			es.report(err)
+		default:
+			attempt = 0
+		}
+	}
+}
+
+// connect sends a request for the event stream, returning the body of a 2xx response.
+func (es *EventSubscriber) connect(ctx context.Context, lastID string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, es.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range es.Header {
+		req.Header[k] = append([]string(nil), v...)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+
+	client := es.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNoContent {
+		resp.Body.Close()
+		return nil, errStreamClosed
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		d, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return nil, responseError(resp, d)
+	}
+	return resp.Body, nil
+}
+
+func (es *EventSubscriber) report(err error) {
+	if es.OnError != nil {
+		es.OnError(err)
+	}
+}
+
+// read parses the event stream in body and delivers its events until the stream ends, returning nil if it ended
+// cleanly.
+func (stream *eventStream) read(ctx context.Context, body io.Reader, events chan<- ServerEvent) error {
+	r := bufio.NewReader(body)
+	var typ string
+	var data []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+
+		if line == "" {
+			if data != nil {
+				ev := ServerEvent{ID: stream.lastID, Type: typ, Data: strings.Join(data, "\n")}
+				if ev.Type == "" {
+					ev.Type = "message"
+				}
+				select {
+				case events <- ev:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			typ, data = "", nil
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value := line, ""
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch field {
+		case "event":
+			typ = value
+		case "data":
+			data = append(data, value)
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				stream.lastID = value
+			}
+		case "retry":
+			if ms, err := strconv.ParseUint(value, 10, 32); err == nil {
+				stream.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
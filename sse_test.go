@@ -0,0 +1,77 @@
+package aws_signing_client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+//   ____ ____  _____
+//  / ___/ ___|| ____|
+//  \___ \___ \|  _|
+//   ___) |__) | |___
+//  |____/____/|_____|
+//
+
+// TestEventSubscriber ensures that events are parsed and that the subscriber reconnects with a new signature and the
+// last event ID until the server closes the stream.
+func TestEventSubscriber(t *testing.T) {
+	bodies := []string{
+		": keep-alive\nid: 1\ndata: a\n\nevent: update\ndata: b\r\ndata: c\r\n\r\n",
+		"retry: 1\nid: 2\ndata: d\n\n",
+	}
+	var lastIDs, signatures []string
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("Accept") != "text/event-stream" {
+			t.Errorf("Unexpected Accept header: %s", req.Header.Get("Accept"))
+		}
+		lastIDs = append(lastIDs, req.Header.Get("Last-Event-ID"))
+		signatures = append(signatures, req.Header.Get("Authorization"))
+		if len(lastIDs) > len(bodies) {
+			return response(http.StatusNoContent, "", nil), nil
+		}
+		return response(200, bodies[len(lastIDs)-1], nil), nil
+	})
+
+	es := NewEventSubscriber(c, "https://api.example.com/events")
+	es.Backoff = ExponentialBackoff{Base: time.Millisecond, Max: time.Millisecond}
+	events, err := es.Subscribe(context.Background())
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while subscribing: %s", err)
+	}
+	var got []ServerEvent
+	for ev := range events {
+		got = append(got, ev)
+	}
+
+	expected := []ServerEvent{{"1", "message", "a"}, {"1", "update", "b\nc"}, {"2", "message", "d"}}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %d events, got %v", len(expected), got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Expected event %d to be %v, got %v", i, expected[i], got[i])
+		}
+	}
+	if strings.Join(lastIDs, ",") != ",1,2" {
+		t.Errorf("Unexpected Last-Event-ID headers: %q", lastIDs)
+	}
+	for _, sig := range signatures {
+		if !strings.Contains(sig, "/es/aws4_request") {
+			t.Errorf("Connection was not signed: %q", sig)
+		}
+	}
+}
+
+// TestEventSubscriberError ensures that a failed first connection is returned.
+func TestEventSubscriberError(t *testing.T) {
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		return response(403, `{"message":"Forbidden"}`, nil), nil
+	})
+	_, err := NewEventSubscriber(c, "https://api.example.com/events").Subscribe(context.Background())
+	if awsErr, ok := err.(*AWSError); !ok || awsErr.StatusCode != 403 {
+		t.Errorf("Expected an *AWSError with status 403, got %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package aws_signing_client
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// Stats collects the outcome of a request sent through a Signer, similar to net/http/httptrace. Attach it to a
+	// request's context with WithRequestStats and read it once the request has returned. A Stats must not be shared
+	// by concurrent requests; values accumulate if it is reused for sequential ones.
+	Stats struct {
+		// Attempts is the number of times the request was signed and sent.
+		Attempts int
+		// SignLatency is the total time spent signing, across attempts.
+		SignLatency time.Duration
+		// SendLatency is the total time spent in the wrapped transport, across attempts.
+		SendLatency time.Duration
+		// RetryReasons holds the failure of each attempt that was retried, in order.
+		RetryReasons []error
+		// StatusCode is the status code of the last response, or 0 if no response was received.
+		StatusCode int
+		// Skipped is why the request was sent without being signed, or empty if it was signed.
+		Skipped SkipReason
+	}
+
+	statsKey struct{}
+)
+
+// WithRequestStats returns a copy of ctx that makes the Signer record the outcome of requests sent with it in stats.
+func WithRequestStats(ctx context.Context, stats *Stats) context.Context {
+	return context.WithValue(ctx, statsKey{}, stats)
+}
+
+func requestStats(ctx context.Context) *Stats {
+	stats, _ := ctx.Value(statsKey{}).(*Stats)
+	return stats
+}
+
+// recordAttempt adds an attempt with timing to the request's Stats, if any.
+func recordAttempt(ctx context.Context, timing RequestTiming) {
+	if stats := requestStats(ctx); stats != nil {
+		stats.Attempts++
+		stats.SignLatency += timing.Sign
+		stats.SendLatency += timing.Send
+		stats.StatusCode = timing.StatusCode
+	}
+}
+
+// recordRetry adds the reason an attempt was retried to the request's Stats, if any.
+func recordRetry(ctx context.Context, cause error) {
+	if stats := requestStats(ctx); stats != nil {
+		stats.RetryReasons = append(stats.RetryReasons, cause)
+	}
+}
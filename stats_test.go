@@ -0,0 +1,53 @@
+package aws_signing_client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+//   ____  _        _
+//  / ___|| |_ __ _| |_ ___
+//  \___ \| __/ _` | __/ __|
+//   ___) | || (_| | |_\__ \
+//  |____/ \__\__,_|\__|___/
+//
+
+// TestWithRequestStats ensures that the attempts, latencies and retry reasons of a request are collected.
+func TestWithRequestStats(t *testing.T) {
+	responses := []*http.Response{
+		response(400, `{"__type":"ThrottlingException","message":"Rate exceeded"}`, nil),
+		response(200, "", nil),
+	}
+	attempt := 0
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		attempt++
+		return responses[attempt-1], nil
+	}, WithRetries(3), fastRetries)
+
+	var stats Stats
+	req, _ := http.NewRequestWithContext(WithRequestStats(context.Background(), &stats), "GET", "https://example.com/", nil)
+	if _, err := c.Do(req); err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err)
+	}
+	var awsErr *AWSError
+	switch {
+	case stats.Attempts != 2 || stats.StatusCode != 200:
+		t.Errorf("Unexpected attempts or status: %+v", stats)
+	case stats.SignLatency <= 0 || stats.SendLatency < 0:
+		t.Errorf("Latencies were not recorded: %+v", stats)
+	case len(stats.RetryReasons) != 1 || !errors.As(stats.RetryReasons[0], &awsErr) || awsErr.Code != "ThrottlingException":
+		t.Errorf("Unexpected retry reasons: %v", stats.RetryReasons)
+	}
+}
+
+// TestWithRequestStatsWithoutCollector ensures that requests without a collector are unaffected.
+func TestWithRequestStatsWithoutCollector(t *testing.T) {
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		return response(200, "", nil), nil
+	})
+	if _, err := c.Get("https://example.com/"); err != nil {
+		t.Errorf("An unexpected error occurred: %s", err)
+	}
+}
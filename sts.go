@@ -0,0 +1,96 @@
+package aws_signing_client
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type (
+	// CallerIdentity is the identity the Signer's credentials belong to, as returned by STS GetCallerIdentity.
+	CallerIdentity struct {
+		Account string `xml:"GetCallerIdentityResult>Account"`
+		ARN     string `xml:"GetCallerIdentityResult>Arn"`
+		UserID  string `xml:"GetCallerIdentityResult>UserId"`
+	}
+
+	// InvalidCredentialsError is an implementation of the error interface that is returned by
+	// Signer.VerifyCredentials when the credentials cannot be retrieved or are rejected by STS.
+	InvalidCredentialsError struct {
+		// Err is the error of the credential provider, or an *AWSError with the response of STS.
+		Err error
+	}
+)
+
+// rejectedCredentialCodes are the error codes with which STS rejects credentials.
+var rejectedCredentialCodes = map[string]bool{
+	"AccessDenied":          true,
+	"ExpiredToken":          true,
+	"InvalidClientTokenId":  true,
+	"SignatureDoesNotMatch": true,
+}
+
+// VerifyCredentials makes a signed STS GetCallerIdentity call to the regional STS endpoint of the client's region and
+// returns the identity the credentials belong to, so that a service can fail at startup with a clear message when its
+// role or keys are broken. Credentials that cannot be retrieved or are rejected are reported as an
+// *InvalidCredentialsError; other error responses, such as throttling or server errors, are returned as an *AWSError
+// and network errors as they are. GetCallerIdentity requires no permissions.
+func (s *Signer) VerifyCredentials(ctx context.Context) (*CallerIdentity, error) {
+	sc := s.loadScope()
+	const body = "Action=GetCallerIdentity&Version=2011-06-15"
+	host := s.partition(sc.region).Host("sts", sc.region)
+	if !s.hostAllowed(host) {
+		return nil, &DisallowedHostError{Host: host}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://"+host+"/", strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	req.Header.Set("Accept", "text/xml")
+	if _, err := sc.signer.Sign(req, strings.NewReader(body), "sts", sc.region, time.Now()); err != nil {
+		s.logf(ctx, "Error while attempting to sign credentials check: '%s'", err)
+		return nil, &InvalidCredentialsError{Err: err}
+	}
+
+	resp, err := s.transportFor(host).RoundTrip(req)
+	if err != nil {
+		s.logf(ctx, "Error while verifying credentials: %s", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+	d, err := ioutil.ReadAll(io.LimitReader(resp.Body, MaxErrorBodySize))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		awsErr := NewAWSError(resp, d)
+		if resp.StatusCode < 400 || resp.StatusCode > 499 || !rejectedCredentialCodes[awsErr.Code] {
+			s.logf(ctx, "Error while verifying credentials: %s", awsErr)
+			return nil, awsErr
+		}
+		s.logf(ctx, "Credentials were rejected by STS: %s", awsErr)
+		return nil, &InvalidCredentialsError{Err: awsErr}
+	}
+	id := &CallerIdentity{}
+	if err := xml.Unmarshal(d, id); err != nil {
+		return nil, fmt.Errorf("decoding GetCallerIdentity response: %w", err)
+	}
+	s.logf(ctx, "Verified credentials of '%s'.", id.ARN)
+	return id, nil
+}
+
+// Error implements the error interface.
+func (err *InvalidCredentialsError) Error() string {
+	return fmt.Sprintf("The signer's credentials could not be verified with STS: %s", err.Err)
+}
+
+// Unwrap returns the underlying error.
+func (err *InvalidCredentialsError) Unwrap() error {
+	return err.Err
+}
@@ -0,0 +1,61 @@
+package aws_signing_client
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// TestVerifyCredentials ensures that GetCallerIdentity is called on the regional STS endpoint and its result returned.
+func TestVerifyCredentials(t *testing.T) {
+	var sent *http.Request
+	status := 200
+	c, err := New(v4.NewSigner(creds), &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent = req
+		if status != 200 {
+			return &http.Response{StatusCode: status, Header: http.Header{}, Body: ioutil.NopCloser(strings.NewReader(
+				`<ErrorResponse><Error><Code>InvalidClientTokenId</Code><Message>The security token included in the request is invalid.</Message></Error></ErrorResponse>`))}, nil
+		}
+		return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(
+			`<GetCallerIdentityResponse><GetCallerIdentityResult><Arn>arn:aws:iam::123456789012:user/app</Arn><UserId>AIDA</UserId><Account>123456789012</Account></GetCallerIdentityResult></GetCallerIdentityResponse>`))}, nil
+	})}, "es", "eu-west-1", nil)
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+	s := c.Transport.(*Signer)
+
+	id, err := s.VerifyCredentials(context.Background())
+	switch {
+	case err != nil:
+		t.Fatalf("An unexpected error occurred while verifying credentials: %s", err)
+	case *id != CallerIdentity{Account: "123456789012", ARN: "arn:aws:iam::123456789012:user/app", UserID: "AIDA"}:
+		t.Errorf("Unexpected identity: %+v", id)
+	case sent.URL.Host != "sts.eu-west-1.amazonaws.com" || !strings.Contains(sent.Header.Get("Authorization"), "/eu-west-1/sts/aws4_request"):
+		t.Errorf("Unexpected request to %s: %s", sent.URL, sent.Header.Get("Authorization"))
+	}
+
+	status = 403
+	_, err = s.VerifyCredentials(context.Background())
+	var ice *InvalidCredentialsError
+	var awsErr *AWSError
+	if !errors.As(err, &ice) || !errors.As(err, &awsErr) || awsErr.Code != "InvalidClientTokenId" {
+		t.Errorf("Expected an *InvalidCredentialsError wrapping an *AWSError, got %v", err)
+	}
+
+	status = 503
+	_, err = s.VerifyCredentials(context.Background())
+	if errors.As(err, &ice) || !errors.As(err, &awsErr) || awsErr.StatusCode != 503 {
+		t.Errorf("Expected an *AWSError for a server error, got %v", err)
+	}
+
+	s.Reload(v4.NewSigner(credentials.NewStaticCredentials("", "", "")), "es", "eu-west-1")
+	if _, err := s.VerifyCredentials(context.Background()); !errors.As(err, &ice) {
+		t.Errorf("Expected an *InvalidCredentialsError for empty credentials, got %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package aws_signing_client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+type (
+	// scopedTransport is the transport of a Client derived with WithScope or WithPathPrefix. It sends requests
+	// through the parent's Signer, marked with the derived scope and with the path prefix prepended.
+	scopedTransport struct {
+		signer     *Signer
+		scope      atomic.Value // scopeValues
+		pathPrefix string
+	}
+
+	// scopeValues are the service and region of a derived Client.
+	scopeValues struct {
+		service string
+		region  string
+	}
+
+	scopeKey struct{}
+)
+
+// WithScope returns a Client that signs its requests for service and region, e.g. to call another AWS API through
+// the same connection pool. It shares the Signer of c, with its transport, hooks and other options, and the settings
+// of the underlying *http.Client, so deriving a Client is cheap. Its requests are signed with the current
+// RequestSigner of c, but without the signing name and region set with WithSigningName and WithSigningRegion, and
+// are not spread by WithRegions. The path prefix of c, if any, is kept.
+func (c *Client) WithScope(service, region string) (*Client, error) {
+	switch {
+	case service == "":
+		return nil, MissingServiceError{}
+	case region == "":
+		return nil, MissingRegionError{}
+	}
+	prefix := ""
+	if c.sub != nil {
+		prefix = c.sub.pathPrefix
+	}
+	return c.derive(&scopeValues{service: service, region: region}, prefix), nil
+}
+
+// WithPathPrefix returns a Client that prepends prefix to the path of its requests, e.g. "/2015-03-31" for the
+// Lambda API, so that callers pass paths relative to it. It shares the Signer of c, as WithScope does, and signs for
+// the same scope as c.
+func (c *Client) WithPathPrefix(prefix string) *Client {
+	var v *scopeValues
+	if c.sub != nil {
+		if sv, ok := c.sub.scope.Load().(scopeValues); ok {
+			v = &sv
+		}
+		prefix = c.sub.pathPrefix + prefix
+	}
+	return c.derive(v, strings.TrimSuffix(prefix, "/"))
+}
+
+// derive returns a Client that sends requests through the Signer of c with scope v, or the Signer's scope if v is
+// nil, and prefix.
+func (c *Client) derive(v *scopeValues, prefix string) *Client {
+	t := &scopedTransport{signer: c.signer, pathPrefix: prefix}
+	if v != nil {
+		t.scope.Store(*v)
+	}
+	hc := *c.http
+	hc.Transport = t
+	return &Client{http: &hc, signer: c.signer, sub: t}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *scopedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if v, ok := t.scope.Load().(scopeValues); ok {
+		req = req.WithContext(context.WithValue(req.Context(), scopeKey{}, v))
+	} else if t.pathPrefix != "" {
+		req = req.WithContext(req.Context())
+	}
+	t.addPrefix(req)
+	return t.signer.RoundTrip(req)
+}
+
+// addPrefix prepends the path prefix to the path of req, replacing its URL rather than modifying it. Redirect hops
+// keep their path.
+func (t *scopedTransport) addPrefix(req *http.Request) {
+	if t.pathPrefix == "" || req.Response != nil {
+		return
+	}
+	u := *req.URL
+	u.Path = t.pathPrefix + u.Path
+	if u.RawPath != "" {
+		u.RawPath = t.pathPrefix + u.RawPath
+	}
+	req.URL = &u
+}
+
+// scopeFor returns the scope the requests of c are signed for.
+func (c *Client) scopeFor() *signingScope {
+	sc := c.signer.loadScope()
+	if c.sub == nil {
+		return sc
+	}
+	if v, ok := c.sub.scope.Load().(scopeValues); ok {
+		return v.apply(sc)
+	}
+	return sc
+}
+
+// scopeOverride returns the scope of the derived Client a request made with ctx was sent by, or sc.
+func scopeOverride(ctx context.Context, sc *signingScope) *signingScope {
+	if v, ok := ctx.Value(scopeKey{}).(scopeValues); ok {
+		return v.apply(sc)
+	}
+	return sc
+}
+
+// apply returns the scope of a derived Client whose parent's scope is sc.
+func (v scopeValues) apply(sc *signingScope) *signingScope {
+	return &signingScope{signer: sc.signer, service: v.service, region: v.region, derived: true}
+}
@@ -0,0 +1,69 @@
+package aws_signing_client
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// TestDerivedClients ensures that clients derived with WithScope and WithPathPrefix share the Signer but sign for
+// their own scope and prefix their paths, without affecting the parent.
+func TestDerivedClients(t *testing.T) {
+	var sent []*http.Request
+	rh := &recordingHooks{}
+	c, err := NewClient(v4.NewSigner(creds), &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent = append(sent, req)
+		return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+	})}, "es", "us-east-1", nil, WithSigningName("aoss"), WithHooks(rh))
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+
+	lambda, err := c.WithScope("lambda", "eu-west-1")
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while deriving a client: %s", err)
+	}
+	functions := lambda.WithPathPrefix("/2015-03-31/")
+	for _, tc := range []struct {
+		c     *Client
+		url   string
+		path  string
+		scope string
+	}{
+		{c, "https://search.example.com/_search", "/_search", "/us-east-1/aoss/"},
+		{lambda, "https://lambda.eu-west-1.amazonaws.com/2015-03-31/functions", "/2015-03-31/functions", "/eu-west-1/lambda/"},
+		{functions, "https://lambda.eu-west-1.amazonaws.com/functions/f/invocations", "/2015-03-31/functions/f/invocations", "/eu-west-1/lambda/"},
+		{c.WithPathPrefix("/v1"), "https://search.example.com/_search", "/v1/_search", "/us-east-1/aoss/"},
+	} {
+		req, _ := http.NewRequest(http.MethodGet, tc.url, nil)
+		if _, err := tc.c.Do(req); err != nil {
+			t.Fatalf("An unexpected error occurred while making a request: %s", err)
+		}
+		got := sent[len(sent)-1]
+		if got.URL.Path != tc.path || !strings.Contains(got.Header.Get("Authorization"), tc.scope) {
+			t.Errorf("%s: expected %s signed for %s, got %s signed with %s", tc.url, tc.path, tc.scope, got.URL.Path, got.Header.Get("Authorization"))
+		}
+		if req.URL.String() != tc.url {
+			t.Errorf("%s: the caller's request was changed to %s", tc.url, req.URL)
+		}
+	}
+	if len(rh.requests) != 4 {
+		t.Errorf("Expected the hooks to see every request, got %d", len(rh.requests))
+	}
+
+	if err := functions.SetScope("lambda", "us-west-2"); err != nil {
+		t.Fatalf("An unexpected error occurred while changing the scope: %s", err)
+	}
+	if o := functions.Options(); o.Region != "us-west-2" || o.SigningName != "lambda" {
+		t.Errorf("Unexpected options of the derived client: %+v", o)
+	}
+	if o := lambda.Options(); o.Region != "eu-west-1" {
+		t.Errorf("Expected the scope of the other derived client to be unchanged, got %+v", o)
+	}
+	if o := c.Options(); o.Service != "es" || o.SigningName != "aoss" {
+		t.Errorf("Expected the scope of the parent to be unchanged, got %+v", o)
+	}
+}
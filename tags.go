@@ -0,0 +1,88 @@
+package aws_signing_client
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+type (
+	requestTagsKey struct{}
+
+	// LogFieldExtractor returns fields to attach to a request made with ctx, e.g. a request ID, trace ID or tenant
+	// taken from values the caller's middleware stored in the context. It may return nil.
+	LogFieldExtractor func(ctx context.Context) map[string]string
+)
+
+// WithRequestTags returns a copy of ctx that carries tags, in addition to any tags already attached to ctx; on
+// conflict the new value wins. The Signer includes the tags of a request's context in its log lines, in the
+// RequestTiming passed to slow-request callbacks and in every Hooks event, where they can be used as metric labels.
+func WithRequestTags(ctx context.Context, tags map[string]string) context.Context {
+	merged := make(map[string]string, len(tags))
+	for k, v := range RequestTags(ctx) {
+		merged[k] = v
+	}
+	for k, v := range tags {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, requestTagsKey{}, merged)
+}
+
+// RequestTags returns the tags attached to ctx with WithRequestTags, or nil. The returned map must not be modified.
+func RequestTags(ctx context.Context) map[string]string {
+	tags, _ := ctx.Value(requestTagsKey{}).(map[string]string)
+	return tags
+}
+
+// WithLogFields registers extractors that run once per request and add the fields they return to the request's
+// tags, so that they appear in every log line, RequestTiming and Hooks event of the request. Tags attached with
+// WithRequestTags take precedence over extracted fields with the same key.
+func WithLogFields(extractors ...LogFieldExtractor) Option {
+	return func(s *Signer) {
+		s.logFields = append(s.logFields, extractors...)
+	}
+}
+
+// extractLogFields returns ctx with the fields of the Signer's LogFieldExtractors added to its tags.
+func (s *Signer) extractLogFields(ctx context.Context) context.Context {
+	fields := map[string]string{}
+	for _, extract := range s.logFields {
+		for k, v := range extract(ctx) {
+			fields[k] = v
+		}
+	}
+	for k, v := range RequestTags(ctx) {
+		fields[k] = v
+	}
+	if len(fields) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, requestTagsKey{}, fields)
+}
+
+// formatTags renders tags as space-separated key=value pairs, sorted by key.
+func formatTags(tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for k, v := range tags {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, " ")
+}
+
+// logf logs through the request's logger, or else the Signer's, appending the attempt and request tags of ctx.
+func (s *Signer) logf(ctx context.Context, format string, v ...interface{}) {
+	if attempt, max, ok := RequestAttempt(ctx); ok {
+		format += " [attempt %d/%d]"
+		v = append(v, attempt, max)
+	}
+	if tags := RequestTags(ctx); len(tags) > 0 {
+		format += " [%s]"
+		v = append(v, formatTags(tags))
+	}
+	if logger := requestLogger(ctx); logger != nil {
+		logger.Printf(ctx, format, v...)
+		return
+	}
+	s.logger.Printf(ctx, format, v...)
+}
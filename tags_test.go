@@ -0,0 +1,109 @@
+package aws_signing_client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+type recordingLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (rl *recordingLogger) Printf(ctx context.Context, format string, v ...interface{}) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.lines = append(rl.lines, fmt.Sprintf(format, v...))
+}
+
+type recordingHooks struct {
+	NopHooks
+	mu       sync.Mutex
+	requests []RequestTiming
+}
+
+func (rh *recordingHooks) OnRequest(ctx context.Context, timing RequestTiming) {
+	rh.mu.Lock()
+	defer rh.mu.Unlock()
+	rh.requests = append(rh.requests, timing)
+}
+
+//   _____
+//  |_   _|_ _  __ _ ___
+//    | |/ _` |/ _` / __|
+//    | | (_| | (_| \__ \
+//    |_|\__,_|\__, |___/
+//             |___/
+
+// TestWithRequestTagsMerges ensures that tags attached to a context are merged, with later values winning.
+func TestWithRequestTagsMerges(t *testing.T) {
+	ctx := WithRequestTags(context.Background(), map[string]string{"tenant": "acme", "team": "search"})
+	ctx = WithRequestTags(ctx, map[string]string{"tenant": "globex"})
+	if tags := RequestTags(ctx); tags["tenant"] != "globex" || tags["team"] != "search" {
+		t.Errorf("Unexpected tags: %v", tags)
+	}
+}
+
+// TestRequestTagsPropagated ensures that request tags reach log lines and hooks.
+func TestRequestTagsPropagated(t *testing.T) {
+	rl := &recordingLogger{}
+	rh := &recordingHooks{}
+	c, _ := New(v4.NewSigner(creds), &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return response(200, "", nil), nil
+	})}, "es", "us-east-1", rl, WithHooks(rh))
+
+	ctx := WithRequestTags(context.Background(), map[string]string{"tenant": "acme", "op": "search"})
+	req, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/", nil)
+	if _, err := c.Do(req); err != nil {
+		t.Fatalf("An unexpected error occurred while making a request: %s", err)
+	}
+
+	for _, l := range rl.lines {
+		if !strings.HasSuffix(l, "[op=search tenant=acme]") {
+			t.Errorf("Log line does not carry the request tags: %s", l)
+		}
+	}
+	switch {
+	case len(rh.requests) != 1:
+		t.Fatalf("Expected 1 hook event, got %d", len(rh.requests))
+	case rh.requests[0].Tags["tenant"] != "acme" || rh.requests[0].StatusCode != 200:
+		t.Errorf("Hook event does not carry the request tags: %+v", rh.requests[0])
+	}
+}
+
+type traceIDKey struct{}
+
+// TestWithLogFields ensures that extracted fields reach log lines and hooks, and that request tags take precedence.
+func TestWithLogFields(t *testing.T) {
+	rl := &recordingLogger{}
+	rh := &recordingHooks{}
+	c, _ := New(v4.NewSigner(creds), &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return response(200, "", nil), nil
+	})}, "es", "us-east-1", rl, WithHooks(rh), WithLogFields(
+		func(ctx context.Context) map[string]string {
+			id, _ := ctx.Value(traceIDKey{}).(string)
+			return map[string]string{"trace": id, "tenant": "default"}
+		},
+	))
+
+	ctx := WithRequestTags(context.WithValue(context.Background(), traceIDKey{}, "abc123"), map[string]string{"tenant": "acme"})
+	req, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/", nil)
+	if _, err := c.Do(req); err != nil {
+		t.Fatalf("An unexpected error occurred while making a request: %s", err)
+	}
+
+	for _, l := range rl.lines {
+		if !strings.HasSuffix(l, "[tenant=acme trace=abc123]") {
+			t.Errorf("Log line does not carry the extracted fields: %s", l)
+		}
+	}
+	if len(rh.requests) != 1 || rh.requests[0].Tags["trace"] != "abc123" {
+		t.Errorf("Hook event does not carry the extracted fields: %+v", rh.requests)
+	}
+}
@@ -0,0 +1,95 @@
+package aws_signing_client
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// RequestTiming is the timing breakdown of a signed request, reported to slow-request callbacks and Hooks.
+	RequestTiming struct {
+		Method  string
+		Host    string
+		Path    string
+		Service string
+		Region  string
+
+		// Start is the time the request was signed at. Every attempt is signed anew, once it has left the Signer's
+		// queues, so that time spent waiting never counts against the signature's validity.
+		Start time.Time
+		// InvocationID is the ID sent in the InvocationIDHeader of this attempt. It is empty unless
+		// WithInvocationIDs is used.
+		InvocationID string
+		// AccessKeyID is the access key ID the request was signed with, as found in its credential scope.
+		AccessKeyID string
+		// Attempt is the number of this attempt at sending the request, counting from 1, out of at most
+		// MaxAttempts. Both are 1 unless WithRetries is used.
+		Attempt     int
+		MaxAttempts int
+
+		// BodyRead is the time spent buffering the request body for hashing.
+		BodyRead time.Duration
+		// Hash is the time spent hashing the request body with WithHashWorkers or WithPayloadHasher. It is only set
+		// on the first attempt.
+		Hash time.Duration
+		// QueueWait is the time this attempt waited inside the Signer before it was signed: the backoff before a
+		// retry, the time spent refreshing credentials ahead of their expiry and the wait for a free hashing worker.
+		QueueWait time.Duration
+		// Sign is the time spent computing the signature.
+		Sign time.Duration
+		// Send is the time spent in the wrapped transport, until the response headers were received.
+		Send time.Duration
+		// Total is the time from the start of signing until the response headers were received.
+		Total time.Duration
+
+		// StatusCode is the response status code, or 0 if no response was received.
+		StatusCode int
+		// Err is the error returned by the wrapped transport, if any.
+		Err error
+
+		// Conn describes the connection the request was sent on. It is nil unless WithConnectionMetrics is used.
+		Conn *ConnectionInfo
+
+		// Tags are the request tags attached to the request's context with WithRequestTags.
+		Tags map[string]string
+	}
+
+	// SlowRequestCallback is called with the timing of a request that exceeded a slow-request threshold.
+	SlowRequestCallback func(ctx context.Context, timing RequestTiming)
+
+	slowThreshold struct {
+		d        time.Duration
+		signOnly bool
+		callback SlowRequestCallback
+	}
+)
+
+// WithSlowRequestThreshold calls callback, after the response headers are received, for every request whose total
+// latency exceeds d. The callback runs on the request's goroutine and should return quickly.
+func WithSlowRequestThreshold(d time.Duration, callback SlowRequestCallback) Option {
+	return func(s *Signer) {
+		s.slowRequests = append(s.slowRequests, slowThreshold{d: d, callback: callback})
+	}
+}
+
+// WithSlowSignThreshold is like WithSlowRequestThreshold, but compares d with the time spent signing only.
+func WithSlowSignThreshold(d time.Duration, callback SlowRequestCallback) Option {
+	return func(s *Signer) {
+		s.slowRequests = append(s.slowRequests, slowThreshold{d: d, signOnly: true, callback: callback})
+	}
+}
+
+func (s *Signer) reportTiming(ctx context.Context, timing RequestTiming) {
+	for _, h := range s.hooks {
+		h.OnRequest(ctx, timing)
+	}
+	for _, st := range s.slowRequests {
+		latency := timing.Total
+		if st.signOnly {
+			latency = timing.Sign
+		}
+		if latency > st.d {
+			st.callback(ctx, timing)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package aws_signing_client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+//   _____ _           _
+//  |_   _(_)_ __ ___ (_)_ __   __ _
+//    | | | | '_ ` _ \| | '_ \ / _` |
+//    | | | | | | | | | | | | | (_| |
+//    |_| |_|_| |_| |_|_|_| |_|\__, |
+//                             |___/
+
+func timingClient(t *testing.T, delay time.Duration, opts ...Option) *http.Client {
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		time.Sleep(delay)
+		return response(201, "", nil), nil
+	}, opts...)
+	return c
+}
+
+// TestSlowRequestThreshold ensures that slow requests are reported with their timing breakdown.
+func TestSlowRequestThreshold(t *testing.T) {
+	var timings []RequestTiming
+	c := timingClient(t, 20*time.Millisecond, WithSlowRequestThreshold(10*time.Millisecond, func(ctx context.Context, timing RequestTiming) {
+		timings = append(timings, timing)
+	}))
+	if _, err := c.Post("https://example.com/idx/_doc", "application/json", strings.NewReader("{}")); err != nil {
+		t.Fatalf("An unexpected error occurred while making a request: %s", err)
+	}
+
+	switch {
+	case len(timings) != 1:
+		t.Fatalf("Expected 1 slow request, got %d", len(timings))
+	case timings[0].Send < 20*time.Millisecond || timings[0].Total < timings[0].Send:
+		t.Errorf("Unexpected timing breakdown: %+v", timings[0])
+	case timings[0].StatusCode != 201 || timings[0].Method != "POST" || timings[0].Path != "/idx/_doc" || timings[0].Service != "es":
+		t.Errorf("Unexpected request details: %+v", timings[0])
+	}
+}
+
+// TestSlowRequestThresholdFastRequest ensures that requests under the threshold are not reported.
+func TestSlowRequestThresholdFastRequest(t *testing.T) {
+	c := timingClient(t, 0, WithSlowRequestThreshold(time.Minute, func(ctx context.Context, timing RequestTiming) {
+		t.Error("A fast request was reported as slow")
+	}), WithSlowSignThreshold(time.Minute, func(ctx context.Context, timing RequestTiming) {
+		t.Error("A fast signature was reported as slow")
+	}))
+	c.Get("https://example.com/")
+}
@@ -0,0 +1,92 @@
+package aws_signing_client
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+)
+
+// InsecureTLSError is an implementation of the error interface that is returned by New when the wrapped transport's
+// TLS configuration violates the policy set with WithMinTLSVersion or WithRequireTLSVerification.
+type InsecureTLSError struct {
+	// Reason describes the violation.
+	Reason string
+}
+
+// WithMinTLSVersion guarantees that signed requests are only sent over TLS version or later, e.g. tls.VersionTLS12.
+// The wrapped transport and those of WithHostTransport must be *http.Transports (or nil for http.DefaultTransport);
+// they are cloned with their minimum version raised if needed, so the caller's transports are left untouched. New
+// fails with an *InsecureTLSError for any other transport, since its TLS configuration cannot be verified.
+func WithMinTLSVersion(version uint16) Option {
+	return func(s *Signer) {
+		s.minTLSVersion = version
+	}
+}
+
+// WithRequireTLSVerification makes New fail with an *InsecureTLSError if the wrapped transport or one of
+// WithHostTransport skips verification of server certificates through InsecureSkipVerify, or is not an
+// *http.Transport whose configuration can be verified.
+func WithRequireTLSVerification() Option {
+	return func(s *Signer) {
+		s.requireTLSVerification = true
+	}
+}
+
+// enforceTLS applies the TLS policy to the wrapped transport and to the transports registered with
+// WithHostTransport once options have been applied.
+func (s *Signer) enforceTLS() error {
+	if s.minTLSVersion == 0 && !s.requireTLSVerification {
+		return nil
+	}
+	secured := map[*http.Transport]*http.Transport{}
+	rt, err := s.secureTransport(s.transport, secured)
+	if err != nil {
+		return err
+	}
+	s.transport = rt
+	for i, ht := range s.hostTransports {
+		rt, err := s.secureTransport(ht.transport, secured)
+		if err != nil {
+			err.(*InsecureTLSError).Reason += fmt.Sprintf(" for hosts matching '%s'", ht.pattern)
+			return err
+		}
+		s.hostTransports[i].transport = rt
+	}
+	return nil
+}
+
+// secureTransport returns rt, or a clone of it with its minimum TLS version raised, if it complies with the TLS
+// policy. Transports already cloned are looked up in secured, so that a transport registered more than once keeps a
+// single connection pool.
+func (s *Signer) secureTransport(rt http.RoundTripper, secured map[*http.Transport]*http.Transport) (http.RoundTripper, error) {
+	t, ok := rt.(*http.Transport)
+	if !ok {
+		return nil, &InsecureTLSError{Reason: fmt.Sprintf("the TLS configuration of a %T transport cannot be verified", rt)}
+	}
+	if clone, ok := secured[t]; ok {
+		return clone, nil
+	}
+	if s.requireTLSVerification && t.TLSClientConfig != nil && t.TLSClientConfig.InsecureSkipVerify {
+		return nil, &InsecureTLSError{Reason: "the transport skips certificate verification (InsecureSkipVerify)"}
+	}
+	if s.minTLSVersion != 0 && (t.TLSClientConfig == nil || t.TLSClientConfig.MinVersion < s.minTLSVersion) {
+		clone := t.Clone()
+		if clone.TLSClientConfig == nil {
+			clone.TLSClientConfig = &tls.Config{}
+		}
+		clone.TLSClientConfig.MinVersion = s.minTLSVersion
+		if clone.TLSClientConfig.MaxVersion != 0 && clone.TLSClientConfig.MaxVersion < s.minTLSVersion {
+			return nil, &InsecureTLSError{Reason: fmt.Sprintf("the transport's maximum TLS version %s is below the required %s",
+				tls.VersionName(clone.TLSClientConfig.MaxVersion), tls.VersionName(s.minTLSVersion))}
+		}
+		secured[t] = clone
+		return clone, nil
+	}
+	secured[t] = t
+	return t, nil
+}
+
+// Error implements the error interface.
+func (err *InsecureTLSError) Error() string {
+	return fmt.Sprintf("Insecure TLS configuration: %s. Cannot create client.", err.Reason)
+}
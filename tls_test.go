@@ -0,0 +1,93 @@
+package aws_signing_client
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+//   _____ _     ____
+//  |_   _| |   / ___|
+//    | | | |   \___ \
+//    | | | |___ ___) |
+//    |_| |_____|____/
+//
+
+// TestWithMinTLSVersion ensures that the minimum TLS version is raised on a copy of the wrapped transport.
+func TestWithMinTLSVersion(t *testing.T) {
+	orig := &http.Transport{TLSClientConfig: &tls.Config{MinVersion: tls.VersionTLS10}}
+	c, err := New(v4.NewSigner(creds), &http.Client{Transport: orig}, "es", "us-east-1", nil, WithMinTLSVersion(tls.VersionTLS12))
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+	tr := c.Transport.(*Signer).transport.(*http.Transport)
+	if tr.TLSClientConfig.MinVersion != tls.VersionTLS12 {
+		t.Errorf("Minimum TLS version was not raised: %x", tr.TLSClientConfig.MinVersion)
+	}
+	if orig.TLSClientConfig.MinVersion != tls.VersionTLS10 {
+		t.Error("The caller's transport was modified")
+	}
+
+	c, err = New(v4.NewSigner(creds), &http.Client{}, "es", "us-east-1", nil, WithMinTLSVersion(tls.VersionTLS13))
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+	if tr := c.Transport.(*Signer).transport.(*http.Transport); tr.TLSClientConfig.MinVersion != tls.VersionTLS13 {
+		t.Errorf("Minimum TLS version was not set on the default transport: %x", tr.TLSClientConfig.MinVersion)
+	}
+}
+
+// TestInsecureTLSError ensures that TLS configurations that violate the policy fail with an *InsecureTLSError.
+func TestInsecureTLSError(t *testing.T) {
+	cases := []struct {
+		transport http.RoundTripper
+		opt       Option
+	}{
+		{&http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}, WithRequireTLSVerification()},
+		{roundTripFunc(func(req *http.Request) (*http.Response, error) { return nil, nil }), WithRequireTLSVerification()},
+		{roundTripFunc(func(req *http.Request) (*http.Response, error) { return nil, nil }), WithMinTLSVersion(tls.VersionTLS12)},
+		{&http.Transport{TLSClientConfig: &tls.Config{MaxVersion: tls.VersionTLS11}}, WithMinTLSVersion(tls.VersionTLS12)},
+	}
+	for i, c := range cases {
+		_, err := New(v4.NewSigner(creds), &http.Client{Transport: c.transport}, "es", "us-east-1", nil, c.opt)
+		var tlsErr *InsecureTLSError
+		if !errors.As(err, &tlsErr) {
+			t.Errorf("Case %d: expected an *InsecureTLSError, got %v", i, err)
+		}
+	}
+
+	_, err := New(v4.NewSigner(creds), &http.Client{Transport: &http.Transport{}}, "es", "us-east-1", nil, WithRequireTLSVerification())
+	if err != nil {
+		t.Errorf("An unexpected error occurred for a verifying transport: %s", err)
+	}
+}
+
+// TestHostTransportTLS ensures that the TLS policy applies to the transports registered with WithHostTransport.
+func TestHostTransportTLS(t *testing.T) {
+	insecure := &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	_, err := New(v4.NewSigner(creds), &http.Client{Transport: &http.Transport{}}, "es", "us-east-1", nil,
+		WithHostTransport("*.corp.example.com", insecure), WithRequireTLSVerification())
+	var tlsErr *InsecureTLSError
+	if !errors.As(err, &tlsErr) || !strings.Contains(tlsErr.Reason, "*.corp.example.com") {
+		t.Errorf("Expected an *InsecureTLSError for the host transport, got %v", err)
+	}
+
+	old := &http.Transport{}
+	c, err := New(v4.NewSigner(creds), &http.Client{Transport: &http.Transport{}}, "es", "us-east-1", nil,
+		WithHostTransport("a.example.com", old), WithHostTransport("b.example.com", old), WithMinTLSVersion(tls.VersionTLS12))
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+	s := c.Transport.(*Signer)
+	a, b := s.transportFor("a.example.com").(*http.Transport), s.transportFor("b.example.com").(*http.Transport)
+	if a != b || a == old || a.TLSClientConfig.MinVersion != tls.VersionTLS12 {
+		t.Errorf("Expected both hosts to share a clone with TLS 1.2 or later, got %p and %p", a, b)
+	}
+	if old.TLSClientConfig != nil && old.TLSClientConfig.MinVersion != 0 {
+		t.Errorf("Expected the caller's transport to be left untouched")
+	}
+}
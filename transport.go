@@ -0,0 +1,99 @@
+package aws_signing_client
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+type (
+	// UnsupportedTransportError is an implementation of the error interface that is returned by New when an option
+	// that reconfigures the wrapped transport is given a transport other than an *http.Transport.
+	UnsupportedTransportError struct {
+		// Option is the name of the option, e.g. "WithResolver".
+		Option string
+		// Transport is the type of the wrapped transport.
+		Transport string
+	}
+
+	// hostTransport is a transport registered with WithHostTransport.
+	hostTransport struct {
+		pattern   string
+		transport http.RoundTripper
+	}
+
+	// transportConfig is a change an option makes to the wrapped *http.Transport.
+	transportConfig struct {
+		option string
+		apply  func(t *http.Transport) error
+	}
+)
+
+// WithHostTransport sends requests to hosts matching pattern through rt instead of the client's transport, e.g. a
+// transport with a proxy for on-premises targets while AWS endpoints are reached directly. A pattern is matched like
+// those of WithAllowedHosts. It may be given more than once; the first matching pattern wins. The transport is
+// selected before each request is signed, and redirect hops are matched again. Options that reconfigure the client's
+// transport, such as WithResolver, do not apply to rt, but WithMinTLSVersion and WithRequireTLSVerification do.
+func WithHostTransport(pattern string, rt http.RoundTripper) Option {
+	return func(s *Signer) {
+		s.hostTransports = append(s.hostTransports, hostTransport{pattern: strings.ToLower(strings.TrimSuffix(pattern, ".")), transport: rt})
+	}
+}
+
+// transportFor returns the transport requests to host, which must not include a port, are sent through.
+func (s *Signer) transportFor(host string) http.RoundTripper {
+	if len(s.hostTransports) > 0 {
+		host = strings.ToLower(strings.TrimSuffix(host, "."))
+		for _, ht := range s.hostTransports {
+			if matchHost(ht.pattern, host) {
+				return ht.transport
+			}
+		}
+	}
+	return s.transport
+}
+
+// transports returns the client's transport followed by every transport registered with WithHostTransport, each
+// once. Transports of types that cannot be compared, such as functions, are never considered the same.
+func (s *Signer) transports() []http.RoundTripper {
+	rts := []http.RoundTripper{s.transport}
+	for _, ht := range s.hostTransports {
+		seen := false
+		for _, rt := range rts {
+			if reflect.TypeOf(rt).Comparable() && reflect.TypeOf(rt) == reflect.TypeOf(ht.transport) && rt == ht.transport {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			rts = append(rts, ht.transport)
+		}
+	}
+	return rts
+}
+
+// configureTransport applies the changes requested by options to a clone of the wrapped transport, so that the
+// caller's transport is left untouched.
+func (s *Signer) configureTransport() error {
+	if len(s.transportConfigs) == 0 {
+		return nil
+	}
+	t, ok := s.transport.(*http.Transport)
+	if !ok {
+		return &UnsupportedTransportError{Option: s.transportConfigs[0].option, Transport: fmt.Sprintf("%T", s.transport)}
+	}
+	t = t.Clone()
+	for _, c := range s.transportConfigs {
+		if err := c.apply(t); err != nil {
+			return err
+		}
+	}
+	s.transport = t
+	return nil
+}
+
+// Error implements the error interface.
+func (err *UnsupportedTransportError) Error() string {
+	return fmt.Sprintf("%s requires an *http.Transport, but the client's transport is a %s. Cannot create client.", err.Option, err.Transport)
+}
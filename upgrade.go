@@ -0,0 +1,56 @@
+package aws_signing_client
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// UnsupportedRequestError is an implementation of the error interface that is returned by RoundTrip, before the
+// request is modified or sent, for CONNECT requests, whose tunneled traffic cannot be signed.
+type UnsupportedRequestError struct {
+	Method string
+	Reason string
+}
+
+// WithUnsignedUpgrades makes the Signer send CONNECT requests unsigned instead of returning an
+// UnsupportedRequestError, and send protocol upgrades unsigned instead of signing their handshake, e.g. for WebSocket
+// endpoints that authenticate by other means. Without it, upgrade handshakes such as the IAM-authorized $connect of
+// an API Gateway WebSocket API are signed like any other GET request.
+func WithUnsignedUpgrades() Option {
+	return func(s *Signer) {
+		s.passUpgrades = true
+	}
+}
+
+// unsignedReason returns why req cannot be signed, or why it is sent unsigned if passUpgrades is set, or "" if it is
+// signed.
+func unsignedReason(req *http.Request, passUpgrades bool) string {
+	switch {
+	case req.Method == http.MethodConnect:
+		return "CONNECT requests cannot be signed"
+	case passUpgrades && isUpgrade(req.Header):
+		return fmt.Sprintf("protocol upgrades to '%s' are not signed", req.Header.Get("Upgrade"))
+	}
+	return ""
+}
+
+// isUpgrade reports whether h requests a protocol upgrade.
+func isUpgrade(h http.Header) bool {
+	if h.Get("Upgrade") != "" {
+		return true
+	}
+	for _, v := range h.Values("Connection") {
+		for _, token := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Error implements the error interface.
+func (err *UnsupportedRequestError) Error() string {
+	return fmt.Sprintf("Unsupported %s request: %s. Refusing to send request.", err.Method, err.Reason)
+}
@@ -0,0 +1,68 @@
+package aws_signing_client
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+//   _   _                           _
+//  | | | |_ __   __ _ _ __ __ _  __| | ___
+//  | | | | '_ \ / _` | '__/ _` |/ _` |/ _ \
+//  | |_| | |_) | (_| | | | (_| | (_| |  __/
+//   \___/| .__/ \__, |_|  \__,_|\__,_|\___|
+//        |_|    |___/
+
+func upgradeRequests() []*http.Request {
+	connect, _ := http.NewRequest("CONNECT", "http://proxy.example.com:443", nil)
+	ws, _ := http.NewRequest("GET", "http://example.com/socket", nil)
+	ws.Header.Set("Connection", "keep-alive, Upgrade")
+	ws.Header.Set("Upgrade", "websocket")
+	h2c, _ := http.NewRequest("GET", "http://example.com/", nil)
+	h2c.Header.Set("Connection", "Upgrade")
+	return []*http.Request{connect, ws, h2c}
+}
+
+// TestUnsupportedRequestError ensures that CONNECT requests are refused before they are modified.
+func TestUnsupportedRequestError(t *testing.T) {
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		t.Errorf("Request to %s should not have been sent", req.URL)
+		return response(200, "", nil), nil
+	})
+	req := upgradeRequests()[0]
+	_, err := c.Transport.RoundTrip(req)
+	var unsupported *UnsupportedRequestError
+	switch {
+	case !errors.As(err, &unsupported) || unsupported.Method != req.Method:
+		t.Errorf("Expected an *UnsupportedRequestError for %s %s, got %v", req.Method, req.URL, err)
+	case req.URL.Scheme != "http" || req.Header.Get("Date") != "":
+		t.Errorf("Request %s %s was modified", req.Method, req.URL)
+	}
+}
+
+// TestSignedUpgrades ensures that protocol upgrade handshakes are signed by default.
+func TestSignedUpgrades(t *testing.T) {
+	c, sent := headerClient(t)
+	for _, req := range upgradeRequests()[1:] {
+		if _, err := c.Transport.RoundTrip(req); err != nil {
+			t.Fatalf("An unexpected error occurred: %s", err)
+		}
+		if !strings.Contains((*sent).Header.Get("Authorization"), "connection;") {
+			t.Errorf("Expected the handshake %s %s to be signed with its Connection header, got %q", req.Method, req.URL, (*sent).Header.Get("Authorization"))
+		}
+	}
+}
+
+// TestWithUnsignedUpgrades ensures that CONNECT requests and upgrades are sent unsigned when configured.
+func TestWithUnsignedUpgrades(t *testing.T) {
+	c, sent := headerClient(t, WithUnsignedUpgrades())
+	for _, req := range upgradeRequests() {
+		if _, err := c.Transport.RoundTrip(req); err != nil {
+			t.Fatalf("An unexpected error occurred: %s", err)
+		}
+		if (*sent).Header.Get("Authorization") != "" {
+			t.Errorf("Request %s %s was signed", req.Method, req.URL)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package aws_signing_client
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+const (
+	// DefaultUploadPartSize is the default number of bytes an Uploader sends per part. S3 requires every part but
+	// the last to be at least 5 MiB.
+	DefaultUploadPartSize = 8 << 20
+	// DefaultUploadConcurrency is the default number of parts an Uploader sends at once.
+	DefaultUploadConcurrency = 4
+	// DefaultUploadMaxAttempts is the default number of times an Uploader sends a request that keeps failing with a
+	// retryable error.
+	DefaultUploadMaxAttempts = 3
+	// MaxUploadParts is the maximum number of parts of a multipart upload.
+	MaxUploadParts = 10000
+)
+
+type (
+	// Uploader sends large objects to S3 or S3-compatible stores with multipart uploads over an *http.Client,
+	// typically one returned by New. The upload is initiated, the object is read in parts that are sent in parallel,
+	// each signed on its own with a Content-MD5 checksum, and the upload is completed. Requests that fail with a
+	// retryable error (see IsRetryable) are retried on their own, with a backoff between attempts. If the upload
+	// fails, it is aborted so that its parts are not billed.
+	Uploader struct {
+		// Client is the HTTP client used to send requests. If nil, http.DefaultClient is used.
+		Client *http.Client
+		// PartSize is the number of bytes per part. Zero means DefaultUploadPartSize. At most Concurrency parts
+		// are held in memory at once.
+		PartSize int64
+		// Concurrency is the number of parts sent at once. Zero means DefaultUploadConcurrency.
+		Concurrency int
+		// MaxAttempts is the number of times a request is sent before its failure is final. Zero means
+		// DefaultUploadMaxAttempts.
+		MaxAttempts int
+		// Backoff decides the delay between the attempts of a request. If nil, ExponentialBackoff is used.
+		Backoff Backoff
+	}
+
+	// UploadResult describes a completed multipart upload.
+	UploadResult struct {
+		Location string
+		ETag     string
+		UploadID string
+		// Parts is the number of parts the object was uploaded in.
+		Parts int
+	}
+
+	// MultipartUploadError is returned by Uploader.Upload when an initiated upload failed. Err is the first failure;
+	// AbortErr is set if the upload could not be aborted either, in which case its parts remain stored until
+	// aborted or expired by a lifecycle rule.
+	MultipartUploadError struct {
+		UploadID string
+		Err      error
+		AbortErr error
+	}
+
+	initiateMultipartUploadResult struct {
+		UploadID string `xml:"UploadId"`
+	}
+
+	completeMultipartUpload struct {
+		XMLName xml.Name       `xml:"CompleteMultipartUpload"`
+		Parts   []completePart `xml:"Part"`
+	}
+
+	completePart struct {
+		PartNumber int
+		ETag       string
+	}
+
+	completeMultipartUploadResult struct {
+		XMLName  xml.Name
+		Location string `xml:"Location"`
+		ETag     string `xml:"ETag"`
+	}
+)
+
+// NewUploader returns an Uploader that sends requests using the provided client.
+func NewUploader(client *http.Client) *Uploader {
+	return &Uploader{Client: client}
+}
+
+// Upload stores the content of r as the object at objectURL, e.g. "https://my-bucket.s3.us-east-1.amazonaws.com/key".
+// Headers in hdr, such as Content-Type or x-amz-server-side-encryption, are sent when the upload is initiated.
+// Errors that occur after the upload was initiated are returned as a *MultipartUploadError.
+func (u *Uploader) Upload(ctx context.Context, objectURL string, r io.Reader, hdr http.Header) (*UploadResult, error) {
+	base, err := url.Parse(objectURL)
+	if err != nil {
+		return nil, err
+	}
+	d, _, err := u.do(ctx, http.MethodPost, partURL(base, url.Values{"uploads": {""}}), nil, hdr)
+	if err != nil {
+		return nil, err
+	}
+	var init initiateMultipartUploadResult
+	if err := xml.Unmarshal(d, &init); err != nil {
+		return nil, fmt.Errorf("decoding CreateMultipartUpload response: %w", err)
+	}
+
+	parts, err := u.uploadParts(ctx, base, init.UploadID, r)
+	var result completeMultipartUploadResult
+	if err == nil {
+		body, _ := xml.Marshal(completeMultipartUpload{Parts: parts})
+		d, _, err = u.do(ctx, http.MethodPost, partURL(base, url.Values{"uploadId": {init.UploadID}}), body, nil)
+		if err == nil {
+			err = xml.Unmarshal(d, &result)
+		}
+	}
+	if err != nil {
+		uploadErr := &MultipartUploadError{UploadID: init.UploadID, Err: err}
+		_, _, uploadErr.AbortErr = u.do(context.WithoutCancel(ctx), http.MethodDelete, partURL(base, url.Values{"uploadId": {init.UploadID}}), nil, nil)
+		return nil, uploadErr
+	}
+	return &UploadResult{Location: result.Location, ETag: result.ETag, UploadID: init.UploadID, Parts: len(parts)}, nil
+}
+
+// uploadParts reads r in parts and sends them concurrently. The first failure cancels the parts in flight.
+func (u *Uploader) uploadParts(ctx context.Context, base *url.URL, uploadID string, r io.Reader) ([]completePart, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	partSize := u.PartSize
+	if partSize <= 0 {
+		partSize = DefaultUploadPartSize
+	}
+	concurrency := u.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultUploadConcurrency
+	}
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		parts    []completePart
+		once     sync.Once
+		firstErr error
+		slots    = make(chan struct{}, concurrency)
+	)
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+loop:
+	for n := 1; ; n++ {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			fail(ctx.Err())
+			break
+		}
+		buf := make([]byte, partSize)
+		size, err := io.ReadFull(r, buf)
+		switch {
+		case err == io.EOF && n > 1:
+			// The previous part was the last one. An empty object is uploaded as a single empty part.
+			<-slots
+			break loop
+		case err != nil && err != io.EOF && err != io.ErrUnexpectedEOF:
+			<-slots
+			fail(err)
+			break loop
+		case n > MaxUploadParts:
+			<-slots
+			fail(fmt.Errorf("the object does not fit in %d parts of %d bytes", MaxUploadParts, partSize))
+			break loop
+		}
+
+		wg.Add(1)
+		go func(n int, part []byte) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			sum := md5.Sum(part)
+			hdr := http.Header{"Content-Md5": {base64.StdEncoding.EncodeToString(sum[:])}}
+			q := url.Values{"partNumber": {strconv.Itoa(n)}, "uploadId": {uploadID}}
+			_, respHdr, err := u.do(ctx, http.MethodPut, partURL(base, q), part, hdr)
+			if err != nil {
+				fail(fmt.Errorf("uploading part %d: %w", n, err))
+				return
+			}
+			mu.Lock()
+			parts = append(parts, completePart{PartNumber: n, ETag: respHdr.Get("ETag")})
+			mu.Unlock()
+		}(n, buf[:size])
+		if size < len(buf) {
+			break
+		}
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].PartNumber < parts[j].PartNumber
+	})
+	return parts, nil
+}
+
+// do sends a signed request and returns the response body and headers, retrying retryable failures. S3 can report
+// a failed CompleteMultipartUpload in the body of a 200 response; such errors are returned as an *AWSError too, and
+// retried if their code is InternalError.
+func (u *Uploader) do(ctx context.Context, method, target string, body []byte, hdr http.Header) ([]byte, http.Header, error) {
+	maxAttempts := u.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultUploadMaxAttempts
+	}
+	client := u.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	var d []byte
+	var respHdr http.Header
+	err := retryCall(ctx, maxAttempts, u.Backoff, func() (err error) {
+		d, respHdr, err = u.send(ctx, client, method, target, body, hdr)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return d, respHdr, nil
+}
+
+// send makes a single attempt of a request made by do.
+func (u *Uploader) send(ctx context.Context, client *http.Client, method, target string, body []byte, hdr http.Header) ([]byte, http.Header, error) {
+	var rd io.Reader
+	if body != nil {
+		rd = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, rd)
+	if err != nil {
+		return nil, nil, err
+	}
+	for k, v := range hdr {
+		req.Header[k] = v
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	d, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, nil, responseError(resp, d)
+	}
+	if method == http.MethodPost && bytes.Contains(d, []byte("<Error>")) {
+		var root struct{ XMLName xml.Name }
+		if xml.Unmarshal(d, &root) == nil && root.XMLName.Local == "Error" {
+			return nil, nil, NewAWSError(resp, d)
+		}
+	}
+	return d, resp.Header, nil
+}
+
+// partURL returns base with the query parameters of a multipart upload request.
+func partURL(base *url.URL, q url.Values) string {
+	u := *base
+	u.RawQuery = EncodeQuery(q)
+	return u.String()
+}
+
+// Error implements the error interface.
+func (err *MultipartUploadError) Error() string {
+	if err.AbortErr != nil {
+		return fmt.Sprintf("Multipart upload %s failed: %s. It could not be aborted: %s", err.UploadID, err.Err, err.AbortErr)
+	}
+	return fmt.Sprintf("Multipart upload %s failed and was aborted: %s", err.UploadID, err.Err)
+}
+
+// Unwrap returns the error the upload failed with.
+func (err *MultipartUploadError) Unwrap() error {
+	return err.Err
+}
@@ -0,0 +1,150 @@
+package aws_signing_client
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// fakeMultipartStore implements the multipart upload API of S3 for a single object.
+type fakeMultipartStore struct {
+	mu       sync.Mutex
+	parts    map[int][]byte
+	attempts map[int]int
+	failPart func(n, attempt int) int
+	object   []byte
+	aborted  bool
+}
+
+func (f *fakeMultipartStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") == "" {
+		http.Error(w, "unsigned", http.StatusForbidden)
+		return
+	}
+	q := r.URL.Query()
+	body, _ := ioutil.ReadAll(r.Body)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case r.Method == http.MethodPost && q.Has("uploads"):
+		f.parts, f.attempts = map[int][]byte{}, map[int]int{}
+		fmt.Fprint(w, `<InitiateMultipartUploadResult><UploadId>upload-1</UploadId></InitiateMultipartUploadResult>`)
+	case r.Method == http.MethodPut && q.Get("uploadId") == "upload-1":
+		n, _ := strconv.Atoi(q.Get("partNumber"))
+		f.attempts[n]++
+		if f.failPart != nil {
+			if status := f.failPart(n, f.attempts[n]); status != 0 {
+				w.WriteHeader(status)
+				return
+			}
+		}
+		sum := md5.Sum(body)
+		if r.Header.Get("Content-Md5") != base64.StdEncoding.EncodeToString(sum[:]) {
+			http.Error(w, "<Error><Code>BadDigest</Code></Error>", http.StatusBadRequest)
+			return
+		}
+		f.parts[n] = body
+		w.Header().Set("ETag", fmt.Sprintf(`"etag-%d"`, n))
+	case r.Method == http.MethodPost && q.Get("uploadId") == "upload-1":
+		var doc completeMultipartUpload
+		xml.Unmarshal(body, &doc)
+		for i, p := range doc.Parts {
+			if p.PartNumber != i+1 || p.ETag != fmt.Sprintf(`"etag-%d"`, i+1) {
+				http.Error(w, "<Error><Code>InvalidPartOrder</Code></Error>", http.StatusBadRequest)
+				return
+			}
+			f.object = append(f.object, f.parts[p.PartNumber]...)
+		}
+		fmt.Fprint(w, `<CompleteMultipartUploadResult><Location>loc</Location><ETag>"final"</ETag></CompleteMultipartUploadResult>`)
+	case r.Method == http.MethodDelete && q.Get("uploadId") == "upload-1":
+		f.aborted = true
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+	}
+}
+
+// TestUploader ensures that an object is uploaded in signed, checksummed parts and that a failed part is retried on
+// its own.
+func TestUploader(t *testing.T) {
+	store := &fakeMultipartStore{failPart: func(n, attempt int) int {
+		if n == 2 && attempt == 1 {
+			return http.StatusServiceUnavailable
+		}
+		return 0
+	}}
+	srv := httptest.NewTLSServer(store)
+	defer srv.Close()
+
+	c, err := New(v4.NewSigner(creds), srv.Client(), "s3", "us-east-1", nil)
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+	}
+	object := []byte(strings.Repeat("0123456789", 25))
+	u := &Uploader{Client: c, PartSize: 100, Concurrency: 2, Backoff: constantBackoff(0)}
+	res, err := u.Upload(context.Background(), srv.URL+"/bucket/key", bytes.NewReader(object), nil)
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while uploading: %s", err)
+	}
+	if *res != (UploadResult{Location: "loc", ETag: `"final"`, UploadID: "upload-1", Parts: 3}) {
+		t.Errorf("Unexpected result: %+v", res)
+	}
+	if !bytes.Equal(store.object, object) {
+		t.Errorf("Expected the object to be assembled from its parts, got %q", store.object)
+	}
+	if store.attempts[1] != 1 || store.attempts[2] != 2 || store.attempts[3] != 1 {
+		t.Errorf("Expected only part 2 to be retried, got %v", store.attempts)
+	}
+}
+
+// TestUploaderEmpty ensures that an empty object is uploaded as a single empty part.
+func TestUploaderEmpty(t *testing.T) {
+	store := &fakeMultipartStore{}
+	srv := httptest.NewTLSServer(store)
+	defer srv.Close()
+
+	c, _ := New(v4.NewSigner(creds), srv.Client(), "s3", "us-east-1", nil)
+	res, err := NewUploader(c).Upload(context.Background(), srv.URL+"/bucket/key", bytes.NewReader(nil), nil)
+	if err != nil || res.Parts != 1 || len(store.parts[1]) != 0 {
+		t.Errorf("Expected a single empty part, got %+v and %v", res, err)
+	}
+}
+
+// TestUploaderAbort ensures that an upload whose part fails permanently is aborted.
+func TestUploaderAbort(t *testing.T) {
+	store := &fakeMultipartStore{failPart: func(n, attempt int) int {
+		if n == 2 {
+			return http.StatusForbidden
+		}
+		return 0
+	}}
+	srv := httptest.NewTLSServer(store)
+	defer srv.Close()
+
+	c, _ := New(v4.NewSigner(creds), srv.Client(), "s3", "us-east-1", nil)
+	u := &Uploader{Client: c, PartSize: 10, Backoff: constantBackoff(0)}
+	_, err := u.Upload(context.Background(), srv.URL+"/bucket/key", strings.NewReader(strings.Repeat("x", 50)), nil)
+	var uploadErr *MultipartUploadError
+	var awsErr *AWSError
+	switch {
+	case !errors.As(err, &uploadErr) || uploadErr.UploadID != "upload-1" || uploadErr.AbortErr != nil:
+		t.Errorf("Expected an aborted *MultipartUploadError, got %v", err)
+	case !errors.As(err, &awsErr) || awsErr.StatusCode != http.StatusForbidden:
+		t.Errorf("Expected the 403 of the part, got %v", err)
+	case !store.aborted || store.attempts[2] != 1:
+		t.Errorf("Expected the upload to be aborted after a single attempt of part 2, got %v attempts", store.attempts)
+	}
+}
@@ -0,0 +1,101 @@
+package aws_signing_client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// InvalidURLError is an implementation of the error interface that is returned by RoundTrip for requests whose URL
+// cannot be signed correctly.
+type InvalidURLError struct {
+	URL    string
+	Reason string
+}
+
+// normalizeURL rejects request URLs that would produce a signature the endpoint cannot verify, and removes parts
+// that are never sent but would otherwise corrupt the canonical request.
+func (s *Signer) normalizeURL(ctx context.Context, req *http.Request) error {
+	u := req.URL
+	switch {
+	case u == nil:
+		return &InvalidURLError{Reason: "the request has no URL"}
+	case u.Host == "":
+		return &InvalidURLError{URL: u.Redacted(), Reason: "the URL has no host"}
+	case u.User != nil:
+		return &InvalidURLError{URL: u.Redacted(), Reason: "the URL contains user information"}
+	}
+	for _, part := range []string{u.Host, u.Path, u.RawPath, u.RawQuery} {
+		if strings.IndexByte(part, 0) >= 0 {
+			return &InvalidURLError{URL: u.Redacted(), Reason: "the URL contains a NUL byte"}
+		}
+	}
+	if u.Fragment != "" || u.RawFragment != "" {
+		s.logf(ctx, "Removing fragment '%s' from URL before signing", u.EscapedFragment())
+		u.Fragment, u.RawFragment = "", ""
+	}
+	return nil
+}
+
+// WithStrictPathEncoding makes the Signer re-encode every request path exactly as SigV4 canonicalizes it before
+// signing: each byte outside the RFC 3986 unreserved set, other than '/', is percent-encoded, so that spaces, '+',
+// '*' and non-ASCII characters are signed and sent the same way. For every service but S3, "." and ".." segments
+// are also removed, as AWS does when verifying the signature; S3 signs object keys as given.
+func WithStrictPathEncoding() Option {
+	return func(s *Signer) {
+		s.strictPaths = true
+	}
+}
+
+// encodePath re-encodes the path of u for service as described by WithStrictPathEncoding. Each segment is decoded
+// and encoded again on its own, so that encoded slashes stay part of their segment.
+func encodePath(u *url.URL, service string) {
+	segments := strings.Split(u.EscapedPath(), "/")
+	for i, seg := range segments {
+		if decoded, err := url.PathUnescape(seg); err == nil {
+			segments[i] = decoded
+		}
+	}
+	if service != "s3" {
+		segments = removeDotSegments(segments)
+	}
+	if len(segments) < 2 {
+		segments = []string{"", ""}
+	}
+	encoded := make([]string, len(segments))
+	for i, seg := range segments {
+		encoded[i] = escapePath(seg, true)
+	}
+	u.Path, u.RawPath = strings.Join(segments, "/"), strings.Join(encoded, "/")
+}
+
+// removeDotSegments removes the "." and ".." segments of a path split at '/', following RFC 3986 section 5.2.4.
+func removeDotSegments(segments []string) []string {
+	out := make([]string, 0, len(segments))
+	for i, seg := range segments {
+		switch seg {
+		case ".":
+		case "..":
+			if len(out) > 1 {
+				out = out[:len(out)-1]
+			}
+		default:
+			out = append(out, seg)
+			continue
+		}
+		if i == len(segments)-1 {
+			out = append(out, "")
+		}
+	}
+	return out
+}
+
+// Error implements the error interface.
+func (err *InvalidURLError) Error() string {
+	if err.URL == "" {
+		return fmt.Sprintf("Invalid request URL: %s. Refusing to send request.", err.Reason)
+	}
+	return fmt.Sprintf("Invalid request URL '%s': %s. Refusing to send request.", err.URL, err.Reason)
+}
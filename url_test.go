@@ -0,0 +1,125 @@
+package aws_signing_client
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+//   _   _ ____  _
+//  | | | |  _ \| |
+//  | | | | |_) | |
+//  | |_| |  _ <| |___
+//   \___/|_| \_\_____|
+//
+
+// TestInvalidURLError ensures that malformed URLs are refused before they are signed.
+func TestInvalidURLError(t *testing.T) {
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		t.Errorf("Request to %s should not have been sent", req.URL)
+		return response(200, "", nil), nil
+	})
+
+	for _, u := range []*url.URL{
+		{Scheme: "https", Path: "/index"},
+		{Scheme: "https", Host: "example.com", User: url.UserPassword("user", "secret"), Path: "/"},
+		{Scheme: "https", Host: "example.com", Path: "/index\x00"},
+		{Scheme: "https", Host: "example.com", Path: "/", RawQuery: "q=a\x00b"},
+	} {
+		_, err := c.Transport.RoundTrip(&http.Request{Method: "GET", URL: u, Header: http.Header{}})
+		var urlErr *InvalidURLError
+		switch {
+		case !errors.As(err, &urlErr):
+			t.Errorf("Expected an *InvalidURLError for %#v, got %v", u, err)
+		case strings.Contains(err.Error(), "secret"):
+			t.Errorf("The error leaks user information: %s", err)
+		}
+	}
+}
+
+// TestFragmentRemoved ensures that fragments are removed from the URL before signing.
+func TestFragmentRemoved(t *testing.T) {
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		if req.URL.Fragment != "" || req.URL.RawFragment != "" {
+			t.Errorf("Fragment was sent: %s", req.URL)
+		}
+		return response(200, "", nil), nil
+	})
+
+	req, _ := http.NewRequest("GET", "https://example.com/index/_search#results", nil)
+	if _, err := c.Transport.RoundTrip(req); err != nil {
+		t.Errorf("An unexpected error occurred: %s", err)
+	}
+}
+
+// TestStrictPathEncoding runs a corpus of tricky paths through WithStrictPathEncoding, checking the path that is sent
+// and the path that is signed for S3 and another service.
+func TestStrictPathEncoding(t *testing.T) {
+	for _, tc := range []struct {
+		path, rawPath, sent, signed, sentS3 string
+	}{
+		{"", "", "/", "/", "/"},
+		{"/", "", "/", "/", "/"},
+		{"/my docs/file.txt", "", "/my%20docs/file.txt", "/my%2520docs/file.txt", ""},
+		{"/a+b", "", "/a%2Bb", "/a%252Bb", ""},
+		{"/a*b", "", "/a%2Ab", "/a%252Ab", ""},
+		{"/~user/file-1_2.txt", "", "/~user/file-1_2.txt", "/~user/file-1_2.txt", ""},
+		{"/café/日本", "", "/caf%C3%A9/%E6%97%A5%E6%9C%AC", "/caf%25C3%25A9/%25E6%2597%25A5%25E6%259C%25AC", ""},
+		{"/a,b;c=d&e", "", "/a%2Cb%3Bc%3Dd%26e", "/a%252Cb%253Bc%253Dd%2526e", ""},
+		{"/100%", "", "/100%25", "/100%2525", ""},
+		{"/a//b/", "", "/a//b/", "/a//b/", ""},
+		{"/a/./b/../c", "", "/a/c", "/a/c", "/a/./b/../c"},
+		{"/a/b/..", "", "/a/", "/a/", "/a/b/.."},
+		{"/../a", "", "/a", "/a", "/../a"},
+		{"/a/b", "/a%2Fb", "/a%2Fb", "/a%252Fb", ""},
+	} {
+		for _, service := range []string{"es", "s3"} {
+			var sent, signed string
+			c, err := New(v4.NewSigner(creds), &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				sent = req.URL.EscapedPath()
+				signed = strings.Split(canonicalRequest(req, nil), "\n")[1]
+				return response(200, "", nil), nil
+			})}, service, "us-east-1", nil, WithStrictPathEncoding())
+			if err != nil {
+				t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+			}
+			u := &url.URL{Scheme: "https", Host: "example.com", Path: tc.path}
+			if tc.rawPath != "" {
+				u.RawPath = tc.rawPath
+			}
+			if _, err := c.Transport.RoundTrip(&http.Request{Method: "GET", URL: u, Header: http.Header{}}); err != nil {
+				t.Fatalf("An unexpected error occurred: %s", err)
+			}
+			wantSent, wantSigned := tc.sent, tc.signed
+			if service == "s3" && tc.sentS3 != "" {
+				wantSent, wantSigned = tc.sentS3, escapePath(tc.sentS3, false)
+			}
+			if sent != wantSent || signed != wantSigned {
+				t.Errorf("%s %q: sent %q and signed %q, want %q and %q", service, tc.path, sent, signed, wantSent, wantSigned)
+			}
+		}
+	}
+}
+
+// TestEscapePath ensures that paths are escaped as aws-sdk-go's rest.EscapePath does, which the nosdkrest build tag
+// relies on.
+func TestEscapePath(t *testing.T) {
+	for _, tc := range []struct {
+		path      string
+		encodeSep bool
+		want      string
+	}{
+		{"/a-b_c.d~e/f", false, "/a-b_c.d~e/f"},
+		{"/a b+c*d,e", false, "/a%20b%2Bc%2Ad%2Ce"},
+		{"/a/b", true, "%2Fa%2Fb"},
+		{"/é%", false, "/%C3%A9%25"},
+	} {
+		if got := escapePath(tc.path, tc.encodeSep); got != tc.want {
+			t.Errorf("escapePath(%q, %t) = %q, want %q", tc.path, tc.encodeSep, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package aws_signing_client
+
+import (
+	"context"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+const (
+	// ModulePath is the module path of this package.
+	ModulePath = "github.com/Nextdoor/aws_signing_client"
+	// UserAgentName is the product name WithUserAgent adds to the User-Agent header.
+	UserAgentName = "aws_signing_client"
+)
+
+var (
+	versionOnce sync.Once
+	version     string
+)
+
+// Version returns the version of this package as recorded in the build information of the running binary, e.g.
+// "v1.4.0", or "(devel)" if it is unknown, e.g. in tests or when the package is built as the main module.
+func Version() string {
+	versionOnce.Do(func() {
+		version = "(devel)"
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		for _, dep := range info.Deps {
+			if dep.Path == ModulePath {
+				if dep.Replace != nil && dep.Replace.Version != "" {
+					dep = dep.Replace
+				}
+				version = dep.Version
+				return
+			}
+		}
+	})
+	return version
+}
+
+// WithUserAgent appends product, e.g. "indexer/2.1", followed by "aws_signing_client/<Version>" to the User-Agent
+// header of every request, so that AWS-side analysis such as CloudTrail and access logs can attribute traffic to the
+// caller and to this package. product may be empty to add only the package's token. The header is not covered by
+// the signature. Redirect hops and retries are not appended to twice.
+func WithUserAgent(product string) Option {
+	token := UserAgentName + "/" + Version()
+	if product != "" {
+		token = product + " " + token
+	}
+	return WithHeaderInjector(func(ctx context.Context, req *http.Request) {
+		ua := req.Header.Get("User-Agent")
+		switch {
+		case strings.Contains(ua, token):
+		case ua == "":
+			req.Header.Set("User-Agent", token)
+		default:
+			req.Header.Set("User-Agent", ua+" "+token)
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package aws_signing_client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// MaxValidatedBodySize is the number of bytes of a response body passed to ResponseValidator.Body.
+const MaxValidatedBodySize = 1 << 20
+
+type (
+	// ResponseValidator describes the responses expected from an endpoint, e.g. during a canary deployment of a
+	// downstream domain. Responses that fail a check are reported to Hooks as an Anomaly. Nil checks accept every
+	// response.
+	ResponseValidator struct {
+		// Name identifies the validator in Anomaly reports.
+		Name string
+		// Status reports whether a status code is expected.
+		Status func(code int) bool
+		// Header reports whether response headers are expected.
+		Header func(h http.Header) bool
+		// Body reports whether a response body, of which at most MaxValidatedBodySize bytes are passed, is
+		// expected.
+		Body func(body []byte) bool
+	}
+
+	// Anomaly describes a response that failed a check of a ResponseValidator, as passed to Hooks.OnAnomaly.
+	Anomaly struct {
+		// Validator is the name of the ResponseValidator.
+		Validator string
+		// Check is the check that failed: "status", "header" or "body".
+		Check      string
+		Method     string
+		Host       string
+		Path       string
+		StatusCode int
+	}
+
+	// validatingBody captures the start of a response body as the caller reads it, and runs the body checks once
+	// it is closed.
+	validatingBody struct {
+		io.ReadCloser
+		once     sync.Once
+		buf      bytes.Buffer
+		validate func(body []byte)
+	}
+)
+
+// WithResponseValidator checks every response received for a signed request against v, and reports the checks it
+// fails to Hooks.OnAnomaly. The caller receives the response unchanged: status and header checks run before
+// RoundTrip returns, and the body check runs on what the caller reads of the body, once it closes it. It may be
+// given more than once.
+func WithResponseValidator(v ResponseValidator) Option {
+	return func(s *Signer) {
+		s.validators = append(s.validators, v)
+	}
+}
+
+// validateResponse runs the ResponseValidators on resp, the response to req.
+func (s *Signer) validateResponse(ctx context.Context, req *http.Request, resp *http.Response) {
+	anomaly := func(v ResponseValidator, check string) {
+		a := Anomaly{Validator: v.Name, Check: check, Method: req.Method, Host: req.URL.Host, Path: req.URL.Path, StatusCode: resp.StatusCode}
+		s.logf(ctx, "Response failed the %s check of validator '%s'.", check, v.Name)
+		for _, h := range s.hooks {
+			h.OnAnomaly(ctx, a)
+		}
+	}
+	var bodyChecks []ResponseValidator
+	for _, v := range s.validators {
+		switch {
+		case v.Status != nil && !v.Status(resp.StatusCode):
+			anomaly(v, "status")
+		case v.Header != nil && !v.Header(resp.Header):
+			anomaly(v, "header")
+		case v.Body != nil:
+			bodyChecks = append(bodyChecks, v)
+		}
+	}
+	if len(bodyChecks) == 0 {
+		return
+	}
+	resp.Body = &validatingBody{ReadCloser: resp.Body, validate: func(body []byte) {
+		for _, v := range bodyChecks {
+			if !v.Body(body) {
+				anomaly(v, "body")
+			}
+		}
+	}}
+}
+
+// Read implements io.Reader.
+func (vb *validatingBody) Read(p []byte) (int, error) {
+	n, err := vb.ReadCloser.Read(p)
+	if room := MaxValidatedBodySize - vb.buf.Len(); room > 0 {
+		if n < room {
+			room = n
+		}
+		vb.buf.Write(p[:room])
+	}
+	return n, err
+}
+
+// Close implements io.Closer.
+func (vb *validatingBody) Close() error {
+	err := vb.ReadCloser.Close()
+	vb.once.Do(func() { vb.validate(vb.buf.Bytes()) })
+	return err
+}
@@ -0,0 +1,76 @@
+package aws_signing_client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type anomalyHooks struct {
+	NopHooks
+	anomalies []string
+}
+
+func (ah *anomalyHooks) OnAnomaly(ctx context.Context, a Anomaly) {
+	ah.anomalies = append(ah.anomalies, fmt.Sprintf("%s:%s:%d", a.Validator, a.Check, a.StatusCode))
+}
+
+// TestWithResponseValidator ensures that responses failing a check are reported without changing what the caller
+// receives.
+func TestWithResponseValidator(t *testing.T) {
+	ah := &anomalyHooks{}
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		status, _ := map[string]int{"/ok": 200, "/missing": 404}[req.URL.Path]
+		header := http.Header{"X-Version": {"2"}}
+		if req.URL.Path == "/old" {
+			status, header = 200, http.Header{"X-Version": {"1"}}
+		}
+		return &http.Response{StatusCode: status, Header: header, Body: ioutil.NopCloser(strings.NewReader(`{"hits":` + req.URL.Path[1:] + `}`))}, nil
+	}, WithHooks(ah),
+		WithResponseValidator(ResponseValidator{
+			Name:   "canary",
+			Status: func(code int) bool { return code < 500 },
+			Header: func(h http.Header) bool { return h.Get("X-Version") == "2" },
+		}),
+		WithResponseValidator(ResponseValidator{
+			Name: "shape",
+			Body: func(body []byte) bool { return bytes.Contains(body, []byte("ok")) },
+		}))
+
+	for _, path := range []string{"/ok", "/old", "/missing"} {
+		resp, err := c.Get("https://example.com" + path)
+		if err != nil {
+			t.Fatalf("An unexpected error occurred while making a request: %s", err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != `{"hits":`+path[1:]+`}` {
+			t.Errorf("%s: the body was changed: %q", path, body)
+		}
+	}
+	if got := fmt.Sprint(ah.anomalies); got != "[canary:header:200 shape:body:200 shape:body:404]" {
+		t.Errorf("Unexpected anomalies: %s", got)
+	}
+}
+
+// TestResponseValidatorErrorParsing ensures that a body validator does not hide the *AWSError of a response.
+func TestResponseValidatorErrorParsing(t *testing.T) {
+	c, _ := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+		return response(http.StatusBadRequest, `{"__type":"ThrottlingException","message":"Rate exceeded"}`, nil), nil
+	}, WithErrorParsing(), WithResponseValidator(ResponseValidator{
+		Name: "shape",
+		Body: func(body []byte) bool { return true },
+	}))
+	resp, err := c.Get("https://example.com/")
+	if err != nil {
+		t.Fatalf("An unexpected error occurred while making a request: %s", err)
+	}
+	defer resp.Body.Close()
+	if awsErr := ResponseError(resp); awsErr == nil || awsErr.Code != "ThrottlingException" || !IsThrottle(resp, nil) {
+		t.Errorf("Expected a throttling *AWSError, got %v", awsErr)
+	}
+}
@@ -0,0 +1,74 @@
+package aws_signing_client
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// TestWithVendorProfile ensures that the transformations of a vendor profile are applied before signing.
+func TestWithVendorProfile(t *testing.T) {
+	var sent *http.Request
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent = req
+		return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+	})
+
+	for _, tc := range []struct {
+		profile               VendorProfile
+		host, path, scope     string
+		payload, checksumAlgo string
+	}{
+		{ProfileMinIO, "minio.internal:9000", "/bucket/a+b", "/us-east-1/s3/", UnsignedPayload, "CRC32"},
+		{ProfileCeph, "minio.internal:9000", "/bucket/a%2Bb", "/eu-west-1/s3/", "", ""},
+		{VendorProfile{StripPort: true}, "minio.internal", "/bucket/a+b", "/eu-west-1/s3/", "", "CRC32"},
+	} {
+		c, err := New(v4.NewSigner(creds), &http.Client{Transport: transport}, "s3", "eu-west-1", nil, WithVendorProfile(tc.profile))
+		if err != nil {
+			t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+		}
+		req, _ := http.NewRequest(http.MethodPut, "https://minio.internal:9000/bucket/a+b", strings.NewReader("data"))
+		req.Header.Set("X-Amz-Sdk-Checksum-Algorithm", "CRC32")
+		req.Header.Set("X-Amz-Checksum-Crc32", "AAAAAA==")
+		if _, err := c.Do(req); err != nil {
+			t.Fatalf("An unexpected error occurred while making a request: %s", err)
+		}
+
+		host := sent.Host
+		if host == "" {
+			host = sent.URL.Host
+		}
+		switch {
+		case host != tc.host || sent.URL.Host != "minio.internal:9000":
+			t.Errorf("%+v: expected Host %s, got %s for %s", tc.profile, tc.host, host, sent.URL.Host)
+		case sent.URL.EscapedPath() != tc.path:
+			t.Errorf("%+v: expected path %s, got %s", tc.profile, tc.path, sent.URL.EscapedPath())
+		case !strings.Contains(sent.Header.Get("Authorization"), tc.scope):
+			t.Errorf("%+v: expected a signature for %s, got %s", tc.profile, tc.scope, sent.Header.Get("Authorization"))
+		case tc.payload != "" && sent.Header.Get("X-Amz-Content-Sha256") != tc.payload:
+			t.Errorf("%+v: expected payload hash %s, got %s", tc.profile, tc.payload, sent.Header.Get("X-Amz-Content-Sha256"))
+		case sent.Header.Get("X-Amz-Sdk-Checksum-Algorithm") != tc.checksumAlgo || (tc.checksumAlgo == "") != (sent.Header.Get("X-Amz-Checksum-Crc32") == ""):
+			t.Errorf("%+v: unexpected checksum headers %v", tc.profile, sent.Header)
+		}
+	}
+}
+
+// TestVendorProfileSigningRegion ensures that WithSigningRegion takes precedence over the region of a profile in
+// either order.
+func TestVendorProfileSigningRegion(t *testing.T) {
+	for _, opts := range [][]Option{
+		{WithSigningRegion("eu-west-1"), WithVendorProfile(ProfileMinIO)},
+		{WithVendorProfile(ProfileMinIO), WithSigningRegion("eu-west-1")},
+	} {
+		c, err := New(v4.NewSigner(creds), &http.Client{}, "s3", "us-west-2", nil, opts...)
+		if err != nil {
+			t.Fatalf("An unexpected error occurred while creating a new client: %s", err)
+		}
+		if region := c.Transport.(*Signer).signingRegionOverride; region != "eu-west-1" {
+			t.Errorf("Expected requests to be signed for eu-west-1, got %s", region)
+		}
+	}
+}
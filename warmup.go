@@ -0,0 +1,80 @@
+package aws_signing_client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Warmup prepares the Signer for its first requests, so that they do not pay for a cold start after a deploy. It
+// signs a request for every expected scope, which retrieves the credentials and lets signers that cache derived
+// signing keys derive them, and sends a signed HEAD request to "/" of each host, which resolves the host and leaves an
+// established TLS connection in the transport's idle pool. The responses are discarded; a 403 or 404 warms up just
+// as well. Without hosts, the endpoints set with WithRegions are warmed up, or only the credentials if there are
+// none. Hosts are warmed up concurrently, and their failures are returned joined.
+func (s *Signer) Warmup(ctx context.Context, hosts ...string) error {
+	if len(hosts) == 0 && s.regions != nil {
+		for _, r := range s.regions.regions {
+			hosts = append(hosts, r.Host)
+		}
+	}
+	if len(hosts) == 0 {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, "https://localhost/", nil)
+		if err != nil {
+			return err
+		}
+		return s.signWarmup(req)
+	}
+
+	errs := make([]error, len(hosts))
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Add(1)
+		go func(i int, host string) {
+			defer wg.Done()
+			errs[i] = s.warmup(ctx, host)
+		}(i, host)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// warmup signs and sends a HEAD request to host, discarding the response.
+func (s *Signer) warmup(ctx context.Context, host string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, "https://"+host+"/", nil)
+	if err != nil {
+		return err
+	}
+	if !s.hostAllowed(req.URL.Hostname()) {
+		return &DisallowedHostError{Host: req.URL.Hostname()}
+	}
+	if err := s.signWarmup(req); err != nil {
+		return err
+	}
+	if s.connMetrics != nil {
+		req = req.WithContext(s.connMetrics.trace(ctx, &connTrace{}))
+	}
+	resp, err := s.transportFor(req.URL.Hostname()).RoundTrip(req)
+	if err != nil {
+		s.logf(ctx, "Error while warming up connection to '%s': %s", host, err)
+		return err
+	}
+	DrainAndClose(resp)
+	s.logf(ctx, "Warmed up connection to '%s'.", host)
+	return nil
+}
+
+// signWarmup signs req for the scope requests to its host are signed for.
+func (s *Signer) signWarmup(req *http.Request) error {
+	sc := s.loadScope()
+	name, region := s.signingValues(sc)
+	if s.regions != nil {
+		if r := s.regions.regionFor(req.URL.Host); r != nil {
+			region = r.Region
+		}
+	}
+	_, err := sc.signer.Sign(req, nil, name, region, time.Now())
+	return err
+}